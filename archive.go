@@ -0,0 +1,423 @@
+package nef
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/snivilised/nefilim/internal/third/lo"
+)
+
+// Archive writes the tree under root in src to w, as an archive of the
+// format specified. The paths of the entries are relative to root and
+// the mode and modification time of every item is recorded.
+func Archive(src ReaderFS, root string, w io.Writer, format ArchiveFormat) error {
+	switch format {
+	case ArchiveZip:
+		return archiveZip(src, root, w)
+
+	case ArchiveTar:
+		return archiveTar(src, root, w)
+
+	case ArchiveTarGz:
+		compressor := gzip.NewWriter(w)
+
+		if err := archiveTar(src, root, compressor); err != nil {
+			return err
+		}
+
+		return compressor.Close()
+
+	case ArchiveUnknown:
+	}
+
+	return fmt.Errorf("unsupported archive format: %q", format)
+}
+
+func archiveZip(src ReaderFS, root string, w io.Writer) error {
+	writer := zip.NewWriter(w)
+
+	err := walkTree(src, root, "", func(path, rel string, entry fs.DirEntry) error {
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+
+		header.Name = rel
+
+		if info.IsDir() {
+			header.Name += separatorStr
+			_, err = writer.CreateHeader(header)
+
+			return err
+		}
+
+		header.Method = zip.Deflate
+		content, err := writer.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		return copyContent(src, path, content)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+func archiveTar(src ReaderFS, root string, w io.Writer) error {
+	writer := tar.NewWriter(w)
+
+	err := walkTree(src, root, "", func(path, rel string, entry fs.DirEntry) error {
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		header.Name = lo.Ternary(info.IsDir(), rel+separatorStr, rel)
+
+		if err := writer.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		return copyContent(src, path, writer)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+func copyContent(src ReaderFS, path string, w io.Writer) error {
+	file, err := src.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck // ok, read only
+
+	_, err = io.Copy(w, file)
+
+	return err
+}
+
+// Extract extracts the archive read from r into the directory dir of dst;
+// the format of the archive is detected from its content. Every entry
+// must have a path that is valid according to fs.ValidPath (a leading
+// "./" is permitted), so an entry that would escape dir (zip-slip) is
+// rejected with an invalid path error, before anything is written.
+//
+// Existing files are only replaced if dst permits it, ie if Create
+// succeeds on the existing file, so a relative file system created without
+// overwrite results in fs.ErrExist. The content replacing an existing file
+// is written to a temporary sibling first, which is then renamed over it,
+// so that a failed write does not lose the existing file. Permissions are applied subject to
+// the umask and modification times are preserved if dst implements
+// ChangeTimesFS.
+func Extract(r io.Reader, dst WriterFS, dir string) error {
+	reader := bufio.NewReader(r)
+	header, _ := reader.Peek(tarMagicOffset + len("ustar") + 1)
+
+	var (
+		entries []*extractEntry
+		err     error
+	)
+
+	switch DetectArchiveFormat(header) {
+	case ArchiveZip:
+		entries, err = zipEntries(reader)
+
+	case ArchiveTar:
+		entries, err = tarEntries(reader)
+
+	case ArchiveTarGz:
+		decompressor, gzErr := gzip.NewReader(reader)
+		if gzErr != nil {
+			return gzErr
+		}
+		defer decompressor.Close() //nolint:errcheck // ok, read only
+
+		entries, err = tarEntries(decompressor)
+
+	case ArchiveUnknown:
+		err = errors.New("unrecognised archive format")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	x := &extractor{
+		dst: dst,
+		dir: dir,
+	}
+
+	return x.extract(entries)
+}
+
+// extractEntry is a validated archive entry, whose content has been read,
+// so that all entries are validated before any are extracted.
+type extractEntry struct {
+	name    string
+	mode    fs.FileMode
+	modTime time.Time
+	content []byte
+}
+
+func newExtractEntry(name string, info fs.FileInfo, content []byte) (*extractEntry, error) {
+	clean := strings.TrimSuffix(strings.TrimPrefix(name, "./"), separatorStr)
+
+	if clean == "" || clean == "." {
+		return nil, nil //nolint:nilnil // ok, the root is not extracted
+	}
+
+	if !fs.ValidPath(clean) {
+		return nil, NewInvalidPathError("Extract", name)
+	}
+
+	return &extractEntry{
+		name:    clean,
+		mode:    info.Mode(),
+		modTime: info.ModTime(),
+		content: content,
+	}, nil
+}
+
+func zipEntries(r io.Reader) ([]*extractEntry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*extractEntry, 0, len(reader.File))
+
+	for _, file := range reader.File {
+		info := file.FileInfo()
+
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			continue
+		}
+
+		var content []byte
+
+		if !info.IsDir() {
+			if content, err = readZipFile(file); err != nil {
+				return nil, err
+			}
+		}
+
+		entry, err := newExtractEntry(file.Name, info, content)
+		if err != nil {
+			return nil, err
+		}
+
+		if entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close() //nolint:errcheck // ok, read only
+
+	return io.ReadAll(reader)
+}
+
+func tarEntries(r io.Reader) ([]*extractEntry, error) {
+	reader := tar.NewReader(r)
+	entries := []*extractEntry{}
+
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeDir && header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := newExtractEntry(header.Name, header.FileInfo(), content)
+		if err != nil {
+			return nil, err
+		}
+
+		if entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+}
+
+// extractTempSuffix is appended to the name of the temporary file, that
+// receives the content of an entry replacing an existing file
+const extractTempSuffix = ".nef-extract"
+
+type extractor struct {
+	dst WriterFS
+	dir string
+}
+
+func (x *extractor) target(rel string) string {
+	if rel == "." {
+		return lo.Ternary(x.dir == "" && x.dst.IsRelative(), ".", x.dir)
+	}
+
+	return joinUnder(x.dst, x.dir, rel)
+}
+
+func (x *extractor) extract(entries []*extractEntry) error {
+	if root := x.target("."); root != "." {
+		if err := x.dst.MakeDirAll(root, fs.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	directories := []*extractEntry{}
+
+	for _, entry := range entries {
+		if entry.mode.IsDir() {
+			if err := x.dst.MakeDirAll(x.target(entry.name), entry.mode.Perm()); err != nil {
+				return err
+			}
+
+			directories = append(directories, entry)
+
+			continue
+		}
+
+		if err := x.file(entry); err != nil {
+			return err
+		}
+	}
+
+	// the modification time of a directory changes as its children are
+	// extracted, so directory times are set last, deepest first.
+	slices.SortFunc(directories, func(a, b *extractEntry) int {
+		return strings.Count(b.name, separatorStr) - strings.Count(a.name, separatorStr)
+	})
+
+	for _, entry := range directories {
+		if err := x.chtimes(x.target(entry.name), entry.modTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (x *extractor) file(entry *extractEntry) error {
+	target := x.target(entry.name)
+
+	if parent := path.Dir(entry.name); parent != "." {
+		if err := x.dst.MakeDirAll(x.target(parent), fs.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	if !x.dst.FileExists(target) {
+		if err := x.dst.WriteFile(target, entry.content, entry.mode.Perm()); err != nil {
+			return err
+		}
+
+		return x.chtimes(target, entry.modTime)
+	}
+
+	// an existing file is replaced by a temporary sibling, that receives
+	// the content first, so that a failed write leaves the file intact.
+	dir, name := x.dst.Calc().Split(target)
+	temp := dir + "." + name + extractTempSuffix
+
+	if err := x.dst.WriteFile(temp, entry.content, entry.mode.Perm()); err != nil {
+		_ = x.dst.Remove(temp)
+
+		return err
+	}
+
+	if err := x.replace(temp, target); err != nil {
+		_ = x.dst.Remove(temp)
+
+		return err
+	}
+
+	return x.chtimes(target, entry.modTime)
+}
+
+// replace renames temp over the existing file target, if dst permits it
+// to be overwritten, ie if Create succeeds on it. The check is made once
+// the content has been written, immediately before the rename.
+func (x *extractor) replace(temp, target string) error {
+	file, err := x.dst.Create(target)
+	if err != nil {
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return x.dst.Rename(temp, target)
+}
+
+func (x *extractor) chtimes(target string, modTime time.Time) error {
+	if changer, ok := x.dst.(ChangeTimesFS); ok && !modTime.IsZero() {
+		return changer.Chtimes(target, modTime, modTime)
+	}
+
+	return nil
+}
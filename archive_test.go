@@ -0,0 +1,204 @@
+package nef_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing/fstest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+const (
+	privatePerm fs.FileMode = 0o640
+)
+
+var _ = Describe("Archive/Extract", func() {
+	var (
+		src     *luna.MemFS
+		modTime time.Time
+	)
+
+	BeforeEach(func() {
+		modTime = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+		src = luna.NewMemFS()
+
+		for name, content := range map[string]string{
+			"fixture/a.txt":         "a",
+			"fixture/sub/b.txt":     "b",
+			"fixture/sub/deep/c.md": "c",
+		} {
+			src.MapFS[name] = &fstest.MapFile{
+				Data:    []byte(content),
+				Mode:    lab.Perms.File,
+				ModTime: modTime,
+			}
+		}
+
+		src.MapFS["fixture/secret.key"] = &fstest.MapFile{
+			Data:    []byte("key"),
+			Mode:    privatePerm,
+			ModTime: modTime,
+		}
+		src.MapFS["fixture/sub"] = &fstest.MapFile{
+			Mode:    fs.ModeDir | lab.Perms.Dir,
+			ModTime: modTime,
+		}
+	})
+
+	DescribeTable("round trip",
+		func(format nef.ArchiveFormat) {
+			var buffer bytes.Buffer
+			Expect(nef.Archive(src, "fixture", &buffer, format)).To(Succeed())
+			Expect(nef.DetectArchiveFormat(buffer.Bytes())).To(Equal(format))
+
+			dst := luna.NewMemFS()
+			Expect(nef.Extract(&buffer, dst, "out")).To(Succeed())
+
+			expected, err := nef.BuildManifest(src, "fixture", nef.HashSHA256)
+			Expect(err).To(Succeed())
+			actual, err := nef.BuildManifest(dst, "out", nef.HashSHA256)
+			Expect(err).To(Succeed())
+			Expect(actual).To(Equal(expected))
+
+			info, err := dst.Stat("out/secret.key")
+			Expect(err).To(Succeed())
+			Expect(info.Mode().Perm()).To(Equal(privatePerm))
+			Expect(info.ModTime().Equal(modTime)).To(BeTrue())
+
+			info, err = dst.Stat("out/sub")
+			Expect(err).To(Succeed())
+			Expect(info.IsDir()).To(BeTrue())
+			Expect(info.ModTime().Equal(modTime)).To(BeTrue())
+		},
+		func(format nef.ArchiveFormat) string {
+			return fmt.Sprintf("🧪 ===> format: '%v', should: extract archived tree", format)
+		},
+		Entry(nil, nef.ArchiveZip),
+		Entry(nil, nef.ArchiveTar),
+		Entry(nil, nef.ArchiveTarGz),
+	)
+
+	When("given: unknown format", func() {
+		It("🧪 should: return error", func() {
+			Expect(nef.Archive(src, "fixture", &bytes.Buffer{}, nef.ArchiveUnknown)).NotTo(Succeed())
+			Expect(nef.Extract(bytes.NewReader([]byte("text")), luna.NewMemFS(), "")).NotTo(Succeed())
+		})
+	})
+
+	When("given: entry that escapes the destination", func() {
+		It("🧪 should: reject archive without writing", func() {
+			for _, data := range [][]byte{
+				zipArchive([]archiveEntry{
+					{name: "ok.txt", content: "ok"},
+					{name: "../evil.txt", content: "evil"},
+				}),
+				tarArchive([]archiveEntry{
+					{name: "ok.txt", content: "ok"},
+					{name: "/etc/evil", content: "evil"},
+				}, true),
+			} {
+				dst := luna.NewMemFS()
+				err := nef.Extract(bytes.NewReader(data), dst, "out")
+				Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+				Expect(dst.MapFS).To(BeEmpty())
+			}
+		})
+	})
+
+	When("given: entries with ./ prefix", func() {
+		It("🧪 should: extract relative to destination", func() {
+			dst := luna.NewMemFS()
+			Expect(nef.Extract(bytes.NewReader(tarArchive([]archiveEntry{
+				{name: "./", dir: true},
+				{name: "./a.txt", content: "a"},
+			}, false)), dst, "")).To(Succeed())
+			Expect(dst.ReadFile("a.txt")).To(Equal([]byte("a")))
+		})
+	})
+
+	Context("relative destination", func() {
+		var (
+			root    string
+			archive []byte
+		)
+
+		BeforeEach(func() {
+			root = GinkgoT().TempDir()
+
+			var buffer bytes.Buffer
+			Expect(nef.Archive(src, "fixture", &buffer, nef.ArchiveTarGz)).To(Succeed())
+			archive = buffer.Bytes()
+		})
+
+		It("🧪 should: preserve mode and modification time", func() {
+			dst := nef.NewUniversalFS(nef.Rel{
+				Root: root,
+			})
+			Expect(nef.Extract(bytes.NewReader(archive), dst, "out")).To(Succeed())
+
+			info, err := os.Stat(filepath.Join(root, "out", "secret.key"))
+			Expect(err).To(Succeed())
+			Expect(info.ModTime().Equal(modTime)).To(BeTrue())
+
+			if runtime.GOOS != "windows" {
+				Expect(info.Mode().Perm()).To(Equal(privatePerm))
+			}
+		})
+
+		When("given: existing file and overwrite disabled", func() {
+			It("🧪 should: return exist error", func() {
+				Expect(os.WriteFile(filepath.Join(root, "a.txt"), []byte("old"), lab.Perms.File)).To(Succeed())
+				dst := nef.NewUniversalFS(nef.Rel{
+					Root: root,
+				})
+
+				err := nef.Extract(bytes.NewReader(archive), dst, "")
+				Expect(err).To(MatchError(fs.ErrExist))
+				Expect(os.ReadFile(filepath.Join(root, "a.txt"))).To(Equal([]byte("old")))
+			})
+		})
+
+		When("given: existing file and overwrite enabled", func() {
+			It("🧪 should: replace file", func() {
+				Expect(os.WriteFile(filepath.Join(root, "a.txt"), []byte("old"), lab.Perms.File)).To(Succeed())
+				dst := nef.NewUniversalFS(nef.Rel{
+					Root:      root,
+					Overwrite: true,
+				})
+
+				Expect(nef.Extract(bytes.NewReader(archive), dst, "")).To(Succeed())
+				Expect(os.ReadFile(filepath.Join(root, "a.txt"))).To(Equal([]byte("a")))
+			})
+		})
+
+		DescribeTable("given: existing file and failed replacement",
+			func(fault luna.Fault) {
+				Expect(os.WriteFile(filepath.Join(root, "a.txt"), []byte("old"), lab.Perms.File)).To(Succeed())
+				dst := luna.NewFaultFS(nef.NewUniversalFS(nef.Rel{
+					Root:      root,
+					Overwrite: true,
+				}), 1, fault)
+
+				err := nef.Extract(bytes.NewReader(archive), dst, "")
+				Expect(err).To(MatchError(syscall.ENOSPC))
+				Expect(os.ReadFile(filepath.Join(root, "a.txt"))).To(Equal([]byte("old")))
+				Expect(filepath.Join(root, ".a.txt.nef-extract")).NotTo(BeAnExistingFile())
+			},
+			func(fault luna.Fault) string {
+				return fmt.Sprintf("🧪 ===> given: '%v' fault, should: preserve existing file", fault.Op)
+			},
+			Entry(nil, luna.Fault{Op: "WriteFile", Pattern: "*a.txt*", Err: syscall.ENOSPC, ShortWrite: 1}),
+		)
+	})
+})
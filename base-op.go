@@ -1,19 +1,28 @@
 package nef
 
+import (
+	"errors"
+	"io/fs"
+)
+
 type baseOp[F ExistsInFS] struct {
 	fS   F
 	calc PathCalc
-	root string
 }
 
-func (m *baseOp[F]) peek(name string) (exists, isDir bool) {
-	if m.fS.DirectoryExists(name) {
-		return true, true
+// peek stats name through fS, reporting whether it exists and if so,
+// whether it is a directory; an error other than fs.ErrNotExist is
+// returned, so that a failure to stat an item is not mistaken for its
+// absence.
+func peek(fS fs.StatFS, name string) (exists, isDir bool, err error) {
+	info, err := fS.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, false, nil
 	}
 
-	if m.fS.FileExists(name) {
-		return true, false
+	if err != nil {
+		return false, false, err
 	}
 
-	return false, false
+	return true, info.IsDir(), nil
 }
@@ -0,0 +1,314 @@
+package nef
+
+import (
+	"bufio"
+	"crypto/md5"  //nolint:gosec // md5 is provided for compatibility, not security
+	"crypto/sha1" //nolint:gosec // sha1 is provided for compatibility, not security
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/snivilised/nefilim/internal/third/lo"
+	"golang.org/x/crypto/blake2b"
+)
+
+// HashAlgorithm identifies a hash algorithm supported by Checksum
+type HashAlgorithm string
+
+const (
+	// HashSHA256 denotes SHA-256, as used by sha256sum
+	HashSHA256 HashAlgorithm = "sha256"
+	// HashSHA1 denotes SHA-1, as used by sha1sum
+	HashSHA1 HashAlgorithm = "sha1"
+	// HashMD5 denotes MD5, as used by md5sum
+	HashMD5 HashAlgorithm = "md5"
+	// HashBlake2b denotes BLAKE2b-256
+	HashBlake2b HashAlgorithm = "blake2b"
+)
+
+// New creates a new hash.Hash for the algorithm
+func (a HashAlgorithm) New() (hash.Hash, error) {
+	switch a {
+	case HashSHA256:
+		return sha256.New(), nil
+	case HashSHA1:
+		return sha1.New(), nil //nolint:gosec // ok, compatibility
+	case HashMD5:
+		return md5.New(), nil //nolint:gosec // ok, compatibility
+	case HashBlake2b:
+		return blake2b.New256(nil)
+	}
+
+	return nil, fmt.Errorf("unsupported hash algorithm: %q", a)
+}
+
+// Checksum returns the hex encoded digest of the contents of the file at
+// path, computed with the algorithm specified. The file is streamed via
+// Open, so it does not have to fit in memory.
+func Checksum(fS ReaderFS, path string, algo HashAlgorithm) (string, error) {
+	h, err := algo.New()
+	if err != nil {
+		return "", err
+	}
+
+	file, err := fS.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close() //nolint:errcheck // ok, read only
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// 🧩 ---> manifest
+
+// ManifestEntry is a single line of a checksum manifest
+type ManifestEntry struct {
+	// Digest is the hex encoded digest of the file
+	Digest string
+	// Path is the '/' separated path of the file, relative to the root
+	// of the manifest
+	Path string
+}
+
+// Manifest is a list of file digests, which can be written and parsed in
+// the format used by sha256sum (and its siblings), ie one line per file
+// consisting of the digest, 2 spaces and the path.
+type Manifest struct {
+	// Algorithm is the hash algorithm that created the digests
+	Algorithm HashAlgorithm
+	// Entries are the files in the manifest, in walk order
+	Entries []ManifestEntry
+}
+
+// BuildManifest creates a manifest containing the digest of every regular
+// file in the tree under root; root is a path in the form accepted by
+// fS. The paths in the manifest are relative to root.
+func BuildManifest(fS ReaderFS, root string, algo HashAlgorithm) (*Manifest, error) {
+	manifest := &Manifest{
+		Algorithm: algo,
+		Entries:   []ManifestEntry{},
+	}
+
+	err := walkFiles(fS, root, "", func(path, rel string) error {
+		digest, err := Checksum(fS, path, algo)
+		if err != nil {
+			return err
+		}
+
+		manifest.Entries = append(manifest.Entries, ManifestEntry{
+			Digest: digest,
+			Path:   rel,
+		})
+
+		return nil
+	})
+
+	return manifest, err
+}
+
+// WriteTo writes the manifest to w in sha256sum format. As with
+// sha256sum, a path that contains a backslash or new line is escaped and
+// its line is prefixed with a backslash.
+func (m *Manifest) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+
+	for _, entry := range m.Entries {
+		prefix, path := "", entry.Path
+
+		if strings.ContainsAny(path, "\\\n") {
+			prefix = "\\"
+			path = manifestEscaper.Replace(path)
+		}
+
+		n, err := fmt.Fprintf(w, "%v%v  %v\n", prefix, entry.Digest, path)
+		total += int64(n)
+
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+var (
+	manifestEscaper   = strings.NewReplacer("\\", "\\\\", "\n", "\\n")
+	manifestUnescaper = strings.NewReplacer("\\\\", "\\", "\\n", "\n")
+)
+
+// ParseManifest reads a manifest in sha256sum format from r. Both the
+// text ("  ") and binary (" *") separators are accepted. The algorithm
+// can't be inferred from the content, so must be specified.
+func ParseManifest(r io.Reader, algo HashAlgorithm) (*Manifest, error) {
+	h, err := algo.New()
+	if err != nil {
+		return nil, err
+	}
+
+	size := hex.EncodedLen(h.Size())
+	manifest := &Manifest{
+		Algorithm: algo,
+		Entries:   []ManifestEntry{},
+	}
+	scanner := bufio.NewScanner(r)
+	number := 0
+
+	for scanner.Scan() {
+		number++
+		line := scanner.Text()
+
+		if line == "" {
+			continue
+		}
+
+		escaped := strings.HasPrefix(line, "\\")
+		line = strings.TrimPrefix(line, "\\")
+
+		if len(line) < size+2 || (line[size:size+2] != "  " && line[size:size+2] != " *") {
+			return nil, fmt.Errorf("invalid manifest line %v: %q", number, scanner.Text())
+		}
+
+		digest, path := line[:size], line[size+2:]
+
+		if _, err := hex.DecodeString(digest); err != nil {
+			return nil, fmt.Errorf("invalid digest on manifest line %v: %w", number, err)
+		}
+
+		if escaped {
+			path = manifestUnescaper.Replace(path)
+		}
+
+		manifest.Entries = append(manifest.Entries, ManifestEntry{
+			Digest: strings.ToLower(digest),
+			Path:   path,
+		})
+	}
+
+	return manifest, scanner.Err()
+}
+
+// ManifestReport is the result of verifying a manifest against a tree
+type ManifestReport struct {
+	// Matched are the paths whose digest matches the manifest
+	Matched []string
+	// Mismatched are the paths whose digest does not match the manifest
+	Mismatched []string
+	// Missing are the paths in the manifest that do not exist in the tree
+	Missing []string
+	// Unlisted are the files in the tree that are not in the manifest
+	Unlisted []string
+}
+
+// OK reports whether the tree exactly matches the manifest
+func (r *ManifestReport) OK() bool {
+	return len(r.Mismatched) == 0 && len(r.Missing) == 0 && len(r.Unlisted) == 0
+}
+
+// Verify checks the files in the tree under root against the manifest.
+// Discrepancies are reported in the returned report; an error is only
+// returned if the tree can not be read, or an entry's path is not a valid
+// '/' separated path under root (see fs.ValidPath), in which case it is an
+// invalid path error, whose reason is ReasonOutsideRoot.
+func (m *Manifest) Verify(fS ReaderFS, root string) (*ManifestReport, error) {
+	report := &ManifestReport{
+		Matched:    []string{},
+		Mismatched: []string{},
+		Missing:    []string{},
+		Unlisted:   []string{},
+	}
+	listed := make(map[string]bool, len(m.Entries))
+
+	for _, entry := range m.Entries {
+		if !fs.ValidPath(entry.Path) {
+			return report, newInvalidPathError("Verify", entry.Path, ReasonOutsideRoot, nil)
+		}
+
+		listed[entry.Path] = true
+		digest, err := Checksum(fS, joinUnder(fS, root, entry.Path), m.Algorithm)
+
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			report.Missing = append(report.Missing, entry.Path)
+		case err != nil:
+			return report, err
+		case digest == entry.Digest:
+			report.Matched = append(report.Matched, entry.Path)
+		default:
+			report.Mismatched = append(report.Mismatched, entry.Path)
+		}
+	}
+
+	err := walkFiles(fS, root, "", func(_, rel string) error {
+		if !listed[rel] {
+			report.Unlisted = append(report.Unlisted, rel)
+		}
+
+		return nil
+	})
+
+	return report, err
+}
+
+// walkFiles invokes fn for every regular file in the tree under path, in
+// lexical order, with the path of the file in the form accepted by fS
+// and its '/' separated path relative to the root of the walk.
+func walkFiles(fS ReaderFS, path, rel string, fn func(path, rel string) error) error {
+	return walkTree(fS, path, rel, func(path, rel string, entry fs.DirEntry) error {
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		return fn(path, rel)
+	})
+}
+
+// walkTree invokes fn for every item in the tree under path, in lexical
+// order; a directory is visited before its children.
+func walkTree(fS ReaderFS, path, rel string,
+	fn func(path, rel string, entry fs.DirEntry) error,
+) error {
+	entries, err := fS.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		childPath := joinUnder(fS, path, entry.Name())
+		childRel := lo.Ternary(rel == "", entry.Name(), rel+separatorStr+entry.Name())
+
+		if err := fn(childPath, childRel, entry); err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if err := walkTree(fS, childPath, childRel, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// joinUnder joins the '/' separated rel onto parent, using the path
+// calculator of the file system. The root of a relative file system
+// is '.', which is not retained as a prefix.
+func joinUnder(fS FSUtility, parent, rel string) string {
+	if fS.IsRelative() && (parent == "." || parent == "") {
+		return rel
+	}
+
+	calc := fS.Calc()
+
+	return calc.Join(append([]string{parent}, strings.Split(rel, separatorStr)...)...)
+}
@@ -0,0 +1,222 @@
+package nef_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/blake2b"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+type checksumTE struct {
+	algo   nef.HashAlgorithm
+	expect string
+}
+
+var _ = Describe("Checksum", func() {
+	var fS *luna.MemFS
+
+	BeforeEach(func() {
+		fS = luna.NewMemFS()
+		fS.MapFS["foo.txt"] = &fstest.MapFile{
+			Data: []byte("foo"),
+			Mode: lab.Perms.File,
+		}
+	})
+
+	DescribeTable("algorithms",
+		func(entry *checksumTE) {
+			digest, err := nef.Checksum(fS, "foo.txt", entry.algo)
+			Expect(err).To(Succeed())
+			Expect(digest).To(Equal(entry.expect))
+		},
+		func(entry *checksumTE) string {
+			return fmt.Sprintf("🧪 ===> algorithm: '%v', should: return digest", entry.algo)
+		},
+		Entry(nil, &checksumTE{
+			algo:   nef.HashSHA256,
+			expect: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		}),
+		Entry(nil, &checksumTE{
+			algo:   nef.HashSHA1,
+			expect: "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33",
+		}),
+		Entry(nil, &checksumTE{
+			algo:   nef.HashMD5,
+			expect: "acbd18db4cc2f85cedef654fccc4a4d8",
+		}),
+		Entry(nil, &checksumTE{
+			algo: nef.HashBlake2b,
+			expect: func() string {
+				sum := blake2b.Sum256([]byte("foo"))
+				return hex.EncodeToString(sum[:])
+			}(),
+		}),
+	)
+
+	When("given: unsupported algorithm", func() {
+		It("🧪 should: return error", func() {
+			_, err := nef.Checksum(fS, "foo.txt", nef.HashAlgorithm("crc32"))
+			Expect(err).NotTo(Succeed())
+		})
+	})
+
+	When("given: missing file", func() {
+		It("🧪 should: return not exist error", func() {
+			_, err := nef.Checksum(fS, "bar.txt", nef.HashSHA256)
+			Expect(err).To(MatchError(os.ErrNotExist))
+		})
+	})
+})
+
+var _ = Describe("Manifest", func() {
+	Context("memory file system", func() {
+		var fS *luna.MemFS
+
+		BeforeEach(func() {
+			fS = luna.NewMemFS()
+
+			for name, content := range map[string]string{
+				"release/a.txt":       "a",
+				"release/sub/b.txt":   "b",
+				"release/sub/c/d.txt": "d",
+				"other/e.txt":         "e",
+			} {
+				fS.MapFS[name] = &fstest.MapFile{
+					Data: []byte(content),
+					Mode: lab.Perms.File,
+				}
+			}
+		})
+
+		When("given: unmodified tree", func() {
+			It("🧪 should: round trip and verify", func() {
+				manifest, err := nef.BuildManifest(fS, "release", nef.HashSHA256)
+				Expect(err).To(Succeed())
+				Expect(manifest.Entries).To(HaveLen(3))
+				Expect(manifest.Entries[0].Path).To(Equal("a.txt"))
+				Expect(manifest.Entries[2].Path).To(Equal("sub/c/d.txt"))
+
+				var buffer bytes.Buffer
+				_, err = manifest.WriteTo(&buffer)
+				Expect(err).To(Succeed())
+				Expect(buffer.String()).To(HavePrefix(
+					"ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb  a.txt\n",
+				))
+
+				parsed, err := nef.ParseManifest(&buffer, nef.HashSHA256)
+				Expect(err).To(Succeed())
+				Expect(parsed).To(Equal(manifest))
+
+				report, err := parsed.Verify(fS, "release")
+				Expect(err).To(Succeed())
+				Expect(report.OK()).To(BeTrue())
+				Expect(report.Matched).To(HaveLen(3))
+			})
+		})
+
+		When("given: modified tree", func() {
+			It("🧪 should: report discrepancies", func() {
+				manifest, err := nef.BuildManifest(fS, "release", nef.HashSHA256)
+				Expect(err).To(Succeed())
+
+				fS.MapFS["release/a.txt"].Data = []byte("corrupt")
+				delete(fS.MapFS, "release/sub/b.txt")
+				fS.MapFS["release/f.txt"] = &fstest.MapFile{
+					Data: []byte("f"),
+					Mode: lab.Perms.File,
+				}
+
+				report, err := manifest.Verify(fS, "release")
+				Expect(err).To(Succeed())
+				Expect(report.OK()).To(BeFalse())
+				Expect(report.Matched).To(Equal([]string{"sub/c/d.txt"}))
+				Expect(report.Mismatched).To(Equal([]string{"a.txt"}))
+				Expect(report.Missing).To(Equal([]string{"sub/b.txt"}))
+				Expect(report.Unlisted).To(Equal([]string{"f.txt"}))
+			})
+		})
+	})
+
+	Context("local file systems", func() {
+		var root string
+
+		BeforeEach(func() {
+			root = GinkgoT().TempDir()
+			Expect(os.MkdirAll(filepath.Join(root, "sub"), lab.Perms.Dir)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), lab.Perms.File)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(root, "sub", "b.txt"), []byte("b"), lab.Perms.File)).To(Succeed())
+		})
+
+		It("🧪 should: build the same manifest for relative and absolute file systems", func() {
+			relative, err := nef.BuildManifest(nef.NewReaderFS(nef.Rel{
+				Root: root,
+			}), ".", nef.HashMD5)
+			Expect(err).To(Succeed())
+
+			absolute, err := nef.BuildManifest(nef.NewReaderABS(), root, nef.HashMD5)
+			Expect(err).To(Succeed())
+
+			Expect(relative).To(Equal(absolute))
+			Expect(relative.Entries[1].Path).To(Equal("sub/b.txt"))
+		})
+
+		DescribeTable("escaping entry",
+			func(path string) {
+				manifest, err := nef.ParseManifest(
+					strings.NewReader(strings.Repeat("a", 64)+"  "+path+"\n"),
+					nef.HashSHA256,
+				)
+				Expect(err).To(Succeed())
+
+				_, err = manifest.Verify(nef.NewReaderABS(), filepath.Join(root, "sub"))
+				Expect(nef.IsInvalidPathError(err)).To(BeTrue(), "%v", err)
+				reason, _ := nef.ReasonOf(err)
+				Expect(reason).To(Equal(nef.ReasonOutsideRoot))
+			},
+			func(path string) string {
+				return fmt.Sprintf("🧪 ===> given: entry '%v', should: reject with invalid path error", path)
+			},
+			Entry(nil, "../a.txt"),
+			Entry(nil, "../../etc/passwd"),
+			Entry(nil, "/etc/passwd"),
+			Entry(nil, "sub/../../a.txt"),
+		)
+	})
+
+	Context("ParseManifest", func() {
+		When("given: escaped path and binary separator", func() {
+			It("🧪 should: unescape path", func() {
+				digest := strings.Repeat("a", 64)
+				manifest, err := nef.ParseManifest(
+					strings.NewReader(`\`+digest+` *foo\nbar\\baz`+"\n"),
+					nef.HashSHA256,
+				)
+				Expect(err).To(Succeed())
+				Expect(manifest.Entries[0].Path).To(Equal("foo\nbar\\baz"))
+
+				var buffer bytes.Buffer
+				_, err = manifest.WriteTo(&buffer)
+				Expect(err).To(Succeed())
+				Expect(buffer.String()).To(Equal(`\` + digest + `  foo\nbar\\baz` + "\n"))
+			})
+		})
+
+		When("given: malformed line", func() {
+			It("🧪 should: return error", func() {
+				_, err := nef.ParseManifest(strings.NewReader("abc foo.txt\n"), nef.HashSHA256)
+				Expect(err).NotTo(Succeed())
+			})
+		})
+	})
+})
@@ -5,20 +5,168 @@ import (
 	"fmt"
 )
 
+// ErrorReason is a machine readable code that identifies why a nef
+// operation failed. Client libraries (eg traverse) can use it as a key
+// into their own message catalogues, instead of parsing error strings.
+type ErrorReason string
+
+const (
+	// ReasonInvalidPath denotes a path that failed validation
+	ReasonInvalidPath ErrorReason = "invalid-path"
+	// ReasonInvalidBinaryFsOp denotes a binary operation (from/to) that
+	// is not valid for the items involved
+	ReasonInvalidBinaryFsOp ErrorReason = "invalid-binary-fs-op"
+	// ReasonRejectSameDirMove denotes a move within the same directory
+	ReasonRejectSameDirMove ErrorReason = "reject-same-dir-move"
+	// ReasonRejectDifferentDirChange denotes a change across directories
+	ReasonRejectDifferentDirChange ErrorReason = "reject-different-dir-change"
+	// ReasonSourceNotFound denotes a binary operation whose source does
+	// not exist; wraps fs.ErrNotExist
+	ReasonSourceNotFound ErrorReason = "source-not-found"
+	// ReasonDestinationClash denotes a binary operation whose destination
+	// already exists and overwrite is not enabled; wraps fs.ErrExist
+	ReasonDestinationClash ErrorReason = "destination-clash"
+	// ReasonFileOntoDirectory denotes an attempt to replace an existing
+	// directory with a file; wraps fs.ErrExist
+	ReasonFileOntoDirectory ErrorReason = "file-onto-directory"
+	// ReasonDirectoryOntoFile denotes an attempt to replace an existing
+	// file with a directory; wraps fs.ErrExist
+	ReasonDirectoryOntoFile ErrorReason = "directory-onto-file"
+	// ReasonNotRelative denotes a path that can't be expressed relative
+	// to another path
+	ReasonNotRelative ErrorReason = "not-relative"
+	// ReasonOutsideRoot denotes a path that does not reside within the
+	// root of a relative file system, or of a QuotaFS, or a manifest entry
+	// that would escape the tree being verified
+	ReasonOutsideRoot ErrorReason = "outside-root"
+	// ReasonFileSystemMismatch denotes a file system that is relative
+	// when an absolute one is required, or vice versa
+	ReasonFileSystemMismatch ErrorReason = "file-system-mismatch"
+	// ReasonUnresolved denotes a path that could not be resolved, eg
+	// because it refers to an undefined environment variable or an
+	// unknown user
+	ReasonUnresolved ErrorReason = "unresolved"
+	// ReasonQuotaExceeded denotes an operation that was rejected because
+	// it would exceed the quota of a QuotaFS
+	ReasonQuotaExceeded ErrorReason = "quota-exceeded"
+	// ReasonCancelled denotes an operation that was stopped because its
+	// context was cancelled or its deadline exceeded
+	ReasonCancelled ErrorReason = "cancelled"
+	// ReasonLocked denotes a lock that could not be acquired, because a
+	// conflicting lock is held
+	ReasonLocked ErrorReason = "locked"
+)
+
+// InvalidPathError is the error returned when a path is rejected by
+// validation. It can be retrieved from an error chain with errors.As.
+type InvalidPathError struct {
+	// Description describes the context in which the path was rejected,
+	// typically the name of the operation
+	Description string
+	// Path is the path that was rejected
+	Path string
+	// Reason is the machine readable reason code
+	Reason ErrorReason
+	// Err is the underlying cause, if there is one
+	Err error
+}
+
+// Error returns the error message
+func (e *InvalidPathError) Error() string {
+	message := fmt.Sprintf("description: %q, path: %q, %v",
+		e.Description, e.Path, ErrCoreInvalidPath,
+	)
+
+	if e.Err != nil {
+		return fmt.Sprintf("%v (%v)", message, e.Err)
+	}
+
+	return message
+}
+
+// Unwrap returns the underlying cause
+func (e *InvalidPathError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the core invalid path error
+func (e *InvalidPathError) Is(target error) bool {
+	return target == ErrCoreInvalidPath
+}
+
 // IsInvalidPathError reports whether err is or wraps the invalid path error.
 func IsInvalidPathError(err error) bool {
-	return errors.Is(errors.Unwrap(err), ErrCoreInvalidPath)
+	return errors.Is(err, ErrCoreInvalidPath)
 }
 
 // NewInvalidPathError returns an error indicating an invalid path,
 // with the given description and path.
 func NewInvalidPathError(description, path string) error {
-	// should this have a description too to describe the path?
-	// we probably need a CoreInvalidPathError which InvalidPathError
-	// wraps.
-	return fmt.Errorf("description: %q, path: %q, %w",
-		description, path, ErrCoreInvalidPath,
+	return &InvalidPathError{
+		Description: description,
+		Path:        path,
+		Reason:      ReasonInvalidPath,
+	}
+}
+
+func newInvalidPathError(description, path string, reason ErrorReason, err error) error {
+	return &InvalidPathError{
+		Description: description,
+		Path:        path,
+		Reason:      reason,
+		Err:         err,
+	}
+}
+
+// BinaryOpError is the error returned when an operation that takes 2 paths,
+// typically from and to, fails. It can be retrieved from an error chain
+// with errors.As, giving access to the operation name and the paths involved.
+type BinaryOpError struct {
+	// Op is the name of the operation, eg Move or Change
+	Op string
+	// From is the source path
+	From string
+	// To is the destination path
+	To string
+	// Reason is the machine readable reason code
+	Reason ErrorReason
+	// Err is the underlying cause, if there is one
+	Err error
+}
+
+// Error returns the error message
+func (e *BinaryOpError) Error() string {
+	message := fmt.Sprintf("op: %q, from %q, to: %q %v",
+		e.Op, e.From, e.To, e.core(),
 	)
+
+	if e.Err != nil {
+		return fmt.Sprintf("%v (%v)", message, e.Err)
+	}
+
+	return message
+}
+
+// Unwrap returns the underlying cause
+func (e *BinaryOpError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the core error that corresponds to
+// the reason of this error.
+func (e *BinaryOpError) Is(target error) bool {
+	return target == e.core()
+}
+
+func (e *BinaryOpError) core() error {
+	switch e.Reason {
+	case ReasonRejectSameDirMove:
+		return ErrCoreRejectSameDirMove
+	case ReasonRejectDifferentDirChange:
+		return ErrCoreRejectDifferentDirChange
+	default:
+		return ErrCoreBinaryFsOp
+	}
 }
 
 // IsBinaryFsOpError reports whether err is or wraps the invalid binary
@@ -30,7 +178,22 @@ func IsBinaryFsOpError(err error) bool {
 // NewInvalidBinaryFsOpError returns an error for an invalid binary FS
 // operation (op, from, to).
 func NewInvalidBinaryFsOpError(op, from, to string) error {
-	return fmt.Errorf("op: %q, from %q, to: %q %w", op, from, to, ErrCoreBinaryFsOp)
+	return &BinaryOpError{
+		Op:     op,
+		From:   from,
+		To:     to,
+		Reason: ReasonInvalidBinaryFsOp,
+	}
+}
+
+func newBinaryFsOpError(op, from, to string, reason ErrorReason, err error) error {
+	return &BinaryOpError{
+		Op:     op,
+		From:   from,
+		To:     to,
+		Reason: reason,
+		Err:    err,
+	}
 }
 
 // IsRejectSameDirMoveError reports whether err is or wraps the same-directory
@@ -42,7 +205,12 @@ func IsRejectSameDirMoveError(err error) bool {
 // NewRejectSameDirMoveError returns an error when a move within the same
 // directory is rejected.
 func NewRejectSameDirMoveError(op, from, to string) error {
-	return fmt.Errorf("op: %q, from %q, to: %q %w", op, from, to, ErrCoreRejectSameDirMove)
+	return &BinaryOpError{
+		Op:     op,
+		From:   from,
+		To:     to,
+		Reason: ReasonRejectSameDirMove,
+	}
 }
 
 // IsRejectDifferentDirChangeError reports whether err is or wraps the different-directory
@@ -54,19 +222,225 @@ func IsRejectDifferentDirChangeError(err error) bool {
 // NewRejectDifferentDirChangeError returns an error when a change across
 // different directories is rejected.
 func NewRejectDifferentDirChangeError(op, from, to string) error {
-	return fmt.Errorf("op: %q, from %q, to: %q %w", op, from, to, ErrCoreRejectDifferentDirChange)
+	return &BinaryOpError{
+		Op:     op,
+		From:   from,
+		To:     to,
+		Reason: ReasonRejectDifferentDirChange,
+	}
+}
+
+// QuotaResource identifies the resource limited by a quota
+type QuotaResource string
+
+const (
+	// QuotaBytes denotes the total size of files
+	QuotaBytes QuotaResource = "bytes"
+	// QuotaFiles denotes the number of files
+	QuotaFiles QuotaResource = "files"
+)
+
+// QuotaError is the error returned when an operation on a QuotaFS would
+// exceed its quota. It can be retrieved from an error chain with errors.As.
+type QuotaError struct {
+	// Op is the name of the operation that was rejected
+	Op string
+	// Path is the path the operation was applied to
+	Path string
+	// Resource is the resource whose limit would have been exceeded
+	Resource QuotaResource
+	// Limit is the configured limit of the resource
+	Limit int64
+	// Usage is the usage of the resource when the operation was rejected
+	Usage int64
+	// Requested is the amount of the resource the operation required
+	Requested int64
+	// Reason is the machine readable reason code
+	Reason ErrorReason
+}
+
+// Error returns the error message
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("op: %q, path: %q, %v (%v: usage %v, requested %v, limit %v)",
+		e.Op, e.Path, ErrCoreQuotaExceeded, e.Resource, e.Usage, e.Requested, e.Limit,
+	)
+}
+
+// Is determines if the target error is a quota exceeded error
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrCoreQuotaExceeded
+}
+
+// IsQuotaExceededError determines if an error is a quota exceeded error
+func IsQuotaExceededError(err error) bool {
+	return errors.Is(err, ErrCoreQuotaExceeded)
+}
+
+func newQuotaError(op, path string, resource QuotaResource, limit, usage, requested int64) error {
+	return &QuotaError{
+		Op:        op,
+		Path:      path,
+		Resource:  resource,
+		Limit:     limit,
+		Usage:     usage,
+		Requested: requested,
+		Reason:    ReasonQuotaExceeded,
+	}
+}
+
+// CancelledError is the error returned when a cancellable operation is
+// stopped because its context was cancelled or its deadline exceeded. It
+// records the progress made before the operation stopped and wraps the
+// error of the context, so errors.Is(err, context.Canceled) holds.
+type CancelledError struct {
+	// Op is the name of the operation that was stopped
+	Op string
+	// Path is the path the operation was applied to
+	Path string
+	// Completed is the number of items processed before the operation stopped
+	Completed int64
+	// Bytes is the number of bytes written before the operation stopped
+	Bytes int64
+	// Err is the error of the context
+	Err error
+	// Reason is the machine readable reason code
+	Reason ErrorReason
+}
+
+// Error returns the error message
+func (e *CancelledError) Error() string {
+	return fmt.Sprintf("op: %q, path: %q, %v after %v items (%v bytes): %v",
+		e.Op, e.Path, ErrCoreCancelled, e.Completed, e.Bytes, e.Err,
+	)
+}
+
+// Is determines if the target error is a cancelled error
+func (e *CancelledError) Is(target error) bool {
+	return target == ErrCoreCancelled
+}
+
+// Unwrap returns the error of the context
+func (e *CancelledError) Unwrap() error {
+	return e.Err
+}
+
+// IsCancelledError determines if an error is a cancelled error
+func IsCancelledError(err error) bool {
+	return errors.Is(err, ErrCoreCancelled)
+}
+
+func newCancelledError(op, path string, completed, bytes int64, err error) error {
+	return &CancelledError{
+		Op:        op,
+		Path:      path,
+		Completed: completed,
+		Bytes:     bytes,
+		Err:       err,
+		Reason:    ReasonCancelled,
+	}
+}
+
+// LockedError is the error returned when a lock can not be acquired
+// without waiting, or within the timeout, because a conflicting lock is
+// held. It can be retrieved from an error chain with errors.As.
+type LockedError struct {
+	// Op is the name of the operation that failed
+	Op string
+	// Path is the path of the item that could not be locked
+	Path string
+	// Mode is the mode of the lock requested
+	Mode LockMode
+	// Reason is the machine readable reason code
+	Reason ErrorReason
+}
+
+// Error returns the error message
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("op: %q, path: %q, %v (%v lock requested)",
+		e.Op, e.Path, ErrCoreLocked, e.Mode,
+	)
+}
+
+// Is determines if the target error is a locked error
+func (e *LockedError) Is(target error) bool {
+	return target == ErrCoreLocked
+}
+
+// IsLockedError determines if an error is a locked error
+func IsLockedError(err error) bool {
+	return errors.Is(err, ErrCoreLocked)
+}
+
+// NewLockedError creates a locked error for a lock of mode on path, for
+// use by implementations of LockFS.
+func NewLockedError(op, path string, mode LockMode) error {
+	return &LockedError{
+		Op:     op,
+		Path:   path,
+		Mode:   mode,
+		Reason: ReasonLocked,
+	}
+}
+
+// IsDecryptionError determines if an error is a decryption error
+func IsDecryptionError(err error) bool {
+	return errors.Is(err, ErrCoreDecryption)
+}
+
+// ReasonOf returns the reason code of the first nef error found in the
+// chain of err. The second return value is false if err does not contain
+// a nef error.
+func ReasonOf(err error) (ErrorReason, bool) {
+	var binaryErr *BinaryOpError
+	if errors.As(err, &binaryErr) {
+		return binaryErr.Reason, true
+	}
+
+	var pathErr *InvalidPathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Reason, true
+	}
+
+	var quotaErr *QuotaError
+	if errors.As(err, &quotaErr) {
+		return quotaErr.Reason, true
+	}
+
+	var cancelledErr *CancelledError
+	if errors.As(err, &cancelledErr) {
+		return cancelledErr.Reason, true
+	}
+
+	var lockedErr *LockedError
+	if errors.As(err, &lockedErr) {
+		return lockedErr.Reason, true
+	}
+
+	return "", false
 }
 
 // these errors are deliberately being exported, so that client libraries
 // (eg traverse) that do support i18n
 // can wrap them and make them translate-able.
 var (
-	// ErrCoreInvalidPath indicates an invalid path	
-	ErrCoreInvalidPath              = errors.New("invalid path")
+	// ErrCoreInvalidPath indicates an invalid path
+	ErrCoreInvalidPath = errors.New("invalid path")
 	// ErrCoreBinaryFsOp indicates an invalid binary file system operation
-	ErrCoreBinaryFsOp               = errors.New("invalid binary file system operation")
+	ErrCoreBinaryFsOp = errors.New("invalid binary file system operation")
 	// ErrCoreRejectSameDirMove indicates a same directory move is rejected
-	ErrCoreRejectSameDirMove        = errors.New("same directory move rejected, use move instead")
+	ErrCoreRejectSameDirMove = errors.New("same directory move rejected, use move instead")
 	// ErrCoreRejectDifferentDirChange indicates a different directory change is rejected
 	ErrCoreRejectDifferentDirChange = errors.New("different directory change rejected, use move instead")
+	// ErrCoreDecryption indicates content or a name that could not be
+	// decrypted, eg because the key is wrong or the data has been tampered with
+	ErrCoreDecryption = errors.New("decryption failed")
+	// ErrCoreQuotaExceeded indicates an operation that would exceed a quota
+	ErrCoreQuotaExceeded = errors.New("quota exceeded")
+	// ErrCoreCancelled indicates an operation that was cancelled
+	ErrCoreCancelled = errors.New("operation cancelled")
+	// ErrCoreWatchOverflow indicates that watch events were lost, because
+	// they were not received quickly enough
+	ErrCoreWatchOverflow = errors.New("watch events overflowed")
+	// ErrCoreLocked indicates a lock held by another owner
+	ErrCoreLocked = errors.New("locked")
 )
@@ -0,0 +1,111 @@
+package nef_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	nef "github.com/snivilised/nefilim"
+)
+
+var _ = Describe("errors", func() {
+	Context("InvalidPathError", func() {
+		When("given: error wrapped more than once", func() {
+			It("🧪 should: still be identified", func() {
+				err := nef.NewInvalidPathError("MakeDir", "/foo")
+				wrapped := fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", err))
+
+				Expect(nef.IsInvalidPathError(wrapped)).To(BeTrue())
+			})
+		})
+
+		When("given: errors.As", func() {
+			It("🧪 should: expose description, path and reason", func() {
+				err := fmt.Errorf("context: %w", nef.NewInvalidPathError("MakeDir", "/foo"))
+
+				var pathErr *nef.InvalidPathError
+				Expect(errors.As(err, &pathErr)).To(BeTrue())
+				Expect(pathErr.Description).To(Equal("MakeDir"))
+				Expect(pathErr.Path).To(Equal("/foo"))
+				Expect(pathErr.Reason).To(Equal(nef.ReasonInvalidPath))
+			})
+		})
+	})
+
+	Context("BinaryOpError", func() {
+		When("given: errors.As", func() {
+			It("🧪 should: expose op, from, to and reason", func() {
+				err := fmt.Errorf("context: %w",
+					nef.NewInvalidBinaryFsOpError("Move", "from.txt", "to.txt"),
+				)
+
+				var binaryErr *nef.BinaryOpError
+				Expect(errors.As(err, &binaryErr)).To(BeTrue())
+				Expect(binaryErr.Op).To(Equal("Move"))
+				Expect(binaryErr.From).To(Equal("from.txt"))
+				Expect(binaryErr.To).To(Equal("to.txt"))
+				Expect(binaryErr.Reason).To(Equal(nef.ReasonInvalidBinaryFsOp))
+				Expect(nef.IsBinaryFsOpError(err)).To(BeTrue())
+			})
+		})
+
+		When("given: underlying os error", func() {
+			It("🧪 should: wrap the os error", func() {
+				err := &nef.BinaryOpError{
+					Op:     "Move",
+					From:   "from.txt",
+					To:     "to.txt",
+					Reason: nef.ReasonInvalidBinaryFsOp,
+					Err:    fs.ErrNotExist,
+				}
+
+				Expect(errors.Is(err, fs.ErrNotExist)).To(BeTrue())
+				Expect(nef.IsBinaryFsOpError(err)).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring(fs.ErrNotExist.Error()))
+			})
+		})
+
+		When("given: same directory move rejection", func() {
+			It("🧪 should: only be identified as same directory move", func() {
+				err := nef.NewRejectSameDirMoveError("Move", "a/from.txt", "a/to.txt")
+
+				Expect(nef.IsRejectSameDirMoveError(err)).To(BeTrue())
+				Expect(nef.IsRejectDifferentDirChangeError(err)).To(BeFalse())
+				Expect(nef.IsBinaryFsOpError(err)).To(BeFalse())
+			})
+		})
+
+		When("given: different directory change rejection", func() {
+			It("🧪 should: only be identified as different directory change", func() {
+				err := nef.NewRejectDifferentDirChangeError("Change", "a/from.txt", "b/to.txt")
+
+				Expect(nef.IsRejectDifferentDirChangeError(err)).To(BeTrue())
+				Expect(nef.IsRejectSameDirMoveError(err)).To(BeFalse())
+			})
+		})
+	})
+
+	Context("ReasonOf", func() {
+		When("given: nef error", func() {
+			It("🧪 should: return reason", func() {
+				err := fmt.Errorf("context: %w",
+					nef.NewRejectSameDirMoveError("Move", "a/from.txt", "a/to.txt"),
+				)
+				reason, ok := nef.ReasonOf(err)
+
+				Expect(ok).To(BeTrue())
+				Expect(reason).To(Equal(nef.ReasonRejectSameDirMove))
+			})
+		})
+
+		When("given: foreign error", func() {
+			It("🧪 should: return false", func() {
+				_, ok := nef.ReasonOf(fs.ErrNotExist)
+
+				Expect(ok).To(BeFalse())
+			})
+		})
+	})
+})
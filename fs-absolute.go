@@ -1,8 +1,11 @@
 package nef
 
 import (
+	"context"
+	"errors"
 	"io/fs"
 	"os"
+	"time"
 )
 
 type absoluteFS struct {
@@ -37,6 +40,30 @@ func (f *absoluteFS) IsRelative() bool {
 	return false
 }
 
+// Root returns the empty string, since an absolute file system has no root
+func (f *absoluteFS) Root() string {
+	return ""
+}
+
+// ToAbsolute returns the cleaned path, since the paths used with an
+// absolute file system are already absolute. An invalid path error is
+// returned if the path is not absolute.
+func (f *absoluteFS) ToAbsolute(name string) (string, error) {
+	abs, err := NewAbsPath(name)
+	if err != nil {
+		return "", err
+	}
+
+	return abs.String(), nil
+}
+
+// ToRelative returns the cleaned path, since an absolute file system
+// accepts absolute paths. An invalid path error is returned if the path
+// is not absolute.
+func (f *absoluteFS) ToRelative(path string) (string, error) {
+	return f.ToAbsolute(path)
+}
+
 // FileExists does file exist at the path specified
 func (f *absoluteFS) FileExists(name string) bool {
 	info, err := f.Stat(name)
@@ -112,6 +139,13 @@ func (f *absoluteFS) MakeDirAll(name string, perm os.FileMode) error {
 	return os.MkdirAll(name, perm)
 }
 
+// MakeDirAllContext is the cancellable variant of MakeDirAll
+func (f *absoluteFS) MakeDirAllContext(ctx context.Context, name string, perm os.FileMode) error {
+	c := newCancellable(ctx, "MakeDirAll", name, name, f.calc)
+
+	return c.makeDirAll(name, perm)
+}
+
 // Ensure makes sure that a path exists at a particular location depending
 // on the value of as.AsFile.
 //
@@ -147,19 +181,25 @@ func (f *absoluteFS) Ensure(as PathAs) (at string, err error) {
 	return calc.Clean(calc.Join(directory, file)), err
 }
 
-// Move is not currently implemented on absoluteFS
-func (f *absoluteFS) Move(_, _ string) error {
-	panic("NOT-IMPL: absoluteFS.Move")
+// Move is not currently implemented on absoluteFS, so it fails with
+// errors.ErrUnsupported
+func (f *absoluteFS) Move(from, to string) error {
+	return &os.LinkError{Op: "Move", Old: from, New: to, Err: errors.ErrUnsupported}
 }
 
-// Change is not currently implemented on absoluteFS
-func (f *absoluteFS) Change(_, _ string) error {
-	panic("NOT-IMPL: absoluteFS.Change")
+// Change is not currently implemented on absoluteFS, so it fails with
+// errors.ErrUnsupported
+func (f *absoluteFS) Change(from, to string) error {
+	return &os.LinkError{Op: "Change", Old: from, New: to, Err: errors.ErrUnsupported}
 }
 
-// Copy is not currently implemented on absoluteFS
-func (f *absoluteFS) Copy(_, _ string) error {
-	panic("NOT-IMPL: absoluteFS.Copy")
+// Copy copies the item at from to to, which must not exist; if to is an
+// existing directory, the item is copied into it, under its own name. A
+// directory is copied with its contents, in the same way as CopyFS.
+func (f *absoluteFS) Copy(from, to string) error {
+	return copyNative(f.calc, func(name string) string {
+		return name
+	}, from, to)
 }
 
 // CopyFS copies the file system fsys into the directory dir,
@@ -177,9 +217,26 @@ func (f *absoluteFS) Copy(_, _ string) error {
 //
 // Symbolic links in dir are followed.
 //
-// Copying stops at and returns the first error encountered.
+// Unlike os.CopyFS, which stops at the first error, a file that fails
+// to copy does not prevent the others from being copied; the errors are
+// joined in walk order. Errors that abort the walk, such as a symbolic
+// link, a directory that can't be created, or an unreadable directory of
+// fsys, still stop the copy.
 func (f *absoluteFS) CopyFS(dir string, fsys fs.FS) error {
-	return os.CopyFS(dir, fsys)
+	return f.CopyFSContext(context.Background(), dir, fsys)
+}
+
+// CopyFSContext is the cancellable variant of CopyFS
+func (f *absoluteFS) CopyFSContext(ctx context.Context, dir string, fsys fs.FS) error {
+	return f.CopyFSWith(ctx, dir, fsys, CopyOptions{})
+}
+
+// CopyFSWith is the variant of CopyFSContext that applies options
+func (f *absoluteFS) CopyFSWith(ctx context.Context, dir string, fsys fs.FS, options CopyOptions) error {
+	c := newCancellable(ctx, "CopyFS", dir, dir, f.calc)
+	c.workers = options.Workers
+
+	return c.copyFS(dir, fsys)
 }
 
 // Remove removes the named file or (empty) directory.
@@ -197,6 +254,13 @@ func (f *absoluteFS) RemoveAll(path string) error {
 	return os.RemoveAll(path)
 }
 
+// RemoveAllContext is the cancellable variant of RemoveAll
+func (f *absoluteFS) RemoveAllContext(ctx context.Context, path string) error {
+	c := newCancellable(ctx, "RemoveAll", path, path, f.calc)
+
+	return c.removeAll(path)
+}
+
 // Rename renames (moves) 'from' to 'to'.
 // If 'to' already exists and is not a directory, Rename replaces it.
 // OS-specific restrictions may apply when 'from' and 'to' are in different directories.
@@ -223,3 +287,46 @@ func (f *absoluteFS) Create(name string) (fs.File, error) {
 func (f *absoluteFS) WriteFile(name string, data []byte, perm os.FileMode) error {
 	return os.WriteFile(name, data, perm)
 }
+
+// Chtimes changes the access and modification times of the named item
+func (f *absoluteFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+// Symlink creates newname as a symbolic link to oldname
+func (f *absoluteFS) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, newname)
+}
+
+// ReadLink returns the destination of the named symbolic link
+func (f *absoluteFS) ReadLink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+// Lock acquires a lock of mode on name, waiting until any conflicting
+// lock is released. It is implemented with flock on linux, darwin and
+// the BSDs and is not supported elsewhere.
+func (f *absoluteFS) Lock(name string, mode LockMode) (FileLock, error) {
+	return lockNative("Lock", name, name, mode, lockWaitForever)
+}
+
+// TryLock acquires a lock of mode on name, without waiting
+func (f *absoluteFS) TryLock(name string, mode LockMode) (FileLock, error) {
+	return lockNative("TryLock", name, name, mode, 0)
+}
+
+// LockTimeout acquires a lock of mode on name, waiting at most timeout
+func (f *absoluteFS) LockTimeout(name string, mode LockMode, timeout time.Duration) (FileLock, error) {
+	return lockNative("LockTimeout", name, name, mode, max(timeout, 0))
+}
+
+// Watch watches name and, if it is a directory, the tree beneath it. It
+// uses inotify on linux, falling back to polling elsewhere.
+func (f *absoluteFS) Watch(name string) (Watcher, error) {
+	origin, err := f.ToAbsolute(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return watch(f.calc, origin, origin)
+}
@@ -0,0 +1,517 @@
+package nef
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/snivilised/nefilim/internal/third/lo"
+)
+
+// 🔥 An archive file system is a read only, relative file system over the
+// content of a zip, tar or gzipped tar archive, so that code written against
+// ReaderFS can inspect an archive without extracting it. The archive is
+// indexed in memory when the file system is created; directories that are
+// implied by the paths of the entries, but not present in the archive, are
+// synthesised. Only regular files and directories are indexed, other
+// entries (eg symbolic links) are ignored.
+
+// ArchiveFormat identifies the format of an archive
+type ArchiveFormat uint8
+
+const (
+	// ArchiveUnknown denotes an unrecognised format; when passed to a
+	// function that accepts a format, the format is detected instead.
+	ArchiveUnknown ArchiveFormat = iota
+	// ArchiveZip denotes a zip archive
+	ArchiveZip
+	// ArchiveTar denotes an uncompressed tar archive
+	ArchiveTar
+	// ArchiveTarGz denotes a gzip compressed tar archive
+	ArchiveTarGz
+)
+
+// String returns the conventional extension of the format, without the
+// leading '.'
+func (f ArchiveFormat) String() string {
+	switch f {
+	case ArchiveZip:
+		return "zip"
+	case ArchiveTar:
+		return "tar"
+	case ArchiveTarGz:
+		return "tar.gz"
+	case ArchiveUnknown:
+	}
+
+	return "unknown"
+}
+
+const (
+	tarMagicOffset = 257
+	archiveDirPerm = 0o555
+)
+
+// DetectArchiveFormat detects the format of an archive from its leading
+// bytes; at least 262 bytes are required to detect a tar archive.
+func DetectArchiveFormat(header []byte) ArchiveFormat {
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")),
+		bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return ArchiveZip
+
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return ArchiveTarGz
+
+	case len(header) >= tarMagicOffset+len("ustar") &&
+		string(header[tarMagicOffset:tarMagicOffset+len("ustar")]) == "ustar":
+		return ArchiveTar
+	}
+
+	return ArchiveUnknown
+}
+
+// NewArchiveFS creates a read only file system over the content of an
+// archive held in data. If format is ArchiveUnknown, it is detected from
+// the content.
+func NewArchiveFS(data []byte, format ArchiveFormat) (ReaderFS, error) {
+	if format == ArchiveUnknown {
+		format = DetectArchiveFormat(data)
+	}
+
+	index := &archiveIndex{
+		nodes: map[string]*archiveNode{
+			".": {
+				info: &archiveInfo{name: ".", mode: fs.ModeDir | archiveDirPerm},
+			},
+		},
+	}
+
+	var err error
+
+	switch format {
+	case ArchiveZip:
+		err = index.loadZip(data)
+	case ArchiveTar:
+		err = index.loadTar(bytes.NewReader(data))
+	case ArchiveTarGz:
+		err = index.loadTarGz(bytes.NewReader(data))
+	case ArchiveUnknown:
+		err = errors.New("unrecognised archive format")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := index.link(); err != nil {
+		return nil, err
+	}
+
+	return &archiveFS{
+		nodes: index.nodes,
+		calc:  &RelativeCalc{},
+	}, nil
+}
+
+// OpenArchiveFS reads the archive at name from fS and creates a read only
+// file system over its content. If format is ArchiveUnknown, it is
+// detected from the content.
+func OpenArchiveFS(fS ReadFileFS, name string, format ArchiveFormat) (ReaderFS, error) {
+	data, err := fS.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewArchiveFS(data, format)
+}
+
+// 🧩 ---> index
+
+type archiveNode struct {
+	info     *archiveInfo
+	open     func() (io.ReadCloser, error)
+	children []string
+}
+
+type archiveIndex struct {
+	nodes map[string]*archiveNode
+}
+
+func (x *archiveIndex) loadZip(data []byte) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range reader.File {
+		info := file.FileInfo()
+
+		switch {
+		case info.IsDir():
+			err = x.add(file.Name, info.Mode(), info.ModTime(), 0, nil)
+		case info.Mode().IsRegular():
+			err = x.add(file.Name, info.Mode(), info.ModTime(), info.Size(), file.Open)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (x *archiveIndex) loadTarGz(r io.Reader) error {
+	decompressor, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer decompressor.Close() //nolint:errcheck // ok, read only
+
+	return x.loadTar(decompressor)
+}
+
+func (x *archiveIndex) loadTar(r io.Reader) error {
+	reader := tar.NewReader(r)
+
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		info := header.FileInfo()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = x.add(header.Name, info.Mode(), info.ModTime(), 0, nil)
+
+		case tar.TypeReg:
+			content, readErr := io.ReadAll(reader)
+			if readErr != nil {
+				return readErr
+			}
+
+			err = x.add(header.Name, info.Mode(), info.ModTime(), int64(len(content)),
+				func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader(content)), nil
+				},
+			)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// add adds an entry to the index; an entry that appears more than once
+// replaces the previous one, as it would on extraction.
+func (x *archiveIndex) add(name string, mode fs.FileMode, modTime time.Time, size int64,
+	open func() (io.ReadCloser, error),
+) error {
+	clean := path.Clean(strings.TrimLeft(name, separatorStr))
+
+	if !fs.ValidPath(clean) {
+		return NewInvalidPathError("ArchiveFS", name)
+	}
+
+	if clean == "." {
+		return nil
+	}
+
+	x.nodes[clean] = &archiveNode{
+		info: &archiveInfo{
+			name:    path.Base(clean),
+			size:    size,
+			mode:    mode,
+			modTime: modTime,
+		},
+		open: open,
+	}
+
+	return nil
+}
+
+// link synthesises missing parent directories and populates the children
+// of every directory.
+func (x *archiveIndex) link() error {
+	names := make([]string, 0, len(x.nodes))
+
+	for name := range x.nodes {
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		for parent := path.Dir(name); parent != "."; parent = path.Dir(parent) {
+			if _, found := x.nodes[parent]; found {
+				break
+			}
+
+			x.nodes[parent] = &archiveNode{
+				info: &archiveInfo{
+					name: path.Base(parent),
+					mode: fs.ModeDir | archiveDirPerm,
+				},
+			}
+		}
+	}
+
+	for name, node := range x.nodes {
+		if name == "." {
+			continue
+		}
+
+		parent := x.nodes[path.Dir(name)]
+
+		if !parent.info.IsDir() {
+			return newInvalidPathError("ArchiveFS", name, ReasonInvalidPath,
+				fmt.Errorf("parent %q is not a directory", path.Dir(name)),
+			)
+		}
+
+		parent.children = append(parent.children, node.info.name)
+	}
+
+	for _, node := range x.nodes {
+		slices.Sort(node.children)
+	}
+
+	return nil
+}
+
+// 🧩 ---> file system
+
+// 🎯 archiveFS
+type archiveFS struct {
+	nodes map[string]*archiveNode
+	calc  PathCalc
+}
+
+func (f *archiveFS) Calc() PathCalc {
+	return f.calc
+}
+
+func (f *archiveFS) IsRelative() bool {
+	return true
+}
+
+// Root returns the virtual root of the archive, which is always "/",
+// since an archive has no location on the local file system.
+func (f *archiveFS) Root() string {
+	return separatorStr
+}
+
+// ToAbsolute converts name into an absolute path beneath the virtual root
+func (f *archiveFS) ToAbsolute(name string) (string, error) {
+	rel, err := NewRelPath(name)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(separatorStr, rel.String()), nil
+}
+
+// ToRelative converts an absolute path beneath the virtual root into a
+// path relative to the root of the archive.
+func (f *archiveFS) ToRelative(abs string) (string, error) {
+	if !strings.HasPrefix(abs, separatorStr) {
+		return "", NewInvalidPathError("ToRelative", abs)
+	}
+
+	rel := strings.TrimPrefix(path.Clean(abs), separatorStr)
+
+	return lo.Ternary(rel == "", ".", rel), nil
+}
+
+func (f *archiveFS) lookup(op, name string) (*archiveNode, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	node, found := f.nodes[name]
+	if !found {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return node, nil
+}
+
+// Open opens the named file or directory within the archive
+func (f *archiveFS) Open(name string) (fs.File, error) {
+	node, err := f.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.info.IsDir() {
+		return &syntheticDir{
+			name:    name,
+			info:    node.info,
+			entries: f.entries(name, node),
+		}, nil
+	}
+
+	reader, err := node.open()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &archiveFile{
+		info:   node.info,
+		reader: reader,
+	}, nil
+}
+
+// Stat returns a FileInfo describing the named file or directory
+func (f *archiveFS) Stat(name string) (fs.FileInfo, error) {
+	node, err := f.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.info, nil
+}
+
+// ReadDir reads the named directory and returns a list of directory
+// entries sorted by filename.
+func (f *archiveFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	node, err := f.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !node.info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	return f.entries(name, node), nil
+}
+
+func (f *archiveFS) entries(name string, node *archiveNode) []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(node.children))
+
+	for _, child := range node.children {
+		entries = append(entries,
+			fs.FileInfoToDirEntry(f.nodes[path.Join(name, child)].info),
+		)
+	}
+
+	return entries
+}
+
+// ReadFile reads the named file and returns its contents
+func (f *archiveFS) ReadFile(name string) ([]byte, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck // ok, read only
+
+	if _, ok := file.(*syntheticDir); ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+
+	return io.ReadAll(file)
+}
+
+// FileExists does file exist at the path specified
+func (f *archiveFS) FileExists(name string) bool {
+	node, found := f.nodes[name]
+
+	return found && !node.info.IsDir()
+}
+
+// DirectoryExists does directory exist at the path specified
+func (f *archiveFS) DirectoryExists(name string) bool {
+	node, found := f.nodes[name]
+
+	return found && node.info.IsDir()
+}
+
+// 🧩 ---> files
+
+type archiveInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i *archiveInfo) Name() string       { return i.name }
+func (i *archiveInfo) Size() int64        { return i.size }
+func (i *archiveInfo) Mode() fs.FileMode  { return i.mode }
+func (i *archiveInfo) ModTime() time.Time { return i.modTime }
+func (i *archiveInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *archiveInfo) Sys() any           { return nil }
+
+type archiveFile struct {
+	info   *archiveInfo
+	reader io.ReadCloser
+}
+
+func (f *archiveFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *archiveFile) Read(p []byte) (int, error) {
+	return f.reader.Read(p)
+}
+
+func (f *archiveFile) Close() error {
+	return f.reader.Close()
+}
+
+// syntheticDir is an open directory, whose entries have been
+// synthesised by the file system.
+type syntheticDir struct {
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *syntheticDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *syntheticDir) Read(_ []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *syntheticDir) Close() error {
+	return nil
+}
+
+// ReadDir reads the contents of the directory, with the semantics
+// defined by fs.ReadDirFile.
+func (d *syntheticDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	remaining := len(d.entries) - d.offset
+
+	if count > 0 && remaining == 0 {
+		return nil, io.EOF
+	}
+
+	if count <= 0 || count > remaining {
+		count = remaining
+	}
+
+	entries := d.entries[d.offset : d.offset+count]
+	d.offset += count
+
+	return entries, nil
+}
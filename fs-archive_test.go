@@ -0,0 +1,194 @@
+package nef_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/fs"
+	"testing/fstest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+type archiveEntry struct {
+	name    string
+	content string
+	dir     bool
+}
+
+var (
+	bundle = []archiveEntry{
+		{name: "release/", dir: true},
+		{name: "release/bin/nef", content: "binary"},
+		{name: "release/README.md", content: "# read me"},
+		{name: "docs/guide/intro.md", content: "intro"},
+	}
+	bundleModTime = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func zipArchive(entries []archiveEntry) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+
+	for _, entry := range entries {
+		header := &zip.FileHeader{
+			Name:     entry.name,
+			Modified: bundleModTime,
+			Method:   zip.Deflate,
+		}
+		header.SetMode(lab.Perms.File)
+
+		if entry.dir {
+			header.SetMode(fs.ModeDir | lab.Perms.Dir)
+		}
+
+		w, err := writer.CreateHeader(header)
+		Expect(err).To(Succeed())
+		_, err = w.Write([]byte(entry.content))
+		Expect(err).To(Succeed())
+	}
+
+	Expect(writer.Close()).To(Succeed())
+
+	return buffer.Bytes()
+}
+
+func tarArchive(entries []archiveEntry, compress bool) []byte {
+	var buffer bytes.Buffer
+	var compressor *gzip.Writer
+	writer := tar.NewWriter(&buffer)
+
+	if compress {
+		compressor = gzip.NewWriter(&buffer)
+		writer = tar.NewWriter(compressor)
+	}
+
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Mode:     int64(lab.Perms.File),
+			Size:     int64(len(entry.content)),
+			ModTime:  bundleModTime,
+			Typeflag: tar.TypeReg,
+		}
+
+		if entry.dir {
+			header.Mode = int64(lab.Perms.Dir)
+			header.Typeflag = tar.TypeDir
+		}
+
+		Expect(writer.WriteHeader(header)).To(Succeed())
+		_, err := writer.Write([]byte(entry.content))
+		Expect(err).To(Succeed())
+	}
+
+	Expect(writer.Close()).To(Succeed())
+
+	if compressor != nil {
+		Expect(compressor.Close()).To(Succeed())
+	}
+
+	return buffer.Bytes()
+}
+
+type archiveTE struct {
+	format nef.ArchiveFormat
+	create func() []byte
+}
+
+var _ = Describe("ArchiveFS", func() {
+	DescribeTable("formats",
+		func(entry *archiveTE) {
+			data := entry.create()
+			Expect(nef.DetectArchiveFormat(data)).To(Equal(entry.format))
+
+			fS, err := nef.NewArchiveFS(data, nef.ArchiveUnknown)
+			Expect(err).To(Succeed())
+			Expect(fstest.TestFS(fS,
+				"release/bin/nef", "release/README.md", "docs/guide/intro.md",
+			)).To(Succeed())
+
+			Expect(fS.IsRelative()).To(BeTrue())
+			Expect(fS.FileExists("release/README.md")).To(BeTrue())
+			Expect(fS.DirectoryExists("release/bin")).To(BeTrue(), "synthesised directory")
+			Expect(fS.DirectoryExists("docs")).To(BeTrue(), "synthesised directory")
+			Expect(fS.FileExists("release")).To(BeFalse())
+
+			content, err := fS.ReadFile("release/bin/nef")
+			Expect(err).To(Succeed())
+			Expect(content).To(Equal([]byte("binary")))
+
+			info, err := fS.Stat("release/README.md")
+			Expect(err).To(Succeed())
+			Expect(info.Size()).To(Equal(int64(len("# read me"))))
+			Expect(info.ModTime().Equal(bundleModTime)).To(BeTrue())
+
+			entries, err := fS.ReadDir("release")
+			Expect(err).To(Succeed())
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].Name()).To(Equal("README.md"))
+			Expect(entries[1].IsDir()).To(BeTrue())
+
+			_, err = fS.Stat("release/missing.txt")
+			Expect(err).To(MatchError(fs.ErrNotExist))
+		},
+		func(entry *archiveTE) string {
+			return fmt.Sprintf("🧪 ===> format: '%v', should: read archive", entry.format)
+		},
+		Entry(nil, &archiveTE{
+			format: nef.ArchiveZip,
+			create: func() []byte { return zipArchive(bundle) },
+		}),
+		Entry(nil, &archiveTE{
+			format: nef.ArchiveTar,
+			create: func() []byte { return tarArchive(bundle, false) },
+		}),
+		Entry(nil, &archiveTE{
+			format: nef.ArchiveTarGz,
+			create: func() []byte { return tarArchive(bundle, true) },
+		}),
+	)
+
+	When("given: entry outside the archive root", func() {
+		It("🧪 should: return invalid path error", func() {
+			_, err := nef.NewArchiveFS(zipArchive([]archiveEntry{
+				{name: "../../etc/passwd", content: "root"},
+			}), nef.ArchiveZip)
+			Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+		})
+	})
+
+	When("given: unrecognised content", func() {
+		It("🧪 should: return error", func() {
+			_, err := nef.NewArchiveFS([]byte("not an archive"), nef.ArchiveUnknown)
+			Expect(err).NotTo(Succeed())
+		})
+	})
+
+	When("given: archive in another file system", func() {
+		It("🧪 should: open and build manifest", func() {
+			memFS := luna.NewMemFS()
+			memFS.MapFS["bundle.tar.gz"] = &fstest.MapFile{
+				Data: tarArchive(bundle, true),
+				Mode: lab.Perms.File,
+			}
+
+			fS, err := nef.OpenArchiveFS(memFS, "bundle.tar.gz", nef.ArchiveUnknown)
+			Expect(err).To(Succeed())
+
+			manifest, err := nef.BuildManifest(fS, "release", nef.HashSHA256)
+			Expect(err).To(Succeed())
+			Expect(manifest.Entries).To(HaveLen(2))
+			Expect(manifest.Entries[0].Path).To(Equal("README.md"))
+			Expect(manifest.Entries[1].Path).To(Equal("bin/nef"))
+		})
+	})
+})
@@ -1,7 +1,9 @@
 package nef_test
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -164,6 +166,8 @@ var _ = Describe("op: change", Ordered, func() {
 					return
 				}
 				Expect(nef.IsBinaryFsOpError(err)).To(BeTrue())
+				Expect(errors.Is(err, fs.ErrExist)).To(BeTrue())
+				IsBinaryFsOpReason(err, nef.ReasonDestinationClash)
 				Expect(luna.AsFile(entry.from)).To(luna.ExistInFS(fS))
 			},
 		}),
@@ -182,6 +186,27 @@ var _ = Describe("op: change", Ordered, func() {
 				err := fS.Change(entry.from, entry.to)
 				Expect(err).NotTo(Succeed(), fmt.Sprintf("OVERWRITE: %v", entry.overwrite))
 				Expect(nef.IsBinaryFsOpError(err)).To(BeTrue())
+				Expect(errors.Is(err, fs.ErrNotExist)).To(BeTrue())
+				IsBinaryFsOpReason(err, nef.ReasonSourceNotFound)
+			},
+		}),
+
+		Entry(nil, fsTE[nef.UniversalFS]{
+			given:   "[from] file exists, [to] directory exists",
+			should:  "fail, file can't be changed onto directory",
+			op:      "Change",
+			require: lab.Static.FS.Scratch,
+			from:    lab.Static.FS.Change.From.File,
+			to:      "no-geography-CHANGE-TO",
+			arrange: func(entry fsTE[nef.UniversalFS], _ nef.UniversalFS) {
+				Expect(require(root, entry.require, entry.from)).To(Succeed())
+				Expect(require(root, lab.Static.FS.Change.Destination)).To(Succeed())
+			},
+			action: func(entry fsTE[nef.UniversalFS], fS nef.UniversalFS) {
+				err := fS.Change(entry.from, entry.to)
+				Expect(err).NotTo(Succeed(), fmt.Sprintf("OVERWRITE: %v", entry.overwrite))
+				Expect(errors.Is(err, fs.ErrExist)).To(BeTrue())
+				IsBinaryFsOpReason(err, nef.ReasonFileOntoDirectory)
 			},
 		}),
 
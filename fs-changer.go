@@ -1,13 +1,16 @@
 package nef
 
 import (
-	"os"
 	"strings"
 	"sync"
 
 	"github.com/snivilised/nefilim/internal/third/lo"
 )
 
+const (
+	changeOpName = "Change"
+)
+
 type (
 	changer interface {
 		create() changer
@@ -39,25 +42,35 @@ func (m *baseChanger) change(from, to string) error {
 		return err
 	}
 
-	mask := m.query(from, to)
+	mask, err := m.query(from, to)
+	if err != nil {
+		return err
+	}
 
 	if action, exists := m.actions[mask]; exists {
 		return action(from, to)
 	}
 
-	return NewInvalidBinaryFsOpError(moveOpName, from, to)
+	return mask.diagnose(changeOpName, from, to)
 }
 
-func (m *baseChanger) query(from, to string) bitmask {
-	fromExists, fromIsDir := m.peek(from)
-	toExists, toIsDir := m.peek(m.fill(from, to))
+func (m *baseChanger) query(from, to string) (bitmask, error) {
+	fromExists, fromIsDir, err := peek(m.fS, from)
+	if err != nil {
+		return bitmask{}, err
+	}
+
+	toExists, toIsDir, err := peek(m.fS, m.fill(from, to))
+	if err != nil {
+		return bitmask{}, err
+	}
 
 	return bitmask{
 		fromExists: fromExists,
 		toExists:   toExists,
 		fromIsDir:  fromIsDir,
 		toIsDir:    toIsDir,
-	}
+	}, nil
 }
 
 func (m *baseChanger) fill(from, to string) string {
@@ -75,10 +88,7 @@ func (m *baseChanger) rename(from, to string) error {
 		return nil
 	}
 
-	return os.Rename(
-		m.calc.Join(m.root, from),
-		m.calc.Join(m.root, destination),
-	)
+	return m.fS.Rename(from, destination)
 }
 
 type lazyChanger struct {
@@ -86,15 +96,15 @@ type lazyChanger struct {
 	changer changer
 }
 
-func (l *lazyChanger) instance(root string, overwrite bool, fS ChangerFS) changer {
+func (l *lazyChanger) instance(overwrite bool, fS ChangerFS) changer {
 	l.once.Do(func() {
-		l.changer = l.create(root, overwrite, fS)
+		l.changer = l.create(overwrite, fS)
 	})
 
 	return l.changer
 }
 
-func (l *lazyChanger) create(root string, overwrite bool, fS ChangerFS) changer {
+func (l *lazyChanger) create(overwrite bool, fS ChangerFS) changer {
 	// create an interface for this function
 	//
 	calc := fS.Calc()
@@ -106,7 +116,6 @@ func (l *lazyChanger) create(root string, overwrite bool, fS ChangerFS) changer
 					baseOp: baseOp[ChangerFS]{
 						fS:   fS,
 						calc: calc,
-						root: root,
 					},
 				},
 			}
@@ -117,7 +126,6 @@ func (l *lazyChanger) create(root string, overwrite bool, fS ChangerFS) changer
 					baseOp: baseOp[ChangerFS]{
 						fS:   fS,
 						calc: calc,
-						root: root,
 					},
 				},
 			}
@@ -158,5 +166,5 @@ func (m *tentativeChanger) create() changer {
 func (m *tentativeChanger) rejectFileOverwrite(from, to string) error {
 	// to file already exists
 	//
-	return NewInvalidBinaryFsOpError("Change", from, to)
+	return rejectClash(changeOpName, from, to)
 }
@@ -0,0 +1,543 @@
+package nef
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/snivilised/nefilim/internal/third/lo"
+)
+
+// 🔥 A compressed file system is a decorator over a UniversalFS, that
+// stores every file compressed by a Codec, under its name with the codec's
+// suffix appended (eg "notes.txt" is stored as "notes.txt.gz"), but
+// exposes it under its logical name with its uncompressed content.
+// Directories are not affected. Only files carrying the suffix are
+// visible through the decorator; other files in the underlying file
+// system are hidden from ReadDir.
+//
+// The logical size reported by Stat and ReadDir is read from the
+// compressed file, if the codec is a SizedCodec that can find it there
+// (eg the gzip trailer or the zstd frame header); otherwise it is found by
+// decompressing the file, so it is proportional to the size of the
+// content.
+
+// Codec compresses and decompresses the content of files stored in a
+// CompressedFS
+type Codec interface {
+	// Suffix is appended to the logical name of a file to derive the name
+	// of the compressed file in the underlying file system
+	Suffix() string
+	// NewWriter returns a writer that compresses everything written to it
+	// into w; the content is only complete when the writer is closed.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader returns a reader of the decompressed content of r
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// SizedCodec is a Codec that can read the logical size of the content
+// from the compressed data, without decompressing it
+type SizedCodec interface {
+	Codec
+	// ContentSize returns the logical size of the content compressed in r,
+	// which holds size bytes; false is returned if it can't be determined.
+	ContentSize(r io.ReaderAt, size int64) (int64, bool)
+}
+
+// gzipMaxRatio is the maximum ratio of the logical size to the compressed
+// size of deflated content; it bounds the stored size for which the
+// trailer, which records the logical size modulo 2^32, is unambiguous.
+const gzipMaxRatio = 1032
+
+// GzipCodec is a Codec that compresses with gzip; a zero Level denotes
+// gzip.DefaultCompression.
+type GzipCodec struct {
+	Level int
+}
+
+// Suffix returns ".gz"
+func (c *GzipCodec) Suffix() string {
+	return ".gz"
+}
+
+// NewWriter returns a gzip writer
+func (c *GzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w,
+		lo.Ternary(c.Level == 0, gzip.DefaultCompression, c.Level),
+	)
+}
+
+// NewReader returns a gzip reader
+func (c *GzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// ContentSize reads the logical size from the ISIZE field of the gzip
+// trailer, provided the file is small enough that the size can't have
+// wrapped and that it holds a single member.
+func (c *GzipCodec) ContentSize(r io.ReaderAt, size int64) (int64, bool) {
+	const (
+		minimum = 18 // header and trailer of an empty member
+		trailer = 4
+	)
+
+	if size < minimum || size > math.MaxUint32/gzipMaxRatio {
+		return 0, false
+	}
+
+	var isize [trailer]byte
+	if _, err := r.ReadAt(isize[:], size-trailer); err != nil {
+		return 0, false
+	}
+
+	return int64(binary.LittleEndian.Uint32(isize[:])), true
+}
+
+// ZstdCodec is a Codec that compresses with zstandard
+type ZstdCodec struct{}
+
+var (
+	_ SizedCodec = (*GzipCodec)(nil)
+	_ SizedCodec = (*ZstdCodec)(nil)
+)
+
+// Suffix returns ".zst"
+func (c *ZstdCodec) Suffix() string {
+	return ".zst"
+}
+
+// NewWriter returns a zstandard encoder
+func (c *ZstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w)
+}
+
+// ContentSize reads the logical size from the frame content size of the
+// zstandard frame header, which is written by WriteFile of a CompressedFS
+// for content of non trivial size; an empty file has no content.
+func (c *ZstdCodec) ContentSize(r io.ReaderAt, size int64) (int64, bool) {
+	if size == 0 {
+		return 0, true
+	}
+
+	buffer := make([]byte, min(size, zstd.HeaderMaxSize))
+	if _, err := r.ReadAt(buffer, 0); err != nil && !errors.Is(err, io.EOF) {
+		return 0, false
+	}
+
+	var header zstd.Header
+	if err := header.Decode(buffer); err != nil || !header.HasFCS {
+		return 0, false
+	}
+
+	if header.FrameContentSize > math.MaxInt64 {
+		return 0, false
+	}
+
+	return int64(header.FrameContentSize), true
+}
+
+// NewReader returns a zstandard decoder
+func (c *ZstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return decoder.IOReadCloser(), nil
+}
+
+// CompressedFS is a UniversalFS that transparently compresses the files
+// it stores in the file system it decorates.
+type CompressedFS struct {
+	UniversalFS
+	codec Codec
+}
+
+var (
+	_ UniversalFS   = (*CompressedFS)(nil)
+	_ ChangeTimesFS = (*CompressedFS)(nil)
+	_ ContextFS     = (*CompressedFS)(nil)
+)
+
+// NewCompressedFS creates a CompressedFS that stores files in fS,
+// compressed by codec.
+func NewCompressedFS(fS UniversalFS, codec Codec) *CompressedFS {
+	return &CompressedFS{
+		UniversalFS: fS,
+		codec:       codec,
+	}
+}
+
+// Codec returns the codec used to compress files
+func (f *CompressedFS) Codec() Codec {
+	return f.codec
+}
+
+// Underlying returns the name of the item in the underlying file system,
+// that is denoted by name; ie name with the codec suffix appended unless
+// name is an existing directory.
+func (f *CompressedFS) Underlying(name string) string {
+	if f.UniversalFS.DirectoryExists(name) {
+		return name
+	}
+
+	return name + f.codec.Suffix()
+}
+
+// FileExists checks whether the compressed file denoted by name exists
+func (f *CompressedFS) FileExists(name string) bool {
+	return f.UniversalFS.FileExists(name + f.codec.Suffix())
+}
+
+// Open opens the named item; a file is decompressed as it is read.
+func (f *CompressedFS) Open(name string) (fs.File, error) {
+	if f.UniversalFS.DirectoryExists(name) {
+		info, err := f.UniversalFS.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := f.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return &syntheticDir{
+			name:    name,
+			info:    info,
+			entries: entries,
+		}, nil
+	}
+
+	file, err := f.UniversalFS.Open(name + f.codec.Suffix())
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := f.codec.NewReader(file)
+	if err != nil {
+		_ = file.Close()
+
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &compressedFile{
+		fS:     f,
+		name:   name,
+		file:   file,
+		reader: reader,
+	}, nil
+}
+
+// ReadFile reads the decompressed content of the named file
+func (f *CompressedFS) ReadFile(name string) ([]byte, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck // ok, read only
+
+	return io.ReadAll(file)
+}
+
+// Stat returns the info of the named item; the size of a file is its
+// logical, ie uncompressed, size.
+func (f *CompressedFS) Stat(name string) (fs.FileInfo, error) {
+	if f.UniversalFS.DirectoryExists(name) {
+		return f.UniversalFS.Stat(name)
+	}
+
+	info, err := f.UniversalFS.Stat(name + f.codec.Suffix())
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := f.logicalSize(name, info.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	return &compressedInfo{
+		FileInfo: info,
+		name:     strings.TrimSuffix(info.Name(), f.codec.Suffix()),
+		size:     size,
+	}, nil
+}
+
+// logicalSize returns the uncompressed size of the named file, whose
+// compressed size is stored. The size is read from the compressed file if
+// the codec is a SizedCodec and the underlying file supports io.ReaderAt;
+// otherwise the file is decompressed.
+func (f *CompressedFS) logicalSize(name string, stored int64) (int64, error) {
+	if sized, ok := f.codec.(SizedCodec); ok {
+		file, err := f.UniversalFS.Open(name + f.codec.Suffix())
+		if err != nil {
+			return 0, err
+		}
+
+		var (
+			size  int64
+			found bool
+		)
+
+		if at, ok := file.(io.ReaderAt); ok {
+			size, found = sized.ContentSize(at, stored)
+		}
+
+		_ = file.Close()
+
+		if found {
+			return size, nil
+		}
+	}
+
+	file, err := f.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close() //nolint:errcheck // ok, read only
+
+	return io.Copy(io.Discard, file)
+}
+
+// ReadDir reads the named directory, returning the sub directories and
+// the compressed files under their logical names.
+func (f *CompressedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := f.UniversalFS.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	logical := make([]fs.DirEntry, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			logical = append(logical, entry)
+
+			continue
+		}
+
+		base, found := strings.CutSuffix(entry.Name(), f.codec.Suffix())
+		if !found || base == "" || !entry.Type().IsRegular() {
+			continue
+		}
+
+		logical = append(logical, &compressedEntry{
+			DirEntry: entry,
+			fS:       f,
+			name:     base,
+			path:     joinUnder(f, name, base),
+		})
+	}
+
+	return logical, nil
+}
+
+// WriteFile compresses data and writes it to the named file. If the
+// writer of the codec can record the size of the content up front (as a
+// zstandard encoder can), it is given the size, so that Stat can read it.
+func (f *CompressedFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	var buffer bytes.Buffer
+
+	writer, err := f.codec.NewWriter(&buffer)
+	if err != nil {
+		return err
+	}
+
+	if sizer, ok := writer.(interface {
+		ResetContentSize(w io.Writer, size int64)
+	}); ok {
+		sizer.ResetContentSize(&buffer, int64(len(data)))
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return f.UniversalFS.WriteFile(name+f.codec.Suffix(), buffer.Bytes(), perm)
+}
+
+// Create creates the named file; the file returned is an io.Writer,
+// whose content is compressed and only complete once the file is closed.
+func (f *CompressedFS) Create(name string) (fs.File, error) {
+	file, err := f.UniversalFS.Create(name + f.codec.Suffix())
+	if err != nil {
+		return nil, err
+	}
+
+	w, ok := file.(io.Writer)
+	if !ok {
+		_ = file.Close()
+
+		return nil, &fs.PathError{Op: "create", Path: name, Err: errors.ErrUnsupported}
+	}
+
+	writer, err := f.codec.NewWriter(w)
+	if err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	return &compressedWriter{
+		File:   file,
+		name:   name,
+		writer: writer,
+	}, nil
+}
+
+// CopyFS copies the file system fsys into the directory dir, compressing
+// each file. Existing files are not overwritten and symbolic links are
+// rejected; a file that fails to copy does not prevent the others from
+// being copied and the errors are joined.
+func (f *CompressedFS) CopyFS(dir string, fsys fs.FS) error {
+	return f.CopyFSContext(context.Background(), dir, fsys)
+}
+
+// CopyFSContext is the cancellable variant of CopyFS
+func (f *CompressedFS) CopyFSContext(ctx context.Context, dir string, fsys fs.FS) error {
+	return copyThrough(ctx, f, dir, fsys, f.WriteFile)
+}
+
+// MakeDirAllContext is the cancellable variant of MakeDirAll
+func (f *CompressedFS) MakeDirAllContext(ctx context.Context, name string, perm os.FileMode) error {
+	return MakeDirAllContext(ctx, f.UniversalFS, name, perm)
+}
+
+// RemoveAllContext is the cancellable variant of RemoveAll
+func (f *CompressedFS) RemoveAllContext(ctx context.Context, path string) error {
+	return RemoveAllContext(ctx, f.UniversalFS, f.Underlying(path))
+}
+
+// Remove removes the named file or empty directory
+func (f *CompressedFS) Remove(name string) error {
+	return f.UniversalFS.Remove(f.Underlying(name))
+}
+
+// RemoveAll removes path and any children it contains
+func (f *CompressedFS) RemoveAll(path string) error {
+	return f.UniversalFS.RemoveAll(f.Underlying(path))
+}
+
+// Move moves an item; the names are mapped to the underlying compressed
+// names, except for an existing destination directory.
+func (f *CompressedFS) Move(from, to string) error {
+	return f.binary(f.UniversalFS.Move, from, to)
+}
+
+// Change renames an item within its directory; a file's new name is
+// mapped to the underlying compressed name. Since to is a name, rather
+// than a path, it is resolved against the directory of from, to detect
+// an existing directory.
+func (f *CompressedFS) Change(from, to string) error {
+	if f.UniversalFS.DirectoryExists(from) {
+		return f.UniversalFS.Change(from, to)
+	}
+
+	sibling := joinUnder(f.UniversalFS, f.Calc().Dir(from), to)
+
+	return f.UniversalFS.Change(from+f.codec.Suffix(),
+		lo.Ternary(f.UniversalFS.DirectoryExists(sibling), to, to+f.codec.Suffix()),
+	)
+}
+
+// Rename renames an item, mapping names as Move does.
+func (f *CompressedFS) Rename(from, to string) error {
+	return f.binary(f.UniversalFS.Rename, from, to)
+}
+
+// Copy copies an item, mapping names as Move does.
+func (f *CompressedFS) Copy(from, to string) error {
+	return f.binary(f.UniversalFS.Copy, from, to)
+}
+
+func (f *CompressedFS) binary(op func(from, to string) error, from, to string) error {
+	if f.UniversalFS.DirectoryExists(from) {
+		return op(from, to)
+	}
+
+	return op(from+f.codec.Suffix(), f.Underlying(to))
+}
+
+// Chtimes changes the access and modification times of the named item,
+// if the decorated file system implements ChangeTimesFS.
+func (f *CompressedFS) Chtimes(name string, atime, mtime time.Time) error {
+	changer, ok := f.UniversalFS.(ChangeTimesFS)
+	if !ok {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: errors.ErrUnsupported}
+	}
+
+	return changer.Chtimes(f.Underlying(name), atime, mtime)
+}
+
+type compressedInfo struct {
+	fs.FileInfo
+	name string
+	size int64
+}
+
+func (i *compressedInfo) Name() string { return i.name }
+func (i *compressedInfo) Size() int64  { return i.size }
+
+type compressedEntry struct {
+	fs.DirEntry
+	fS   *CompressedFS
+	name string
+	path string
+}
+
+func (e *compressedEntry) Name() string { return e.name }
+
+func (e *compressedEntry) Info() (fs.FileInfo, error) {
+	return e.fS.Stat(e.path)
+}
+
+type compressedFile struct {
+	fS     *CompressedFS
+	name   string
+	file   fs.File
+	reader io.ReadCloser
+}
+
+func (c *compressedFile) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+func (c *compressedFile) Stat() (fs.FileInfo, error) {
+	return c.fS.Stat(c.name)
+}
+
+func (c *compressedFile) Close() error {
+	return errors.Join(c.reader.Close(), c.file.Close())
+}
+
+type compressedWriter struct {
+	fs.File
+	name   string
+	writer io.WriteCloser
+}
+
+func (c *compressedWriter) Write(p []byte) (int, error) {
+	return c.writer.Write(p)
+}
+
+func (c *compressedWriter) Read(_ []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: c.name, Err: errors.ErrUnsupported}
+}
+
+func (c *compressedWriter) Close() error {
+	return errors.Join(c.writer.Close(), c.File.Close())
+}
@@ -0,0 +1,184 @@
+package nef_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+var _ = Describe("CompressedFS", func() {
+	content := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 64))
+
+	DescribeTable("round trip",
+		func(codec nef.Codec) {
+			memFS := luna.NewMemFS()
+			fS := nef.NewCompressedFS(memFS, codec)
+
+			Expect(fS.MakeDirAll("logs", lab.Perms.Dir)).To(Succeed())
+			Expect(fS.WriteFile("logs/app.log", content, lab.Perms.File)).To(Succeed())
+
+			stored, found := memFS.MapFS["logs/app.log"+codec.Suffix()]
+			Expect(found).To(BeTrue(), "stored under compressed name")
+			Expect(len(stored.Data)).To(BeNumerically("<", len(content)))
+			Expect(fS.FileExists("logs/app.log")).To(BeTrue())
+
+			Expect(fS.ReadFile("logs/app.log")).To(Equal(content))
+
+			info, err := fS.Stat("logs/app.log")
+			Expect(err).To(Succeed())
+			Expect(info.Name()).To(Equal("app.log"))
+			Expect(info.Size()).To(Equal(int64(len(content))))
+
+			file, err := fS.Open("logs/app.log")
+			Expect(err).To(Succeed())
+			Expect(io.ReadAll(file)).To(Equal(content))
+			Expect(file.Close()).To(Succeed())
+
+			entries, err := fS.ReadDir("logs")
+			Expect(err).To(Succeed())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Name()).To(Equal("app.log"))
+			entryInfo, err := entries[0].Info()
+			Expect(err).To(Succeed())
+			Expect(entryInfo.Size()).To(Equal(int64(len(content))))
+		},
+		func(codec nef.Codec) string {
+			return fmt.Sprintf("🧪 ===> suffix: '%v', should: store compressed and read logical", codec.Suffix())
+		},
+		Entry(nil, &nef.GzipCodec{}),
+		Entry(nil, &nef.ZstdCodec{}),
+	)
+
+	DescribeTable("logical size",
+		func(codec nef.Codec) {
+			memFS := luna.NewMemFS()
+			fS := nef.NewCompressedFS(memFS, codec)
+			Expect(fS.WriteFile("app.log", content, lab.Perms.File)).To(Succeed())
+
+			// corrupt the compressed body, so that decompression fails and
+			// the size can only be read from the header or trailer
+			stored := memFS.MapFS["app.log"+codec.Suffix()]
+			stored.Data[len(stored.Data)/2] ^= 0xff
+
+			info, err := fS.Stat("app.log")
+			Expect(err).To(Succeed())
+			Expect(info.Size()).To(Equal(int64(len(content))))
+		},
+		func(codec nef.Codec) string {
+			return fmt.Sprintf("🧪 ===> suffix: '%v', should: read size without decompressing", codec.Suffix())
+		},
+		Entry(nil, &nef.GzipCodec{}),
+		Entry(nil, &nef.ZstdCodec{}),
+	)
+
+	DescribeTable("CopyFS",
+		func(codec nef.Codec) {
+			memFS := luna.NewMemFS()
+			fS := nef.NewCompressedFS(memFS, codec)
+			source := fstest.MapFS{
+				"a.txt":     {Data: content, Mode: lab.Perms.File},
+				"sub/b.txt": {Data: []byte("beta"), Mode: lab.Perms.File},
+			}
+
+			Expect(fS.CopyFS("imported", source)).To(Succeed())
+			Expect(memFS.MapFS).To(HaveKey("imported/a.txt" + codec.Suffix()))
+			Expect(memFS.MapFS).To(HaveKey("imported/sub/b.txt" + codec.Suffix()))
+			Expect(fS.ReadFile("imported/a.txt")).To(Equal(content))
+			Expect(fS.ReadFile("imported/sub/b.txt")).To(Equal([]byte("beta")))
+
+			err := fS.CopyFS("imported", source)
+			Expect(errors.Is(err, fs.ErrExist)).To(BeTrue())
+		},
+		func(codec nef.Codec) string {
+			return fmt.Sprintf("🧪 ===> suffix: '%v', should: compress each file copied", codec.Suffix())
+		},
+		Entry(nil, &nef.GzipCodec{}),
+		Entry(nil, &nef.ZstdCodec{}),
+	)
+
+	When("given: uncompressed file in underlying file system", func() {
+		It("🧪 should: hide it from directory listing", func() {
+			memFS := luna.NewMemFS()
+			fS := nef.NewCompressedFS(memFS, &nef.GzipCodec{})
+			Expect(memFS.WriteFile("plain.txt", content, lab.Perms.File)).To(Succeed())
+			Expect(fS.WriteFile("packed.txt", content, lab.Perms.File)).To(Succeed())
+
+			entries, err := fS.ReadDir(".")
+			Expect(err).To(Succeed())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Name()).To(Equal("packed.txt"))
+			Expect(fS.FileExists("plain.txt")).To(BeFalse())
+		})
+	})
+
+	Context("relative file system", func() {
+		var (
+			root string
+			fS   *nef.CompressedFS
+		)
+
+		BeforeEach(func() {
+			root = GinkgoT().TempDir()
+			fS = nef.NewCompressedFS(nef.NewUniversalFS(nef.Rel{
+				Root: root,
+			}), &nef.GzipCodec{Level: gzip.BestCompression})
+		})
+
+		It("🧪 should: compress content written via Create", func() {
+			file, err := fS.Create("report.csv")
+			Expect(err).To(Succeed())
+			writer, ok := file.(io.Writer)
+			Expect(ok).To(BeTrue())
+			_, err = writer.Write(content)
+			Expect(err).To(Succeed())
+			Expect(file.Close()).To(Succeed())
+
+			data, err := os.ReadFile(filepath.Join(root, "report.csv.gz"))
+			Expect(err).To(Succeed())
+			reader, err := gzip.NewReader(bytes.NewReader(data))
+			Expect(err).To(Succeed())
+			Expect(io.ReadAll(reader)).To(Equal(content))
+		})
+
+		It("🧪 should: move and change underlying compressed names", func() {
+			Expect(fS.WriteFile("a.txt", content, lab.Perms.File)).To(Succeed())
+			Expect(fS.MakeDir("archive", lab.Perms.Dir)).To(Succeed())
+
+			Expect(fS.Change("a.txt", "b.txt")).To(Succeed())
+			Expect(filepath.Join(root, "b.txt.gz")).To(BeARegularFile())
+
+			Expect(fS.Move("b.txt", "archive")).To(Succeed())
+			Expect(filepath.Join(root, "archive", "b.txt.gz")).To(BeARegularFile())
+			Expect(fS.ReadFile("archive/b.txt")).To(Equal(content))
+
+			Expect(fS.Remove("archive/b.txt")).To(Succeed())
+			Expect(filepath.Join(root, "archive", "b.txt.gz")).NotTo(BeAnExistingFile())
+		})
+
+		When("given: directory at root with the new name", func() {
+			It("🧪 should: change name within parent directory", func() {
+				Expect(fS.MakeDir("b.txt", lab.Perms.Dir)).To(Succeed())
+				Expect(fS.MakeDir("docs", lab.Perms.Dir)).To(Succeed())
+				Expect(fS.WriteFile("docs/a.txt", content, lab.Perms.File)).To(Succeed())
+
+				Expect(fS.Change("docs/a.txt", "b.txt")).To(Succeed())
+				Expect(filepath.Join(root, "docs", "b.txt.gz")).To(BeARegularFile())
+				Expect(fS.ReadFile("docs/b.txt")).To(Equal(content))
+			})
+		})
+	})
+})
@@ -0,0 +1,432 @@
+package nef
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// MakeDirAllContext creates the directory name, along with any necessary
+// parents, in fS. If fS does not implement ContextFS, MakeDirAll is
+// invoked, unless ctx is already done.
+func MakeDirAllContext(ctx context.Context, fS MakeDirFS, name string, perm os.FileMode) error {
+	if cfs, ok := fS.(ContextFS); ok {
+		return cfs.MakeDirAllContext(ctx, name, perm)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return newCancelledError("MakeDirAll", name, 0, 0, err)
+	}
+
+	return fS.MakeDirAll(name, perm)
+}
+
+// RemoveAllContext removes path and any children it contains from fS. If
+// fS does not implement ContextFS, RemoveAll is invoked, unless ctx is
+// already done.
+func RemoveAllContext(ctx context.Context, fS RemoveFS, path string) error {
+	if cfs, ok := fS.(ContextFS); ok {
+		return cfs.RemoveAllContext(ctx, path)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return newCancelledError("RemoveAll", path, 0, 0, err)
+	}
+
+	return fS.RemoveAll(path)
+}
+
+// CopyFSContext copies the file system fsys into the directory dir of fS.
+// If fS does not implement ContextFS, CopyFS is invoked, unless ctx is
+// already done.
+func CopyFSContext(ctx context.Context, fS CopyFS, dir string, fsys fs.FS) error {
+	if cfs, ok := fS.(ContextFS); ok {
+		return cfs.CopyFSContext(ctx, dir, fsys)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return newCancelledError("CopyFS", dir, 0, 0, err)
+	}
+
+	return fS.CopyFS(dir, fsys)
+}
+
+// cancellable tracks the progress of a cancellable operation on the
+// native file system, whose context is checked at every safe point, ie
+// before an item is created, removed or a buffer is written. The native
+// path origin corresponds to path, the path the operation was invoked
+// with, so that progress can be reported in the form of the file system.
+// The counters are guarded by mu, since files may be copied concurrently.
+// Progress is reported outside of mu, but under reporting, so that the
+// callback is never invoked concurrently and can't stall the counters.
+type cancellable struct {
+	ctx        context.Context
+	op         string
+	path       string
+	origin     string
+	calc       PathCalc
+	progress   *ProgressOptions
+	workers    int
+	mu         sync.Mutex
+	completed  int64
+	bytes      int64
+	totalItems int64
+	totalBytes int64
+	sequence   int64
+	reporting  sync.Mutex
+	reported   int64
+}
+
+func newCancellable(ctx context.Context, op, path, origin string, calc PathCalc) *cancellable {
+	return &cancellable{
+		ctx:      ctx,
+		op:       op,
+		path:     path,
+		origin:   origin,
+		calc:     calc,
+		progress: progressOf(ctx),
+	}
+}
+
+func (c *cancellable) check() error {
+	if err := c.ctx.Err(); err != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		return newCancelledError(c.op, c.path, c.completed, c.bytes, err)
+	}
+
+	return nil
+}
+
+// advance records the items and bytes processed at the native path and
+// reports the progress made. The snapshot is taken under mu, but reported
+// after it is released; a snapshot taken before one that has already
+// been reported, by another worker, is dropped, so that the progress
+// reported never goes backwards.
+func (c *cancellable) advance(native string, items, bytes int64) {
+	c.mu.Lock()
+	c.completed += items
+	c.bytes += bytes
+
+	if c.progress == nil {
+		c.mu.Unlock()
+
+		return
+	}
+
+	c.sequence++
+	sequence := c.sequence
+	snapshot := Progress{
+		Op:         c.op,
+		Path:       c.display(native),
+		Items:      c.completed,
+		Bytes:      c.bytes,
+		TotalItems: c.totalItems,
+		TotalBytes: c.totalBytes,
+	}
+	c.mu.Unlock()
+
+	c.reporting.Lock()
+	defer c.reporting.Unlock()
+
+	if sequence < c.reported {
+		return
+	}
+
+	c.reported = sequence
+	c.progress.OnProgress(snapshot)
+}
+
+// display maps the native path to the form of the file system
+func (c *cancellable) display(native string) string {
+	return present(c.calc, c.path, c.origin, native)
+}
+
+// prescan estimates the totals of the operation from the tree traversed
+// by walk, counting the size of files only when sized, then reports the
+// initial progress. Nothing is scanned when progress is not reported or
+// the pre-scan is skipped.
+func (c *cancellable) prescan(walk func(fn fs.WalkDirFunc) error, sized bool) error {
+	if c.progress == nil {
+		return nil
+	}
+
+	if !c.progress.SkipPreScan {
+		err := walk(func(_ string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if err := c.check(); err != nil {
+				return err
+			}
+
+			c.totalItems++
+
+			if sized && entry.Type().IsRegular() {
+				if info, err := entry.Info(); err == nil {
+					c.totalBytes += info.Size()
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	c.advance(c.origin, 0, 0)
+
+	return nil
+}
+
+// makeDirAll creates the native directory path, one level at a time,
+// reporting each directory created. The pre-scan counts the missing
+// directories.
+func (c *cancellable) makeDirAll(path string, perm os.FileMode) error {
+	err := c.prescan(func(fn fs.WalkDirFunc) error {
+		for missing := path; ; missing = filepath.Dir(missing) {
+			if _, err := os.Stat(missing); err == nil || filepath.Dir(missing) == missing {
+				return nil
+			}
+
+			if err := fn(missing, nil, nil); err != nil {
+				return err
+			}
+		}
+	}, false)
+	if err != nil {
+		return err
+	}
+
+	return c.makeDir(path, perm)
+}
+
+func (c *cancellable) makeDir(path string, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return nil
+		}
+
+		return &fs.PathError{Op: "mkdir", Path: path, Err: fs.ErrExist}
+	}
+
+	if parent := filepath.Dir(path); parent != path {
+		if err := c.makeDir(parent, perm); err != nil {
+			return err
+		}
+	}
+
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	if err := os.Mkdir(path, perm); err != nil {
+		if info, statErr := os.Lstat(path); statErr == nil && info.IsDir() {
+			return nil
+		}
+
+		return err
+	}
+
+	c.advance(path, 1, 0)
+
+	return nil
+}
+
+// removeAll removes the native path, depth first; a path that does not
+// exist is not an error.
+func (c *cancellable) removeAll(path string) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = c.prescan(func(fn fs.WalkDirFunc) error {
+		return filepath.WalkDir(path, fn)
+	}, false)
+	if err != nil {
+		return err
+	}
+
+	return c.remove(path, info.IsDir())
+}
+
+func (c *cancellable) remove(path string, isDir bool) error {
+	if isDir {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if err := c.remove(filepath.Join(path, entry.Name()), entry.IsDir()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	c.advance(path, 1, 0)
+
+	return nil
+}
+
+// copyFS copies fsys into the native directory dir; existing files are
+// not overwritten. A file whose copy is cancelled is removed, so that no
+// partial file remains. The files are copied by the configured number of
+// workers, but a failure is handled the same way regardless of that
+// number (see copyTree).
+func (c *cancellable) copyFS(dir string, fsys fs.FS) error {
+	err := c.prescan(func(fn fs.WalkDirFunc) error {
+		return fs.WalkDir(fsys, ".", fn)
+	}, true)
+	if err != nil {
+		return err
+	}
+
+	return c.copyTree(dir, fsys)
+}
+
+// destination returns the native path in dir that the path of fsys is
+// copied to.
+func destination(dir, path string) (string, error) {
+	local, err := filepath.Localize(path)
+	if err != nil {
+		return "", &fs.PathError{Op: "CopyFS", Path: path, Err: err}
+	}
+
+	return filepath.Join(dir, local), nil
+}
+
+func (c *cancellable) copyDir(dir, path string) error {
+	target, err := destination(dir, path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(target, 0o777); err != nil { //nolint:gosec // as os.CopyFS
+		return err
+	}
+
+	c.advance(target, 1, 0)
+
+	return nil
+}
+
+// copyItem copies the native item from to the native path to; a
+// directory is copied with its contents, as by copyFS.
+func (c *cancellable) copyItem(from, to string) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(from)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return c.copyFS(to, os.DirFS(from))
+	}
+
+	fsys, name := os.DirFS(filepath.Dir(from)), filepath.Base(from)
+	err = c.prescan(func(fn fs.WalkDirFunc) error {
+		return fs.WalkDir(fsys, name, fn)
+	}, true)
+	if err != nil {
+		return err
+	}
+
+	return c.copyFileTo(fsys, name, to)
+}
+
+func (c *cancellable) copyFile(fsys fs.FS, dir, path string) error {
+	target, err := destination(dir, path)
+	if err != nil {
+		return err
+	}
+
+	return c.copyFileTo(fsys, path, target)
+}
+
+// copyFileTo copies the file at path of fsys to the native path target,
+// which must not exist.
+func (c *cancellable) copyFileTo(fsys fs.FS, path, target string) error {
+	source, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close() //nolint:errcheck // read only
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(target, //nolint:gosec // as os.CopyFS
+		os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o666|info.Mode()&0o777,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, &cancellableReader{reader: source, path: target, c: c})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		if IsCancelledError(err) {
+			_ = os.Remove(target)
+		}
+
+		return err
+	}
+
+	c.advance(target, 1, 0)
+
+	return nil
+}
+
+// cancellableReader checks the context before every read and reports
+// the bytes transferred to the native path
+type cancellableReader struct {
+	reader io.Reader
+	path   string
+	c      *cancellable
+}
+
+func (r *cancellableReader) Read(p []byte) (int, error) {
+	if err := r.c.check(); err != nil {
+		return 0, err
+	}
+
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.c.advance(r.path, 0, int64(n))
+	}
+
+	return n, err
+}
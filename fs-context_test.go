@@ -0,0 +1,225 @@
+package nef_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+// countdown is a context that becomes done after its Err method has been
+// invoked a number of times, so that an operation is cancelled at a
+// deterministic point.
+type countdown struct {
+	context.Context
+	remaining int
+}
+
+func (c *countdown) Err() error {
+	if c.remaining <= 0 {
+		return context.Canceled
+	}
+	c.remaining--
+
+	return nil
+}
+
+func cancelled() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	return ctx
+}
+
+func cancelledAt(err error) *nef.CancelledError {
+	Expect(nef.IsCancelledError(err)).To(BeTrue(), "%v", err)
+	Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+
+	reason, _ := nef.ReasonOf(err)
+	Expect(reason).To(Equal(nef.ReasonCancelled))
+
+	var cancelledErr *nef.CancelledError
+	Expect(errors.As(err, &cancelledErr)).To(BeTrue())
+
+	return cancelledErr
+}
+
+var _ = Describe("ContextFS", func() {
+	var (
+		fS  nef.UniversalFS
+		cfs nef.ContextFS
+	)
+
+	BeforeEach(func() {
+		fS = nef.NewUniversalFS(nef.Rel{
+			Root: GinkgoT().TempDir(),
+		})
+
+		var ok bool
+		cfs, ok = fS.(nef.ContextFS)
+		Expect(ok).To(BeTrue())
+
+		Expect(luna.Fixture{
+			luna.Dir("tree",
+				luna.File("a.txt", "a"),
+				luna.File("b.txt", "b"),
+				luna.File("c.txt", "c"),
+			),
+		}.Build(fS, ".")).To(Succeed())
+	})
+
+	Context("RemoveAllContext", func() {
+		It("🧪 should: remove tree", func() {
+			Expect(cfs.RemoveAllContext(context.Background(), "tree")).To(Succeed())
+			Expect(luna.AsDirectory("tree")).NotTo(luna.ExistInFS(fS))
+			Expect(cfs.RemoveAllContext(context.Background(), "missing")).To(Succeed())
+		})
+
+		When("given: cancelled context", func() {
+			It("🧪 should: not remove anything", func() {
+				cancelledErr := cancelledAt(cfs.RemoveAllContext(cancelled(), "tree"))
+				Expect(cancelledErr.Op).To(Equal("RemoveAll"))
+				Expect(cancelledErr.Path).To(Equal("tree"))
+				Expect(cancelledErr.Completed).To(BeZero())
+				Expect(luna.AsFile("tree/a.txt")).To(luna.ExistInFS(fS))
+			})
+		})
+
+		When("given: context cancelled during removal", func() {
+			It("🧪 should: stop and report progress", func() {
+				ctx := &countdown{Context: context.Background(), remaining: 3}
+				cancelledErr := cancelledAt(cfs.RemoveAllContext(ctx, "tree"))
+				Expect(cancelledErr.Completed).To(Equal(int64(2)))
+				Expect(luna.AsDirectory("tree")).To(luna.MatchTree(fS, luna.Tree{
+					"c.txt": "c",
+				}))
+			})
+		})
+	})
+
+	Context("MakeDirAllContext", func() {
+		It("🧪 should: make directory with parents", func() {
+			Expect(cfs.MakeDirAllContext(context.Background(), "a/b/c", lab.Perms.Dir)).To(Succeed())
+			Expect(luna.AsDirectory("a/b/c")).To(luna.ExistInFS(fS))
+			Expect(cfs.MakeDirAllContext(context.Background(), "a/b", lab.Perms.Dir)).To(Succeed())
+		})
+
+		When("given: context cancelled during creation", func() {
+			It("🧪 should: stop and report progress", func() {
+				ctx := &countdown{Context: context.Background(), remaining: 1}
+				cancelledErr := cancelledAt(cfs.MakeDirAllContext(ctx, "a/b/c", lab.Perms.Dir))
+				Expect(cancelledErr.Completed).To(Equal(int64(1)))
+				Expect(luna.AsDirectory("a")).To(luna.ExistInFS(fS))
+				Expect(luna.AsDirectory("a/b")).NotTo(luna.ExistInFS(fS))
+			})
+		})
+
+		When("given: file in the way", func() {
+			It("🧪 should: fail", func() {
+				Expect(cfs.MakeDirAllContext(context.Background(), "tree/a.txt/d", lab.Perms.Dir)).To(
+					MatchError(fs.ErrExist),
+				)
+			})
+		})
+	})
+
+	Context("CopyFSContext", func() {
+		var source fstest.MapFS
+
+		BeforeEach(func() {
+			source = fstest.MapFS{
+				"site/index.html":   &fstest.MapFile{Data: []byte("<html/>"), Mode: lab.Perms.File},
+				"site/css/site.css": &fstest.MapFile{Data: []byte("body {}"), Mode: lab.Perms.File},
+			}
+		})
+
+		It("🧪 should: copy file system", func() {
+			Expect(cfs.CopyFSContext(context.Background(), "copy", source)).To(Succeed())
+			Expect(luna.AsDirectory("copy")).To(luna.MatchTree(fS, luna.Tree{
+				"site/index.html":   "<html/>",
+				"site/css/site.css": "body {}",
+			}))
+		})
+
+		It("🧪 should: copy file system, without context", func() {
+			Expect(fS.CopyFS("copy", source)).To(Succeed())
+			Expect(luna.AsFile("copy/site/index.html")).To(luna.HaveFileContent(fS, "<html/>"))
+		})
+
+		When("given: file exists", func() {
+			It("🧪 should: not overwrite", func() {
+				Expect(cfs.CopyFSContext(context.Background(), "tree", fstest.MapFS{
+					"a.txt": &fstest.MapFile{Data: []byte("copy"), Mode: lab.Perms.File},
+				})).To(MatchError(fs.ErrExist))
+				Expect(luna.AsFile("tree/a.txt")).To(luna.HaveFileContent(fS, "a"))
+			})
+		})
+
+		When("given: context cancelled during copy of file", func() {
+			It("🧪 should: remove partial file and report progress", func() {
+				size := 256 * 1024
+				ctx := &countdown{Context: context.Background(), remaining: 4}
+				cancelledErr := cancelledAt(cfs.CopyFSContext(ctx, "copy", fstest.MapFS{
+					"big.bin": &fstest.MapFile{
+						Data: bytes.Repeat([]byte{'x'}, size),
+						Mode: lab.Perms.File,
+					},
+				}))
+				Expect(cancelledErr.Completed).To(Equal(int64(1)))
+				Expect(cancelledErr.Bytes).To(BeNumerically(">", 0))
+				Expect(cancelledErr.Bytes).To(BeNumerically("<", size))
+				Expect(luna.AsDirectory("copy")).To(luna.BeEmptyDirectory(fS))
+			})
+		})
+	})
+
+	Context("absolute", func() {
+		It("🧪 should: remove tree", func() {
+			root := GinkgoT().TempDir()
+			abs := nef.NewUniversalABS()
+			tree := abs.Calc().Join(root, "tree")
+			Expect(abs.MakeDirAll(abs.Calc().Join(tree, "a"), lab.Perms.Dir)).To(Succeed())
+
+			cancelledAt(nef.RemoveAllContext(cancelled(), abs, tree))
+			Expect(luna.AsDirectory(tree)).To(luna.ExistInFS(abs))
+
+			Expect(nef.RemoveAllContext(context.Background(), abs, tree)).To(Succeed())
+			Expect(luna.AsDirectory(tree)).NotTo(luna.ExistInFS(abs))
+		})
+	})
+
+	Context("fallback", func() {
+		var memFS *luna.MemFS
+
+		BeforeEach(func() {
+			memFS = luna.NewMemFS()
+			Expect(memFS.WriteFile("tree/a.txt", []byte("a"), lab.Perms.File)).To(Succeed())
+		})
+
+		When("given: file system without ContextFS", func() {
+			It("🧪 should: invoke non cancellable operation", func() {
+				Expect(nef.RemoveAllContext(context.Background(), memFS, "tree")).To(Succeed())
+				Expect(luna.AsFile("tree/a.txt")).NotTo(luna.ExistInFS(memFS))
+
+				Expect(nef.MakeDirAllContext(context.Background(), memFS, "a/b", lab.Perms.Dir)).To(Succeed())
+				Expect(luna.AsDirectory("a/b")).To(luna.ExistInFS(memFS))
+			})
+
+			It("🧪 should: not invoke operation, if context cancelled", func() {
+				cancelledAt(nef.RemoveAllContext(cancelled(), memFS, "tree"))
+				Expect(luna.AsFile("tree/a.txt")).To(luna.ExistInFS(memFS))
+
+				cancelledAt(nef.MakeDirAllContext(cancelled(), memFS, "a/b", lab.Perms.Dir))
+				cancelledAt(nef.CopyFSContext(cancelled(), memFS, "a", fstest.MapFS{}))
+			})
+		})
+	})
+})
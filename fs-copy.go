@@ -0,0 +1,218 @@
+package nef
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/snivilised/nefilim/internal/third/lo"
+)
+
+// CopyOptions defines how a tree is copied by CopyFSWith
+type CopyOptions struct {
+	// Workers is the number of files copied concurrently. When less than
+	// 2, the files are copied sequentially. The outcome of a copy does
+	// not depend on the number of workers: a file that fails to copy does
+	// not prevent the others from being copied and the errors are joined
+	// in walk order.
+	Workers int
+}
+
+// CopyFSWith copies the file system fsys into the directory dir of fS,
+// applying options. If fS does not implement CopyOptionsFS, the options
+// are ignored and CopyFSContext is invoked.
+func CopyFSWith(ctx context.Context, fS CopyFS, dir string, fsys fs.FS, options CopyOptions) error {
+	if cfs, ok := fS.(CopyOptionsFS); ok {
+		return cfs.CopyFSWith(ctx, dir, fsys, options)
+	}
+
+	return CopyFSContext(ctx, fS, dir, fsys)
+}
+
+// copyNative implements Copy for the native file systems, where native
+// maps a path of the file system to its native path. When to is an
+// existing directory, from is copied into it, under its own name. An
+// existing destination is not overwritten and a directory is copied with
+// its contents, by the engine behind CopyFS.
+func copyNative(calc PathCalc, native func(string) string, from, to string) error {
+	source := native(from)
+	if _, err := os.Stat(source); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return newBinaryFsOpError("Copy", from, to, ReasonSourceNotFound, err)
+		}
+
+		return err
+	}
+
+	if info, err := os.Stat(native(to)); err == nil && info.IsDir() {
+		to = calc.Join(to, calc.Base(from))
+	}
+
+	target := native(to)
+	if _, err := os.Lstat(target); err == nil {
+		return rejectClash("Copy", from, to)
+	}
+
+	if rel, err := filepath.Rel(source, target); err == nil && filepath.IsLocal(rel) {
+		return newBinaryFsOpError("Copy", from, to, ReasonInvalidBinaryFsOp,
+			errors.New("destination is within source"),
+		)
+	}
+
+	c := newCancellable(context.Background(), "Copy", to, target, calc)
+
+	return c.copyItem(source, target)
+}
+
+// copyTree copies fsys into the native directory dir. All the
+// directories are created first, in walk order, then the files are
+// copied by a bounded pool of workers, of which there is at least 1. A
+// file that fails to copy does not prevent the others from being copied,
+// so the result does not depend on scheduling; the errors are joined in
+// walk order. Once the context is done, no more files are dispatched and
+// a CancelledError is returned.
+func (c *cancellable) copyTree(dir string, fsys fs.FS) error {
+	var files []string
+
+	err := fs.WalkDir(fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if err := c.check(); err != nil {
+			return err
+		}
+
+		switch entry.Type() {
+		case fs.ModeDir:
+			return c.copyDir(dir, path)
+
+		case 0:
+			files = append(files, path)
+
+			return nil
+
+		default:
+			return &fs.PathError{Op: "CopyFS", Path: path, Err: fs.ErrInvalid}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	var (
+		errs = make([]error, len(files))
+		jobs = make(chan int)
+		wg   sync.WaitGroup
+	)
+
+	for range min(max(c.workers, 1), len(files)) {
+		wg.Go(func() {
+			for i := range jobs {
+				errs[i] = c.copyFile(fsys, dir, files[i])
+			}
+		})
+	}
+
+	for i := range files {
+		if c.ctx.Err() != nil {
+			break
+		}
+
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	return errors.Join(errs...)
+}
+
+// copyThrough copies fsys into the directory dir of the decorator fS,
+// writing each file with write, so that the decorator can encode, or
+// account for, its content; directories are created with the MakeDirAll
+// of fS. As with the copy engine of the native file systems, existing
+// files are not overwritten, symbolic links are rejected and a file that
+// fails to copy does not prevent the others from being copied; the errors
+// are joined in walk order. Once ctx is done, a CancelledError is
+// returned.
+func copyThrough(ctx context.Context, fS WriterFS, dir string, fsys fs.FS,
+	write func(name string, data []byte, perm os.FileMode) error,
+) error {
+	var (
+		errs             []error
+		completed, bytes int64
+	)
+
+	err := fs.WalkDir(fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return newCancelledError("CopyFS", dir, completed, bytes, err)
+		}
+
+		target := lo.Ternary(path == ".", syncDir(fS, dir), joinUnder(fS, dir, path))
+
+		switch entry.Type() {
+		case fs.ModeDir:
+			if err := fS.MakeDirAll(target, 0o777); err != nil {
+				return err
+			}
+
+		case 0:
+			size, err := copyThroughFile(fS, fsys, path, target, write)
+			if err != nil {
+				errs = append(errs, err)
+
+				return nil
+			}
+
+			bytes += size
+
+		default:
+			return &fs.PathError{Op: "CopyFS", Path: path, Err: fs.ErrInvalid}
+		}
+
+		completed++
+
+		return nil
+	})
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+func copyThroughFile(fS WriterFS, fsys fs.FS, path, target string,
+	write func(name string, data []byte, perm os.FileMode) error,
+) (int64, error) {
+	if fS.FileExists(target) {
+		return 0, &fs.PathError{Op: "CopyFS", Path: target, Err: fs.ErrExist}
+	}
+
+	info, err := fs.Stat(fsys, path)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := write(target, data, 0o666|info.Mode()&0o777); err != nil {
+		return 0, err
+	}
+
+	return int64(len(data)), nil
+}
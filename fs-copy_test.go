@@ -1,9 +1,19 @@
 package nef_test
 
 import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
 
 	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
 	"github.com/snivilised/nefilim/test/luna"
 )
 
@@ -42,3 +52,223 @@ var _ = Describe("op: copy/all", Ordered, func() {
 		})
 	})
 })
+
+var _ = Describe("Copy", func() {
+	var (
+		fS nef.UniversalFS
+	)
+
+	BeforeEach(func() {
+		fS = nef.NewUniversalFS(nef.Rel{
+			Root: GinkgoT().TempDir(),
+		})
+		Expect(luna.Fixture{
+			luna.Dir("from",
+				luna.File("a.txt", "a"),
+				luna.Dir("sub",
+					luna.File("b.txt", "b"),
+				),
+			),
+			luna.Dir("to",
+				luna.File("a.txt", "existing"),
+			),
+		}.Build(fS, ".")).To(Succeed())
+	})
+
+	DescribeTable("copied",
+		func(given, from, to string, copied map[string]string) {
+			Expect(fS.Copy(from, to)).To(Succeed())
+
+			for name, content := range copied {
+				Expect(luna.AsFile(name)).To(luna.HaveFileContent(fS, content))
+			}
+			Expect(luna.AsFile("from/sub/b.txt")).To(luna.HaveFileContent(fS, "b"))
+		},
+		func(given, from, to string, _ map[string]string) string {
+			return fmt.Sprintf("🧪 ===> given: %v, from: '%v', to: '%v', should: copy", given, from, to)
+		},
+		Entry(nil, "file to missing", "from/a.txt", "to/c.txt", map[string]string{
+			"to/c.txt": "a",
+		}),
+		Entry(nil, "file into directory", "from/sub/b.txt", "to", map[string]string{
+			"to/b.txt": "b",
+		}),
+		Entry(nil, "directory to missing", "from", "copy", map[string]string{
+			"copy/a.txt":     "a",
+			"copy/sub/b.txt": "b",
+		}),
+		Entry(nil, "directory into directory", "from/sub", "to", map[string]string{
+			"to/sub/b.txt": "b",
+		}),
+	)
+
+	DescribeTable("rejected",
+		func(given, from, to string, reason nef.ErrorReason) {
+			err := fS.Copy(from, to)
+			Expect(nef.IsBinaryFsOpError(err)).To(BeTrue(), "%v", err)
+			actual, _ := nef.ReasonOf(err)
+			Expect(actual).To(Equal(reason))
+			Expect(luna.AsFile("to/a.txt")).To(luna.HaveFileContent(fS, "existing"))
+			Expect(luna.AsDirectory("from/sub/from")).NotTo(luna.ExistInFS(fS))
+		},
+		func(given, from, to string, reason nef.ErrorReason) string {
+			return fmt.Sprintf("🧪 ===> given: %v, should: reject with reason '%v'", given, reason)
+		},
+		Entry(nil, "destination exists", "from/a.txt", "to/a.txt", nef.ReasonDestinationClash),
+		Entry(nil, "file into directory with same name", "from/a.txt", "to", nef.ReasonDestinationClash),
+		Entry(nil, "source missing", "from/missing.txt", "to/missing.txt", nef.ReasonSourceNotFound),
+		Entry(nil, "directory into itself", "from", "from/sub", nef.ReasonInvalidBinaryFsOp),
+	)
+
+	When("given: absolute file system", func() {
+		It("🧪 should: copy directory", func() {
+			root := GinkgoT().TempDir()
+			abs := nef.NewUniversalABS()
+			Expect(luna.Fixture{
+				luna.Dir("from",
+					luna.Dir("sub",
+						luna.File("b.txt", "b"),
+					),
+				),
+			}.Build(abs, root)).To(Succeed())
+
+			Expect(abs.Copy(filepath.Join(root, "from"), filepath.Join(root, "copy"))).To(Succeed())
+			Expect(luna.AsFile(filepath.Join(root, "copy", "sub", "b.txt"))).To(
+				luna.HaveFileContent(abs, "b"),
+			)
+		})
+	})
+})
+
+// tree returns a source file system of count files, spread over 10
+// directories, along with the tree expected to be copied from it.
+func tree(count int) (fstest.MapFS, luna.Tree) {
+	source := fstest.MapFS{
+		"empty": &fstest.MapFile{Mode: fs.ModeDir | lab.Perms.Dir},
+	}
+	expected := luna.Tree{
+		"empty/": "",
+	}
+
+	for i := range count {
+		name := fmt.Sprintf("dir-%02d/file-%04d.txt", i%10, i)
+		content := strings.Repeat(name, 1+i%5)
+		source[name] = &fstest.MapFile{Data: []byte(content), Mode: lab.Perms.File}
+		expected[name] = content
+	}
+
+	return source, expected
+}
+
+var _ = Describe("CopyOptions", func() {
+	var (
+		fS     nef.UniversalFS
+		source fstest.MapFS
+		tr     luna.Tree
+	)
+
+	BeforeEach(func() {
+		fS = nef.NewUniversalFS(nef.Rel{
+			Root: GinkgoT().TempDir(),
+		})
+		source, tr = tree(100)
+	})
+
+	DescribeTable("CopyFSWith",
+		func(workers int) {
+			var last nef.Progress
+
+			ctx := nef.WithProgress(context.Background(), nef.ProgressOptions{
+				OnProgress: func(progress nef.Progress) {
+					last = progress
+				},
+			})
+
+			Expect(nef.CopyFSWith(ctx, fS, "copy", source, nef.CopyOptions{Workers: workers})).To(Succeed())
+			Expect(luna.AsDirectory("copy")).To(luna.MatchTree(fS, tr))
+			Expect(last.Items).To(Equal(last.TotalItems))
+			Expect(last.Bytes).To(Equal(last.TotalBytes))
+		},
+		func(workers int) string {
+			return fmt.Sprintf("🧪 ===> workers: '%v', should: copy tree", workers)
+		},
+		Entry(nil, 0),
+		Entry(nil, 1),
+		Entry(nil, 4),
+		Entry(nil, 200),
+	)
+
+	DescribeTable("given: files exist",
+		func(workers int) {
+			Expect(luna.Fixture{
+				luna.Dir("copy",
+					luna.File("dir-07/file-0007.txt", "existing"),
+					luna.File("dir-02/file-0002.txt", "existing"),
+				),
+			}.Build(fS, ".")).To(Succeed())
+
+			err := nef.CopyFSWith(context.Background(), fS, "copy", source, nef.CopyOptions{Workers: workers})
+
+			Expect(err).To(MatchError(fs.ErrExist))
+			messages := strings.Split(err.Error(), "\n")
+			Expect(messages).To(HaveLen(2))
+			Expect(messages[0]).To(ContainSubstring("file-0002.txt"))
+			Expect(messages[1]).To(ContainSubstring("file-0007.txt"))
+
+			Expect(luna.AsFile("copy/dir-07/file-0007.txt")).To(luna.HaveFileContent(fS, "existing"))
+			Expect(luna.AsFile("copy/dir-07/file-0017.txt")).To(
+				luna.HaveFileContent(fS, tr["dir-07/file-0017.txt"]),
+			)
+			Expect(luna.AsFile("copy/dir-09/file-0099.txt")).To(
+				luna.HaveFileContent(fS, tr["dir-09/file-0099.txt"]),
+			)
+		},
+		func(workers int) string {
+			return fmt.Sprintf(
+				"🧪 ===> workers: '%v', should: copy remaining files and join errors in walk order", workers,
+			)
+		},
+		Entry(nil, 0),
+		Entry(nil, 1),
+		Entry(nil, 8),
+	)
+
+	When("given: cancelled context", func() {
+		It("🧪 should: not copy", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			cancelledAt(nef.CopyFSWith(ctx, fS, "copy", source, nef.CopyOptions{Workers: 4}))
+			Expect(luna.AsDirectory("copy")).NotTo(luna.ExistInFS(fS))
+		})
+	})
+})
+
+func benchmarkCopyFS(b *testing.B, workers int) {
+	source, _ := tree(2000)
+	ctx := context.Background()
+
+	for b.Loop() {
+		b.StopTimer()
+		fS := nef.NewUniversalFS(nef.Rel{
+			Root: b.TempDir(),
+		})
+		b.StartTimer()
+
+		if err := nef.CopyFSWith(ctx, fS, "copy", source, nef.CopyOptions{Workers: workers}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCopyFSSequential(b *testing.B) {
+	benchmarkCopyFS(b, 1)
+}
+
+func BenchmarkCopyFSParallel4(b *testing.B) {
+	benchmarkCopyFS(b, 4)
+}
+
+func BenchmarkCopyFSParallel16(b *testing.B) {
+	benchmarkCopyFS(b, 16)
+}
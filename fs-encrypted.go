@@ -0,0 +1,828 @@
+package nef
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/snivilised/nefilim/internal/third/lo"
+	"golang.org/x/crypto/hkdf"
+)
+
+// 🔥 An encrypted file system is a decorator over a UniversalFS, that
+// encrypts the content of every file at rest with AES-GCM. A file is
+// stored as a random nonce followed by the sealed content (which includes
+// the authentication tag), so the size of the plaintext can be derived
+// from the size of the stored file without decrypting it. The content is
+// authenticated together with the logical path of the file, so a file
+// whose content has been swapped with, or copied from, another file in
+// the underlying file system fails to decrypt; consequently, the files
+// affected by Move, Change, Rename and Copy are re-encrypted. The path
+// authenticated is relative to EncryptOptions.Root, if specified, so that
+// a tree can be relocated as a whole (eg restored to another location)
+// and still decrypt.
+//
+// Optionally, the names of files and directories are also encrypted, one
+// path segment at a time. So that a name can be found again, the nonce
+// of a segment is derived from the segment with an HMAC (ie the same name
+// always encrypts to the same value). Separate keys for the content, the
+// names and the HMAC are derived from the key provided with HKDF;
+// the result is encoded as unpadded base64url, so the name of an item in
+// the underlying file system is roughly 4/3 of its length plus 38 bytes.
+// Name encryption is only available over a relative file system.
+//
+// The key is obtained from the KeyProvider for every operation, so a
+// provider may fetch it from a secret store on demand.
+
+// KeyProvider provides the key used by an EncryptedFS. The key must be
+// 16, 24 or 32 bytes long, selecting AES-128, AES-192 or AES-256.
+type KeyProvider interface {
+	Key() ([]byte, error)
+}
+
+// KeyFunc adapts a function to a KeyProvider
+type KeyFunc func() ([]byte, error)
+
+// Key returns the result of invoking the function
+func (fn KeyFunc) Key() ([]byte, error) {
+	return fn()
+}
+
+// EncryptOptions defines how an EncryptedFS encrypts
+type EncryptOptions struct {
+	// Keys provides the encryption key
+	Keys KeyProvider
+	// EncryptNames denotes that the names of files and directories are
+	// encrypted as well as the content of files
+	EncryptNames bool
+	// Root is the directory, in the form accepted by the decorated file
+	// system, to which the path authenticated with the content of a file
+	// is relative; a file outside it can't be written. When empty, the
+	// path is authenticated as given to the file system, which for an
+	// absolute file system is the full path.
+	Root string
+}
+
+const (
+	encryptNonceSize = 12
+	encryptTagSize   = 16
+	encryptOverhead  = encryptNonceSize + encryptTagSize
+	nameDomain       = "nef/name:"
+	contentDomain    = "nef/content:"
+	contentKeyInfo   = "nef/content-key"
+	nameKeyInfo      = "nef/name-key"
+	nonceKeyInfo     = "nef/name-nonce-key"
+	nonceKeySize     = 32
+	resealSuffix     = ".nef-reseal"
+)
+
+// EncryptedFS is a UniversalFS that transparently encrypts the files it
+// stores in the file system it decorates.
+type EncryptedFS struct {
+	UniversalFS
+	options EncryptOptions
+}
+
+var (
+	_ UniversalFS   = (*EncryptedFS)(nil)
+	_ ChangeTimesFS = (*EncryptedFS)(nil)
+	_ ContextFS     = (*EncryptedFS)(nil)
+)
+
+// NewEncryptedFS creates an EncryptedFS that stores files in fS. An
+// error is returned if no key provider is specified, or if name encryption
+// is requested for a file system that is not relative.
+func NewEncryptedFS(fS UniversalFS, options EncryptOptions) (*EncryptedFS, error) {
+	if options.Keys == nil {
+		return nil, errors.New("encrypted file system requires a key provider")
+	}
+
+	if options.EncryptNames && !fS.IsRelative() {
+		return nil, newInvalidPathError("NewEncryptedFS", "", ReasonFileSystemMismatch,
+			errors.New("name encryption requires a relative file system"),
+		)
+	}
+
+	return &EncryptedFS{
+		UniversalFS: fS,
+		options:     options,
+	}, nil
+}
+
+// Underlying returns the name of the item in the underlying file system,
+// that is denoted by name; this is name itself unless names are encrypted.
+func (f *EncryptedFS) Underlying(name string) (string, error) {
+	if !f.options.EncryptNames {
+		return name, nil
+	}
+
+	keys, err := f.keys()
+	if err != nil {
+		return "", err
+	}
+
+	segments := strings.Split(name, separatorStr)
+
+	for i, segment := range segments {
+		if segment == "" || segment == "." {
+			continue
+		}
+
+		segments[i] = keys.sealName(segment)
+	}
+
+	return strings.Join(segments, separatorStr), nil
+}
+
+// encryptKeys holds the ciphers derived from the key provided
+type encryptKeys struct {
+	content cipher.AEAD
+	names   cipher.AEAD
+	nonces  []byte
+}
+
+func (f *EncryptedFS) keys() (*encryptKeys, error) {
+	key, err := f.options.Keys.Key()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := deriveAEAD(key, contentKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := deriveAEAD(key, nameKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	nonces, err := deriveKey(key, nonceKeyInfo, nonceKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptKeys{
+		content: content,
+		names:   names,
+		nonces:  nonces,
+	}, nil
+}
+
+// deriveKey derives a subkey of size bytes from key with HKDF-SHA256,
+// for the purpose denoted by info
+func deriveKey(key []byte, info string, size int) ([]byte, error) {
+	subkey := make([]byte, size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(info)), subkey); err != nil {
+		return nil, err
+	}
+
+	return subkey, nil
+}
+
+// deriveAEAD derives an AES-GCM cipher, whose key is the same size as
+// key (which selects the AES variant), for the purpose denoted by info
+func deriveAEAD(key []byte, info string) (cipher.AEAD, error) {
+	subkey, err := deriveKey(key, info, len(key))
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(subkey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (k *encryptKeys) sealName(segment string) string {
+	mac := hmac.New(sha256.New, k.nonces)
+	_, _ = mac.Write([]byte(nameDomain + segment))
+	nonce := mac.Sum(nil)[:encryptNonceSize]
+
+	return base64.RawURLEncoding.EncodeToString(
+		k.names.Seal(nonce, nonce, []byte(segment), nil),
+	)
+}
+
+func openName(aead cipher.AEAD, encoded string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < encryptOverhead {
+		return "", ErrCoreDecryption
+	}
+
+	plain, err := aead.Open(nil, sealed[:encryptNonceSize], sealed[encryptNonceSize:], nil)
+	if err != nil {
+		return "", ErrCoreDecryption
+	}
+
+	return string(plain), nil
+}
+
+// bind returns the additional data that binds content to the logical
+// path of its file, which is relative to the root, if there is one.
+func (f *EncryptedFS) bind(name string) ([]byte, error) {
+	calc := f.Calc()
+	path := calc.Clean(name)
+
+	if f.options.Root != "" {
+		rel, err := calc.Rel(f.options.Root, path)
+		elements := calc.Elements(rel)
+
+		if err != nil || len(elements) == 0 || elements[0] == ".." {
+			return nil, newInvalidPathError("encrypt", name, ReasonOutsideRoot, err)
+		}
+
+		path = strings.Join(elements, separatorStr)
+	}
+
+	return []byte(contentDomain + path), nil
+}
+
+func (f *EncryptedFS) seal(name string, data []byte) ([]byte, error) {
+	keys, err := f.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	bound, err := f.bind(name)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, encryptNonceSize, encryptOverhead+len(data))
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return keys.content.Seal(nonce, nonce, data, bound), nil
+}
+
+func (f *EncryptedFS) open(name string, sealed []byte) ([]byte, error) {
+	keys, err := f.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	bound, err := f.bind(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < encryptOverhead {
+		return nil, &fs.PathError{Op: "decrypt", Path: name, Err: ErrCoreDecryption}
+	}
+
+	plain, err := keys.content.Open(nil,
+		sealed[:encryptNonceSize], sealed[encryptNonceSize:], bound,
+	)
+	if err != nil {
+		return nil, &fs.PathError{Op: "decrypt", Path: name,
+			Err: fmt.Errorf("%w: %w", ErrCoreDecryption, err),
+		}
+	}
+
+	return plain, nil
+}
+
+// FileExists checks whether the named file exists
+func (f *EncryptedFS) FileExists(name string) bool {
+	underlying, err := f.Underlying(name)
+
+	return err == nil && f.UniversalFS.FileExists(underlying)
+}
+
+// DirectoryExists checks whether the named directory exists
+func (f *EncryptedFS) DirectoryExists(name string) bool {
+	underlying, err := f.Underlying(name)
+
+	return err == nil && f.UniversalFS.DirectoryExists(underlying)
+}
+
+// Open opens the named item; the content of a file is decrypted in full
+// when it is opened.
+func (f *EncryptedFS) Open(name string) (fs.File, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		entries, err := f.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return &syntheticDir{
+			name:    name,
+			info:    info,
+			entries: entries,
+		}, nil
+	}
+
+	content, err := f.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedFile{
+		Reader: bytes.NewReader(content),
+		info:   info,
+	}, nil
+}
+
+// ReadFile reads and decrypts the content of the named file
+func (f *EncryptedFS) ReadFile(name string) ([]byte, error) {
+	underlying, err := f.Underlying(name)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := f.UniversalFS.ReadFile(underlying)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.open(name, sealed)
+}
+
+// Stat returns the info of the named item; the size of a file is the
+// size of its plaintext.
+func (f *EncryptedFS) Stat(name string) (fs.FileInfo, error) {
+	underlying, err := f.Underlying(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.UniversalFS.Stat(underlying)
+	if err != nil {
+		return nil, err
+	}
+
+	return plainInfo(info, f.base(name, info)), nil
+}
+
+func (f *EncryptedFS) base(name string, info fs.FileInfo) string {
+	if !f.options.EncryptNames || name == "." {
+		return info.Name()
+	}
+
+	return f.Calc().Base(name)
+}
+
+func plainInfo(info fs.FileInfo, name string) fs.FileInfo {
+	size := info.Size()
+	if !info.IsDir() {
+		size = max(size-encryptOverhead, 0)
+	}
+
+	return &encryptedInfo{
+		FileInfo: info,
+		name:     name,
+		size:     size,
+	}
+}
+
+// ReadDir reads the named directory; when names are encrypted, entries
+// whose names can't be decrypted with the current key are omitted.
+func (f *EncryptedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	underlying, err := f.Underlying(name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := f.UniversalFS.ReadDir(underlying)
+	if err != nil {
+		return nil, err
+	}
+
+	var names cipher.AEAD
+
+	if f.options.EncryptNames {
+		keys, err := f.keys()
+		if err != nil {
+			return nil, err
+		}
+
+		names = keys.names
+	}
+
+	plain := make([]fs.DirEntry, 0, len(entries))
+
+	for _, entry := range entries {
+		logical := entry.Name()
+
+		if names != nil {
+			if logical, err = openName(names, entry.Name()); err != nil {
+				continue
+			}
+		}
+
+		plain = append(plain, &encryptedEntry{
+			DirEntry: entry,
+			name:     logical,
+		})
+	}
+
+	return plain, nil
+}
+
+// WriteFile encrypts data and writes it to the named file
+func (f *EncryptedFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	underlying, err := f.Underlying(name)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := f.seal(name, data)
+	if err != nil {
+		return err
+	}
+
+	return f.UniversalFS.WriteFile(underlying, sealed, perm)
+}
+
+// Create creates the named file; the file returned is an io.Writer. As
+// AES-GCM authenticates the content as a whole, the content is buffered
+// and only encrypted and written when the file is closed.
+func (f *EncryptedFS) Create(name string) (fs.File, error) {
+	underlying, err := f.Underlying(name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := f.UniversalFS.Create(underlying)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := file.(io.Writer); !ok {
+		_ = file.Close()
+
+		return nil, &fs.PathError{Op: "create", Path: name, Err: errors.ErrUnsupported}
+	}
+
+	return &encryptedWriter{
+		File: file,
+		fS:   f,
+		name: name,
+	}, nil
+}
+
+// MakeDir creates the named directory
+func (f *EncryptedFS) MakeDir(name string, perm os.FileMode) error {
+	return f.unary(name, func(underlying string) error {
+		return f.UniversalFS.MakeDir(underlying, perm)
+	})
+}
+
+// MakeDirAll creates the named directory and any missing parents
+func (f *EncryptedFS) MakeDirAll(name string, perm os.FileMode) error {
+	return f.unary(name, func(underlying string) error {
+		return f.UniversalFS.MakeDirAll(underlying, perm)
+	})
+}
+
+// Ensure makes sure that a path exists at a particular location depending
+// on the value of as.AsFile, with the semantics of the decorated file
+// system; the path returned is a logical path.
+func (f *EncryptedFS) Ensure(as PathAs) (string, error) {
+	if !f.options.EncryptNames {
+		return f.UniversalFS.Ensure(as)
+	}
+
+	calc := f.Calc()
+
+	if as.AsFile {
+		directory, _ := calc.Split(as.Name)
+
+		return as.Name, f.MakeDirAll(calc.Clean(directory), as.Perm)
+	}
+
+	return calc.Clean(calc.Join(as.Name, as.Default)), f.MakeDirAll(as.Name, as.Perm)
+}
+
+// CopyFS copies the file system fsys into the directory dir, encrypting
+// each file. Existing files are not overwritten and symbolic links are
+// rejected; a file that fails to copy does not prevent the others from
+// being copied and the errors are joined.
+func (f *EncryptedFS) CopyFS(dir string, fsys fs.FS) error {
+	return f.CopyFSContext(context.Background(), dir, fsys)
+}
+
+// CopyFSContext is the cancellable variant of CopyFS
+func (f *EncryptedFS) CopyFSContext(ctx context.Context, dir string, fsys fs.FS) error {
+	return copyThrough(ctx, f, dir, fsys, f.WriteFile)
+}
+
+// MakeDirAllContext is the cancellable variant of MakeDirAll
+func (f *EncryptedFS) MakeDirAllContext(ctx context.Context, name string, perm os.FileMode) error {
+	return f.unary(name, func(underlying string) error {
+		return MakeDirAllContext(ctx, f.UniversalFS, underlying, perm)
+	})
+}
+
+// RemoveAllContext is the cancellable variant of RemoveAll
+func (f *EncryptedFS) RemoveAllContext(ctx context.Context, path string) error {
+	return f.unary(path, func(underlying string) error {
+		return RemoveAllContext(ctx, f.UniversalFS, underlying)
+	})
+}
+
+// Remove removes the named file or empty directory
+func (f *EncryptedFS) Remove(name string) error {
+	return f.unary(name, f.UniversalFS.Remove)
+}
+
+// RemoveAll removes path and any children it contains
+func (f *EncryptedFS) RemoveAll(path string) error {
+	return f.unary(path, f.UniversalFS.RemoveAll)
+}
+
+// Move moves an item from one path to another; the files moved are
+// re-encrypted, as their content is bound to their path.
+func (f *EncryptedFS) Move(from, to string) error {
+	dest := f.into(from, to)
+
+	return f.relocate(f.UniversalFS.Move, from, dest, dest, true)
+}
+
+// Change renames an item within its directory; the files renamed are
+// re-encrypted, as their content is bound to their path.
+func (f *EncryptedFS) Change(from, to string) error {
+	return f.relocate(f.UniversalFS.Change, from, to,
+		joinUnder(f, f.Calc().Dir(from), to), true,
+	)
+}
+
+// Rename renames an item; the files renamed are re-encrypted, as their
+// content is bound to their path.
+func (f *EncryptedFS) Rename(from, to string) error {
+	return f.relocate(f.UniversalFS.Rename, from, to, to, true)
+}
+
+// Copy copies an item; the files copied are re-encrypted, as their
+// content is bound to their path.
+func (f *EncryptedFS) Copy(from, to string) error {
+	dest := f.into(from, to)
+
+	return f.relocate(f.UniversalFS.Copy, from, dest, dest, false)
+}
+
+// into returns the path of the item from, once moved or copied to, which
+// may be an existing directory.
+func (f *EncryptedFS) into(from, to string) string {
+	if f.DirectoryExists(to) {
+		return joinUnder(f, to, f.Calc().Base(from))
+	}
+
+	return to
+}
+
+// relocate moves (or copies) the item at from to dest, re-encrypting the
+// files affected. They are first re-encrypted into a temporary sibling of
+// from, which op then moves to to, the argument of op that denotes dest;
+// so if re-encryption fails, nothing has been moved and no file is left
+// sealed under the wrong path. Once moved, from is removed. Nothing needs
+// re-encrypting if from does not exist (op reports the error) or denotes
+// the same item as dest.
+func (f *EncryptedFS) relocate(op func(from, to string) error, from, to, dest string, moved bool) error {
+	calc := f.Calc()
+
+	if _, err := f.Stat(from); err != nil || calc.Clean(from) == calc.Clean(dest) {
+		return f.binary(op, from, to)
+	}
+
+	underlyingFrom, err := f.Underlying(from)
+	if err != nil {
+		return err
+	}
+
+	underlyingTo, err := f.Underlying(to)
+	if err != nil {
+		return err
+	}
+
+	temp := underlyingFrom + resealSuffix
+	_ = f.UniversalFS.RemoveAll(temp)
+
+	if err := f.stage(from, dest, temp); err != nil {
+		_ = f.UniversalFS.RemoveAll(temp)
+
+		return err
+	}
+
+	if err := op(temp, underlyingTo); err != nil {
+		_ = f.UniversalFS.RemoveAll(temp)
+
+		return err
+	}
+
+	return f.UniversalFS.RemoveAll(lo.Ternary(moved, underlyingFrom, temp))
+}
+
+// stage re-encrypts the item at from, and all of its descendants, into
+// the underlying path temp, binding their content to dest. An item whose
+// name can't be decrypted can't be re-encrypted, so a directory that
+// contains one is not staged.
+func (f *EncryptedFS) stage(from, dest, temp string) error {
+	info, err := f.Stat(from)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return f.stageFile(from, dest, temp, info)
+	}
+
+	if err := f.UniversalFS.MakeDir(temp, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	entries, err := f.ReadDir(from)
+	if err != nil {
+		return err
+	}
+
+	if f.options.EncryptNames {
+		if err := f.legible(from, entries); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		child := joinUnder(f, from, entry.Name())
+
+		underlying, err := f.Underlying(child)
+		if err != nil {
+			return err
+		}
+
+		if err := f.stage(child, joinUnder(f, dest, entry.Name()),
+			joinUnder(f.UniversalFS, temp, f.Calc().Base(underlying)),
+		); err != nil {
+			return err
+		}
+	}
+
+	return f.restoreTimes(temp, info)
+}
+
+// legible checks that every item in the underlying directory of name is
+// among its decrypted entries
+func (f *EncryptedFS) legible(name string, entries []fs.DirEntry) error {
+	underlying, err := f.Underlying(name)
+	if err != nil {
+		return err
+	}
+
+	all, err := f.UniversalFS.ReadDir(underlying)
+	if err != nil {
+		return err
+	}
+
+	if len(all) != len(entries) {
+		return &fs.PathError{Op: "reseal", Path: name, Err: ErrCoreDecryption}
+	}
+
+	return nil
+}
+
+func (f *EncryptedFS) stageFile(from, dest, temp string, info fs.FileInfo) error {
+	plain, err := f.ReadFile(from)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := f.seal(dest, plain)
+	if err != nil {
+		return err
+	}
+
+	if err := f.UniversalFS.WriteFile(temp, sealed, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	return f.restoreTimes(temp, info)
+}
+
+// restoreTimes sets the modification time of the underlying item to that
+// of info, if the decorated file system implements ChangeTimesFS
+func (f *EncryptedFS) restoreTimes(underlying string, info fs.FileInfo) error {
+	if changer, ok := f.UniversalFS.(ChangeTimesFS); ok {
+		return changer.Chtimes(underlying, info.ModTime(), info.ModTime())
+	}
+
+	return nil
+}
+
+// Chtimes changes the access and modification times of the named item,
+// if the decorated file system implements ChangeTimesFS.
+func (f *EncryptedFS) Chtimes(name string, atime, mtime time.Time) error {
+	changer, ok := f.UniversalFS.(ChangeTimesFS)
+	if !ok {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: errors.ErrUnsupported}
+	}
+
+	return f.unary(name, func(underlying string) error {
+		return changer.Chtimes(underlying, atime, mtime)
+	})
+}
+
+func (f *EncryptedFS) unary(name string, op func(name string) error) error {
+	underlying, err := f.Underlying(name)
+	if err != nil {
+		return err
+	}
+
+	return op(underlying)
+}
+
+func (f *EncryptedFS) binary(op func(from, to string) error, from, to string) error {
+	underlyingFrom, err := f.Underlying(from)
+	if err != nil {
+		return err
+	}
+
+	underlyingTo, err := f.Underlying(to)
+	if err != nil {
+		return err
+	}
+
+	return op(underlyingFrom, underlyingTo)
+}
+
+type encryptedInfo struct {
+	fs.FileInfo
+	name string
+	size int64
+}
+
+func (i *encryptedInfo) Name() string { return i.name }
+func (i *encryptedInfo) Size() int64  { return i.size }
+
+type encryptedEntry struct {
+	fs.DirEntry
+	name string
+}
+
+func (e *encryptedEntry) Name() string { return e.name }
+
+func (e *encryptedEntry) Info() (fs.FileInfo, error) {
+	info, err := e.DirEntry.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	return plainInfo(info, e.name), nil
+}
+
+type encryptedFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (e *encryptedFile) Stat() (fs.FileInfo, error) {
+	return e.info, nil
+}
+
+func (e *encryptedFile) Close() error {
+	return nil
+}
+
+type encryptedWriter struct {
+	fs.File
+	fS     *EncryptedFS
+	name   string
+	buffer bytes.Buffer
+}
+
+func (e *encryptedWriter) Write(p []byte) (int, error) {
+	return e.buffer.Write(p)
+}
+
+func (e *encryptedWriter) Read(_ []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: e.name, Err: errors.ErrUnsupported}
+}
+
+func (e *encryptedWriter) Close() error {
+	sealed, err := e.fS.seal(e.name, e.buffer.Bytes())
+	if err == nil {
+		_, err = e.File.(io.Writer).Write(sealed)
+	}
+
+	return errors.Join(err, e.File.Close())
+}
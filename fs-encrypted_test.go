@@ -0,0 +1,294 @@
+package nef_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+var _ = Describe("EncryptedFS", func() {
+	var (
+		key     []byte
+		secret  []byte
+		keys    nef.KeyProvider
+		memFS   *luna.MemFS
+		options nef.EncryptOptions
+	)
+
+	BeforeEach(func() {
+		key = bytes.Repeat([]byte{0x2a}, 32)
+		secret = []byte("token=4f2b9c8d1e")
+		keys = nef.KeyFunc(func() ([]byte, error) {
+			return key, nil
+		})
+		memFS = luna.NewMemFS()
+		options = nef.EncryptOptions{
+			Keys: keys,
+		}
+	})
+
+	Context("content encryption", func() {
+		It("🧪 should: store ciphertext and read plaintext", func() {
+			fS, err := nef.NewEncryptedFS(memFS, options)
+			Expect(err).To(Succeed())
+			Expect(fS.MakeDirAll("cache", lab.Perms.Dir)).To(Succeed())
+			Expect(fS.WriteFile("cache/credentials", secret, lab.Perms.File)).To(Succeed())
+
+			stored := memFS.MapFS["cache/credentials"].Data
+			Expect(bytes.Contains(stored, secret)).To(BeFalse())
+			Expect(fS.ReadFile("cache/credentials")).To(Equal(secret))
+
+			info, err := fS.Stat("cache/credentials")
+			Expect(err).To(Succeed())
+			Expect(info.Size()).To(Equal(int64(len(secret))))
+
+			entries, err := fS.ReadDir("cache")
+			Expect(err).To(Succeed())
+			Expect(entries).To(HaveLen(1))
+			entryInfo, err := entries[0].Info()
+			Expect(err).To(Succeed())
+			Expect(entryInfo.Size()).To(Equal(int64(len(secret))))
+
+			file, err := fS.Open("cache/credentials")
+			Expect(err).To(Succeed())
+			Expect(io.ReadAll(file)).To(Equal(secret))
+			Expect(file.Close()).To(Succeed())
+		})
+
+		When("given: wrong key", func() {
+			It("🧪 should: return decryption error", func() {
+				fS, err := nef.NewEncryptedFS(memFS, options)
+				Expect(err).To(Succeed())
+				Expect(fS.WriteFile("credentials", secret, lab.Perms.File)).To(Succeed())
+
+				key = bytes.Repeat([]byte{0x17}, 32)
+				_, err = fS.ReadFile("credentials")
+				Expect(nef.IsDecryptionError(err)).To(BeTrue())
+			})
+		})
+
+		When("given: contents swapped in underlying file system", func() {
+			It("🧪 should: return decryption error", func() {
+				fS, err := nef.NewEncryptedFS(memFS, options)
+				Expect(err).To(Succeed())
+				Expect(fS.WriteFile("credentials", secret, lab.Perms.File)).To(Succeed())
+				Expect(fS.WriteFile("public", []byte("hello"), lab.Perms.File)).To(Succeed())
+
+				memFS.MapFS["credentials"], memFS.MapFS["public"] =
+					memFS.MapFS["public"], memFS.MapFS["credentials"]
+
+				_, err = fS.ReadFile("public")
+				Expect(nef.IsDecryptionError(err)).To(BeTrue())
+				_, err = fS.ReadFile("credentials")
+				Expect(nef.IsDecryptionError(err)).To(BeTrue())
+			})
+		})
+
+		When("given: key provided", func() {
+			It("🧪 should: encrypt content with a derived key", func() {
+				fS, err := nef.NewEncryptedFS(memFS, options)
+				Expect(err).To(Succeed())
+				Expect(fS.WriteFile("credentials", secret, lab.Perms.File)).To(Succeed())
+
+				block, err := aes.NewCipher(key)
+				Expect(err).To(Succeed())
+				aead, err := cipher.NewGCM(block)
+				Expect(err).To(Succeed())
+
+				stored := memFS.MapFS["credentials"].Data
+				_, err = aead.Open(nil, stored[:aead.NonceSize()], stored[aead.NonceSize():], nil)
+				Expect(err).NotTo(Succeed())
+			})
+		})
+
+		When("given: key of invalid length", func() {
+			It("🧪 should: return error", func() {
+				key = []byte("short")
+				fS, err := nef.NewEncryptedFS(memFS, options)
+				Expect(err).To(Succeed())
+				Expect(fS.WriteFile("credentials", secret, lab.Perms.File)).NotTo(Succeed())
+			})
+		})
+	})
+
+	Context("name encryption", func() {
+		BeforeEach(func() {
+			options.EncryptNames = true
+		})
+
+		It("🧪 should: hide names in underlying file system", func() {
+			fS, err := nef.NewEncryptedFS(memFS, options)
+			Expect(err).To(Succeed())
+			Expect(fS.MakeDirAll("reports/2024", lab.Perms.Dir)).To(Succeed())
+			Expect(fS.WriteFile("reports/2024/q1.csv", secret, lab.Perms.File)).To(Succeed())
+
+			for name := range memFS.MapFS {
+				Expect(name).NotTo(ContainSubstring("reports"))
+				Expect(name).NotTo(ContainSubstring("q1.csv"))
+			}
+
+			Expect(fS.FileExists("reports/2024/q1.csv")).To(BeTrue())
+			Expect(fS.DirectoryExists("reports/2024")).To(BeTrue())
+			Expect(fS.ReadFile("reports/2024/q1.csv")).To(Equal(secret))
+
+			entries, err := fS.ReadDir("reports/2024")
+			Expect(err).To(Succeed())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Name()).To(Equal("q1.csv"))
+
+			info, err := fS.Stat("reports/2024/q1.csv")
+			Expect(err).To(Succeed())
+			Expect(info.Name()).To(Equal("q1.csv"))
+
+			Expect(fS.Rename("reports", "archive")).To(Succeed())
+			Expect(fS.ReadFile("archive/2024/q1.csv")).To(Equal(secret))
+		})
+
+		When("given: absolute file system", func() {
+			It("🧪 should: reject name encryption", func() {
+				_, err := nef.NewEncryptedFS(nef.NewUniversalABS(), options)
+				Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+				reason, _ := nef.ReasonOf(err)
+				Expect(reason).To(Equal(nef.ReasonFileSystemMismatch))
+			})
+		})
+
+		When("given: relative file system", func() {
+			It("🧪 should: create, change and move encrypted items", func() {
+				root := GinkgoT().TempDir()
+				fS, err := nef.NewEncryptedFS(nef.NewUniversalFS(nef.Rel{
+					Root: root,
+				}), options)
+				Expect(err).To(Succeed())
+
+				file, err := fS.Create("session")
+				Expect(err).To(Succeed())
+				_, err = file.(io.Writer).Write(secret)
+				Expect(err).To(Succeed())
+				Expect(file.Close()).To(Succeed())
+
+				Expect(fS.MakeDir("archive", lab.Perms.Dir)).To(Succeed())
+				Expect(fS.Change("session", "expired")).To(Succeed())
+				Expect(fS.Move("expired", "archive")).To(Succeed())
+				Expect(fS.ReadFile("archive/expired")).To(Equal(secret))
+
+				underlying, err := fS.Underlying("archive/expired")
+				Expect(err).To(Succeed())
+				Expect(strings.Split(underlying, "/")).To(HaveLen(2))
+				Expect(filepath.Join(root, underlying)).To(BeARegularFile())
+
+				Expect(fS.Rename("archive/expired", "restored")).To(Succeed())
+				Expect(fS.ReadFile("restored")).To(Equal(secret))
+			})
+		})
+	})
+
+	When("given: absolute file system", func() {
+		It("🧪 should: encrypt content", func() {
+			root := GinkgoT().TempDir()
+			fS, err := nef.NewEncryptedFS(nef.NewUniversalABS(), options)
+			Expect(err).To(Succeed())
+
+			path := filepath.Join(root, "credentials")
+			Expect(fS.WriteFile(path, secret, lab.Perms.File)).To(Succeed())
+
+			stored, err := os.ReadFile(path)
+			Expect(err).To(Succeed())
+			Expect(bytes.Contains(stored, secret)).To(BeFalse())
+			Expect(fS.ReadFile(path)).To(Equal(secret))
+
+			info, err := fS.Stat(path)
+			Expect(err).To(Succeed())
+			Expect(info.Size()).To(Equal(int64(len(secret))))
+		})
+	})
+
+	When("given: root", func() {
+		It("🧪 should: decrypt tree relocated as a whole", func() {
+			parent := GinkgoT().TempDir()
+			root := filepath.Join(parent, "vault")
+			Expect(os.Mkdir(root, lab.Perms.Dir)).To(Succeed())
+
+			options.Root = root
+			fS, err := nef.NewEncryptedFS(nef.NewUniversalABS(), options)
+			Expect(err).To(Succeed())
+			Expect(fS.WriteFile(filepath.Join(root, "credentials"), secret, lab.Perms.File)).To(Succeed())
+
+			relocated := filepath.Join(parent, "restored")
+			Expect(os.Rename(root, relocated)).To(Succeed())
+
+			options.Root = relocated
+			fS, err = nef.NewEncryptedFS(nef.NewUniversalABS(), options)
+			Expect(err).To(Succeed())
+			Expect(fS.ReadFile(filepath.Join(relocated, "credentials"))).To(Equal(secret))
+		})
+
+		It("🧪 should: reject file outside root", func() {
+			options.Root = "vault"
+			fS, err := nef.NewEncryptedFS(memFS, options)
+			Expect(err).To(Succeed())
+
+			err = fS.WriteFile("credentials", secret, lab.Perms.File)
+			Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+			reason, _ := nef.ReasonOf(err)
+			Expect(reason).To(Equal(nef.ReasonOutsideRoot))
+		})
+	})
+
+	Context("CopyFS", func() {
+		It("🧪 should: encrypt each file copied", func() {
+			fS, err := nef.NewEncryptedFS(memFS, options)
+			Expect(err).To(Succeed())
+			source := fstest.MapFS{
+				"credentials":   {Data: secret, Mode: lab.Perms.File},
+				"sub/api-token": {Data: secret, Mode: lab.Perms.File},
+			}
+
+			Expect(nef.CopyFSContext(context.Background(), fS, "imported", source)).To(Succeed())
+			Expect(bytes.Contains(memFS.MapFS["imported/credentials"].Data, secret)).To(BeFalse())
+			Expect(fS.ReadFile("imported/credentials")).To(Equal(secret))
+			Expect(fS.ReadFile("imported/sub/api-token")).To(Equal(secret))
+		})
+	})
+
+	When("given: re-encryption fails", func() {
+		It("🧪 should: leave item in place", func() {
+			faultFS := luna.NewFaultFS(memFS, 1, luna.Fault{
+				Op:      "WriteFile",
+				Pattern: "*" + ".nef-reseal",
+				Err:     syscall.ENOSPC,
+			})
+			fS, err := nef.NewEncryptedFS(faultFS, options)
+			Expect(err).To(Succeed())
+			Expect(fS.MakeDir("archive", lab.Perms.Dir)).To(Succeed())
+			Expect(fS.WriteFile("session", secret, lab.Perms.File)).To(Succeed())
+
+			Expect(errors.Is(fS.Move("session", "archive"), syscall.ENOSPC)).To(BeTrue())
+			Expect(fS.ReadFile("session")).To(Equal(secret))
+			Expect(fS.FileExists("archive/session")).To(BeFalse())
+			Expect(memFS.MapFS).NotTo(HaveKey("session.nef-reseal"))
+		})
+	})
+
+	When("given: no key provider", func() {
+		It("🧪 should: return error", func() {
+			_, err := nef.NewEncryptedFS(memFS, nef.EncryptOptions{})
+			Expect(err).NotTo(Succeed())
+		})
+	})
+})
@@ -0,0 +1,49 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package nef
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+const flockSupported = true
+
+var errWouldBlock = unix.EWOULDBLOCK
+
+// flock acquires a lock of mode on file with flock(2), which is held by
+// the open file, so locks acquired through different opens conflict,
+// even within the same process.
+func flock(file *os.File, mode LockMode, block bool) error {
+	how := unix.LOCK_EX
+	if mode == LockShared {
+		how = unix.LOCK_SH
+	}
+
+	if !block {
+		how |= unix.LOCK_NB
+	}
+
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var lockErr error
+
+	err = conn.Control(func(fd uintptr) {
+		for {
+			lockErr = unix.Flock(int(fd), how) //nolint:gosec // fd fits in int
+			if !errors.Is(lockErr, unix.EINTR) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return lockErr
+}
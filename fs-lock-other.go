@@ -0,0 +1,17 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package nef
+
+import (
+	"errors"
+	"os"
+)
+
+const flockSupported = false
+
+var errWouldBlock = errors.New("lock is held")
+
+// flock is not supported, so locking fails with errors.ErrUnsupported
+func flock(_ *os.File, _ LockMode, _ bool) error {
+	return errors.ErrUnsupported
+}
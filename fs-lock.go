@@ -0,0 +1,117 @@
+package nef
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// LockMode denotes the kind of lock acquired from a LockFS
+type LockMode uint8
+
+const (
+	// LockExclusive a lock that can only be held by a single owner
+	LockExclusive LockMode = iota
+	// LockShared a lock that can be held by many owners at the same
+	// time, but not while an exclusive lock is held
+	LockShared
+)
+
+// String returns the name of the mode
+func (m LockMode) String() string {
+	if m == LockShared {
+		return "shared"
+	}
+
+	return "exclusive"
+}
+
+const (
+	// lockWaitForever denotes a lock acquired by blocking
+	lockWaitForever = time.Duration(-1)
+	// lockRetryInterval is the maximum interval between attempts to
+	// acquire a lock with a timeout
+	lockRetryInterval = 50 * time.Millisecond
+)
+
+// lockNative acquires a lock of mode on the native path, which is name on
+// the file system, waiting at most wait (without waiting if 0, or until
+// it is acquired if lockWaitForever). The item is created, as an empty
+// file, if it does not exist.
+func lockNative(op, name, native string, mode LockMode, wait time.Duration) (FileLock, error) {
+	if !flockSupported {
+		return nil, &fs.PathError{Op: op, Path: name, Err: errors.ErrUnsupported}
+	}
+
+	file, err := openLockFile(native)
+	if err != nil {
+		return nil, err
+	}
+
+	if wait == lockWaitForever {
+		if err := flock(file, mode, true); err != nil {
+			_ = file.Close()
+
+			return nil, &fs.PathError{Op: op, Path: name, Err: err}
+		}
+
+		return &fileLock{file: file}, nil
+	}
+
+	deadline := time.Now().Add(wait)
+	interval := time.Millisecond
+
+	for {
+		err := flock(file, mode, false)
+		if err == nil {
+			return &fileLock{file: file}, nil
+		}
+
+		if !errors.Is(err, errWouldBlock) {
+			_ = file.Close()
+
+			return nil, &fs.PathError{Op: op, Path: name, Err: err}
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			_ = file.Close()
+
+			return nil, NewLockedError(op, name, mode)
+		}
+
+		time.Sleep(min(interval, remaining))
+		interval = min(interval*2, lockRetryInterval)
+	}
+}
+
+// openLockFile opens the native path for locking, creating it if it
+// does not exist; only read access is required, so directories can be
+// locked too.
+func openLockFile(native string) (*os.File, error) {
+	if _, err := os.Stat(native); err == nil {
+		return os.Open(native)
+	}
+
+	return os.OpenFile(native, os.O_RDONLY|os.O_CREATE, 0o666) //nolint:gosec // lock file
+}
+
+// fileLock is a lock held on an open file, which is released when the
+// file is closed
+type fileLock struct {
+	file *os.File
+	once sync.Once
+}
+
+// Unlock releases the lock; releasing it again returns fs.ErrClosed
+func (l *fileLock) Unlock() error {
+	err := fs.ErrClosed
+
+	l.once.Do(func() {
+		err = l.file.Close()
+	})
+
+	return err
+}
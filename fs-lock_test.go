@@ -0,0 +1,140 @@
+package nef_test
+
+import (
+	"fmt"
+	"io/fs"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+var _ = Describe("LockFS", func() {
+	var (
+		fS     nef.UniversalFS
+		locker nef.LockFS
+	)
+
+	BeforeEach(func() {
+		fS = nef.NewUniversalFS(nef.Rel{
+			Root: GinkgoT().TempDir(),
+		})
+
+		var ok bool
+		locker, ok = fS.(nef.LockFS)
+		Expect(ok).To(BeTrue())
+
+		Expect(luna.Fixture{
+			luna.File("state.json", "{}"),
+		}.Build(fS, ".")).To(Succeed())
+	})
+
+	DescribeTable("TryLock",
+		func(held, requested nef.LockMode, conflict bool) {
+			lock, err := locker.TryLock("state.json", held)
+			Expect(err).To(Succeed())
+			DeferCleanup(lock.Unlock)
+
+			second, err := locker.TryLock("state.json", requested)
+			if !conflict {
+				Expect(err).To(Succeed())
+				Expect(second.Unlock()).To(Succeed())
+
+				return
+			}
+
+			Expect(nef.IsLockedError(err)).To(BeTrue(), "%v", err)
+			reason, _ := nef.ReasonOf(err)
+			Expect(reason).To(Equal(nef.ReasonLocked))
+		},
+		func(held, requested nef.LockMode, conflict bool) string {
+			return fmt.Sprintf("🧪 ===> given: '%v' lock held, '%v' lock requested, should: conflict '%v'",
+				held, requested, conflict,
+			)
+		},
+		Entry(nil, nef.LockExclusive, nef.LockExclusive, true),
+		Entry(nil, nef.LockExclusive, nef.LockShared, true),
+		Entry(nil, nef.LockShared, nef.LockExclusive, true),
+		Entry(nil, nef.LockShared, nef.LockShared, false),
+	)
+
+	Context("Lock", func() {
+		It("🧪 should: wait until lock released", func() {
+			lock, err := locker.Lock("state.json", nef.LockExclusive)
+			Expect(err).To(Succeed())
+
+			released := time.Now().Add(50 * time.Millisecond)
+			time.AfterFunc(50*time.Millisecond, func() {
+				_ = lock.Unlock()
+			})
+
+			second, err := locker.Lock("state.json", nef.LockShared)
+			Expect(err).To(Succeed())
+			Expect(time.Now()).To(BeTemporally(">=", released))
+			Expect(second.Unlock()).To(Succeed())
+		})
+
+		It("🧪 should: create missing item", func() {
+			lock, err := locker.Lock("build.lock", nef.LockExclusive)
+			Expect(err).To(Succeed())
+			Expect(lock.Unlock()).To(Succeed())
+			Expect(luna.AsFile("build.lock")).To(luna.ExistInFS(fS))
+		})
+
+		It("🧪 should: fail to unlock twice", func() {
+			lock, err := locker.Lock("state.json", nef.LockExclusive)
+			Expect(err).To(Succeed())
+			Expect(lock.Unlock()).To(Succeed())
+			Expect(lock.Unlock()).To(MatchError(fs.ErrClosed))
+		})
+
+		When("given: invalid path", func() {
+			It("🧪 should: fail", func() {
+				_, err := locker.Lock("/state.json", nef.LockExclusive)
+				Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Context("LockTimeout", func() {
+		It("🧪 should: fail after timeout", func() {
+			lock, err := locker.Lock("state.json", nef.LockExclusive)
+			Expect(err).To(Succeed())
+			DeferCleanup(lock.Unlock)
+
+			started := time.Now()
+			_, err = locker.LockTimeout("state.json", nef.LockExclusive, 30*time.Millisecond)
+			Expect(nef.IsLockedError(err)).To(BeTrue(), "%v", err)
+			Expect(time.Since(started)).To(BeNumerically(">=", 30*time.Millisecond))
+		})
+
+		It("🧪 should: acquire lock released within timeout", func() {
+			lock, err := locker.Lock("state.json", nef.LockExclusive)
+			Expect(err).To(Succeed())
+			time.AfterFunc(20*time.Millisecond, func() {
+				_ = lock.Unlock()
+			})
+
+			second, err := locker.LockTimeout("state.json", nef.LockExclusive, 5*time.Second)
+			Expect(err).To(Succeed())
+			Expect(second.Unlock()).To(Succeed())
+		})
+	})
+
+	Context("absolute", func() {
+		It("🧪 should: lock by absolute path", func() {
+			abs := nef.NewUniversalABS()
+			name := abs.Calc().Join(GinkgoT().TempDir(), "state.json")
+
+			lock, err := abs.(nef.LockFS).TryLock(name, nef.LockExclusive)
+			Expect(err).To(Succeed())
+			DeferCleanup(lock.Unlock)
+
+			_, err = abs.(nef.LockFS).TryLock(name, nef.LockShared)
+			Expect(nef.IsLockedError(err)).To(BeTrue(), "%v", err)
+		})
+	})
+})
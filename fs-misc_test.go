@@ -3,6 +3,7 @@ package nef_test
 import (
 	"io/fs"
 	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -110,6 +111,84 @@ var _ = Describe("file systems", Ordered, func() {
 		})
 	})
 
+	Context("fs: FSUtility", func() {
+		Context("relative", func() {
+			var fS nef.UniversalFS
+
+			BeforeEach(func() {
+				fS = nef.NewUniversalFS(nef.Rel{
+					Root: root,
+				})
+			})
+
+			When("given: relative path", func() {
+				It("🧪 should: convert to absolute path and back", func() {
+					Expect(fS.Root()).To(Equal(root))
+
+					abs, err := fS.ToAbsolute(lab.Static.FS.Existing.File)
+					Expect(err).To(Succeed())
+					Expect(abs).To(Equal(filepath.Join(root, filepath.FromSlash(lab.Static.FS.Existing.File))))
+
+					rel, err := fS.ToRelative(abs)
+					Expect(err).To(Succeed())
+					Expect(rel).To(Equal(lab.Static.FS.Existing.File))
+
+					rel, err = fS.ToRelative(root)
+					Expect(err).To(Succeed())
+					Expect(rel).To(Equal("."))
+				})
+			})
+
+			When("given: absolute path outside root", func() {
+				It("🧪 should: return invalid path error", func() {
+					_, err := fS.ToRelative(filepath.Dir(root))
+					Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+
+					reason, _ := nef.ReasonOf(err)
+					Expect(reason).To(Equal(nef.ReasonOutsideRoot))
+				})
+			})
+
+			When("given: invalid path", func() {
+				It("🧪 should: return invalid path error", func() {
+					_, err := fS.ToAbsolute("../foo")
+					Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+
+					_, err = fS.ToRelative("foo")
+					Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+				})
+			})
+		})
+
+		Context("absolute", func() {
+			It("🧪 should: pass absolute paths through", func() {
+				fS := nef.NewUniversalABS()
+				Expect(fS.Root()).To(BeEmpty())
+
+				abs, err := fS.ToRelative(filepath.Join(root, "foo", "..", "bar"))
+				Expect(err).To(Succeed())
+				Expect(abs).To(Equal(filepath.Join(root, "bar")))
+
+				_, err = fS.ToAbsolute("bar")
+				Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+			})
+		})
+
+		Context("memory", func() {
+			It("🧪 should: convert relative to virtual root", func() {
+				fS := luna.NewMemFS()
+
+				abs, err := fS.ToAbsolute("foo/bar.txt")
+				Expect(err).To(Succeed())
+				Expect(abs).To(Equal("/foo/bar.txt"))
+
+				rel, err := fS.ToRelative(abs)
+				Expect(err).To(Succeed())
+				Expect(rel).To(Equal("foo/bar.txt"))
+			})
+		})
+	})
+
 	Context("fs: RenameFS", func() {
 		Context("op: Rename", func() {
 			When("given: ", func() {
@@ -1,7 +1,9 @@
 package nef_test
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -100,7 +102,9 @@ var _ = Describe("op: move", Ordered, func() {
 					Expect(luna.AsFile(lab.Static.FS.Move.To.File)).To(luna.ExistInFS(fS))
 					return
 				}
-				Expect(fS.Move(entry.from, lab.Static.FS.Move.Destination)).NotTo(Succeed())
+				err := fS.Move(entry.from, lab.Static.FS.Move.Destination)
+				Expect(errors.Is(err, fs.ErrExist)).To(BeTrue())
+				IsBinaryFsOpReason(err, nef.ReasonDestinationClash)
 			},
 		}),
 
@@ -264,6 +268,28 @@ var _ = Describe("op: move", Ordered, func() {
 				err := fS.Move(entry.from, entry.to)
 				Expect(err).NotTo(Succeed(), fmt.Sprintf("OVERWRITE: %v", entry.overwrite))
 				Expect(nef.IsBinaryFsOpError(err)).To(BeTrue())
+				Expect(errors.Is(err, fs.ErrNotExist)).To(BeTrue())
+				IsBinaryFsOpReason(err, nef.ReasonSourceNotFound)
+			},
+		}),
+
+		Entry(nil, fsTE[nef.UniversalFS]{
+			given:   "[from] directory exists, [to] file exists",
+			should:  "fail, directory can't be moved onto file",
+			op:      "Move",
+			require: lab.Static.FS.Scratch,
+			from:    lab.Static.FS.Move.From.Directory,
+			to:      lab.Static.FS.Remove.File,
+			arrange: func(entry fsTE[nef.UniversalFS], _ nef.UniversalFS) {
+				Expect(require(root, entry.from)).To(Succeed())
+				Expect(require(root, entry.require, entry.to)).To(Succeed())
+			},
+			action: func(entry fsTE[nef.UniversalFS], fS nef.UniversalFS) {
+				err := fS.Move(entry.from, entry.to)
+				Expect(err).NotTo(Succeed(), fmt.Sprintf("OVERWRITE: %v", entry.overwrite))
+				Expect(nef.IsBinaryFsOpError(err)).To(BeTrue())
+				Expect(errors.Is(err, fs.ErrExist)).To(BeTrue())
+				IsBinaryFsOpReason(err, nef.ReasonDirectoryOntoFile)
 			},
 		}),
 
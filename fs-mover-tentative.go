@@ -32,7 +32,7 @@ func (m *tentativeMover) moveItemWithoutName(from, to string) error {
 	// from/file.txt => to/
 	//
 	if _, err := m.fS.Stat(m.calc.Join(to, m.calc.Base(from))); err == nil {
-		return NewInvalidBinaryFsOpError("Move", from, to)
+		return rejectClash(moveOpName, from, to)
 	}
 
 	return m.baseMover.moveItemWithoutName(from, to)
@@ -44,7 +44,7 @@ func (m *tentativeMover) rejectOverwriteOrNoOp(from, to string) error {
 	// otherwise they are the same item and this should effectively be a no op.
 	//
 	if m.calc.Dir(from) != m.calc.Dir(to) {
-		return NewInvalidBinaryFsOpError(moveOpName, from, to)
+		return rejectClash(moveOpName, from, to)
 	}
 
 	return nil
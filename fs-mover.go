@@ -1,7 +1,9 @@
 package nef
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"io/fs"
 	"sync"
 
 	"github.com/snivilised/nefilim/internal/third/lo"
@@ -29,7 +31,6 @@ type (
 	movers map[bitmask]moveFunc
 
 	baseMover struct {
-		root    string
 		fS      MoverFS
 		calc    PathCalc
 		actions movers
@@ -40,8 +41,63 @@ func noOp(_, _ string) error {
 	return nil
 }
 
+func (b bitmask) String() string {
+	describe := func(exists, isDir bool) string {
+		if !exists {
+			return "missing"
+		}
+
+		return lo.Ternary(isDir, "exists as directory", "exists as file")
+	}
+
+	return fmt.Sprintf("from %v, to %v",
+		describe(b.fromExists, b.fromIsDir),
+		describe(b.toExists, b.toIsDir),
+	)
+}
+
+// diagnose is invoked when there is no action defined for the combination
+// of from/to existence and type. The error returned identifies which
+// combination was hit and wraps fs.ErrNotExist or fs.ErrExist where
+// applicable, so that the client does not need to re-stat the paths to
+// find out why the operation was rejected.
+func (b bitmask) diagnose(op, from, to string) error {
+	switch {
+	case !b.fromExists:
+		return newBinaryFsOpError(op, from, to, ReasonSourceNotFound,
+			fmt.Errorf("%v: %w", b, fs.ErrNotExist),
+		)
+
+	case b.fromIsDir && b.toExists && !b.toIsDir:
+		return newBinaryFsOpError(op, from, to, ReasonDirectoryOntoFile,
+			fmt.Errorf("%v: %w", b, fs.ErrExist),
+		)
+
+	case !b.fromIsDir && b.toIsDir:
+		return newBinaryFsOpError(op, from, to, ReasonFileOntoDirectory,
+			fmt.Errorf("%v: %w", b, fs.ErrExist),
+		)
+
+	case b.toExists:
+		return newBinaryFsOpError(op, from, to, ReasonDestinationClash,
+			fmt.Errorf("%v: %w", b, fs.ErrExist),
+		)
+	}
+
+	return newBinaryFsOpError(op, from, to, ReasonInvalidBinaryFsOp,
+		errors.New(b.String()),
+	)
+}
+
+func rejectClash(op, from, to string) error {
+	return newBinaryFsOpError(op, from, to, ReasonDestinationClash, fs.ErrExist)
+}
+
 func (m *baseMover) move(from, to string) error {
-	mask := m.query(from, to)
+	mask, err := m.query(from, to)
+	if err != nil {
+		return err
+	}
 
 	if action, exists := m.actions[mask]; exists {
 		if err := action(from, to); err != nil {
@@ -50,31 +106,26 @@ func (m *baseMover) move(from, to string) error {
 		return nil
 	}
 
-	return NewInvalidBinaryFsOpError(moveOpName, from, to)
+	return mask.diagnose(moveOpName, from, to)
 }
 
-func (m *baseMover) query(from, to string) bitmask {
-	fromExists, fromIsDir := m.peek(from)
-	toExists, toIsDir := m.peek(to)
+func (m *baseMover) query(from, to string) (bitmask, error) {
+	fromExists, fromIsDir, err := peek(m.fS, from)
+	if err != nil {
+		return bitmask{}, err
+	}
+
+	toExists, toIsDir, err := peek(m.fS, to)
+	if err != nil {
+		return bitmask{}, err
+	}
 
 	return bitmask{
 		fromExists: fromExists,
 		toExists:   toExists,
 		fromIsDir:  fromIsDir,
 		toIsDir:    toIsDir,
-	}
-}
-
-func (m *baseMover) peek(name string) (exists, isDir bool) {
-	if m.fS.DirectoryExists(name) {
-		return true, true
-	}
-
-	if m.fS.FileExists(name) {
-		return true, false
-	}
-
-	return false, false
+	}, nil
 }
 
 func (m *baseMover) moveItemWithName(from, to string) error {
@@ -85,20 +136,14 @@ func (m *baseMover) moveItemWithName(from, to string) error {
 		return NewRejectSameDirMoveError(moveOpName, from, to)
 	}
 
-	return os.Rename(
-		m.calc.Join(m.root, from),
-		m.calc.Join(m.root, to),
-	)
+	return m.fS.Rename(from, to)
 }
 
 func (m *baseMover) moveItemWithoutName(from, to string) error {
 	// 'to' does not include the file name, so it has to be appended, eg:
 	// from/file.txt => to/
 	//
-	return os.Rename(
-		m.calc.Join(m.root, from),
-		m.calc.Join(m.root, to, m.calc.Base(from)),
-	)
+	return m.fS.Rename(from, m.calc.Join(to, m.calc.Base(from)))
 }
 
 func (m *baseMover) moveItemWithoutNameClash(from, to string) error {
@@ -120,21 +165,20 @@ type lazyMover struct {
 	mover mover
 }
 
-func (l *lazyMover) instance(root string, overwrite bool, fS MoverFS) mover {
+func (l *lazyMover) instance(overwrite bool, fS MoverFS) mover {
 	l.once.Do(func() {
-		l.mover = l.create(root, overwrite, fS)
+		l.mover = l.create(overwrite, fS)
 	})
 
 	return l.mover
 }
 
-func (l *lazyMover) create(root string, overwrite bool, fS MoverFS) mover {
+func (l *lazyMover) create(overwrite bool, fS MoverFS) mover {
 	calc := fS.Calc()
 	return lo.TernaryF(overwrite,
 		func() mover {
 			return &overwriteMover{
 				baseMover: baseMover{
-					root: root,
 					fS:   fS,
 					calc: calc,
 				},
@@ -143,7 +187,6 @@ func (l *lazyMover) create(root string, overwrite bool, fS MoverFS) mover {
 		func() mover {
 			return &tentativeMover{
 				baseMover: baseMover{
-					root: root,
 					fS:   fS,
 					calc: calc,
 				},
@@ -0,0 +1,77 @@
+package nef
+
+import (
+	"context"
+)
+
+// Progress is a snapshot of the progress of a bulk operation, reported
+// each time an item is processed or a buffer of a file is transferred.
+type Progress struct {
+	// Op is the name of the operation, eg "CopyFS"
+	Op string
+
+	// Path is the item currently being processed, in the form of the
+	// paths of the file system performing the operation
+	Path string
+
+	// Items is the number of items processed so far
+	Items int64
+
+	// Bytes is the number of bytes transferred so far
+	Bytes int64
+
+	// TotalItems is the estimated number of items the operation will
+	// process; zero when the pre-scan has been skipped
+	TotalItems int64
+
+	// TotalBytes is the estimated number of bytes the operation will
+	// transfer; zero when the pre-scan has been skipped
+	TotalBytes int64
+}
+
+// ProgressFunc receives progress snapshots, one at a time, on the
+// goroutine performing the operation or one of its workers, so it should
+// return promptly.
+type ProgressFunc func(progress Progress)
+
+// ProgressOptions defines how the progress of a bulk operation is reported
+type ProgressOptions struct {
+	// OnProgress is invoked with a snapshot once the totals have been
+	// estimated and thereafter whenever progress is made
+	OnProgress ProgressFunc
+
+	// SkipPreScan turns off the scan of the tree that estimates the
+	// totals, which is an extra walk of the tree before the operation
+	// starts
+	SkipPreScan bool
+}
+
+type progressKey struct{}
+
+// WithProgress returns a copy of ctx carrying options, so that the
+// progress of the cancellable operations of ContextFS invoked with it,
+// ie MakeDirAllContext, RemoveAllContext and CopyFSContext, is reported.
+func WithProgress(ctx context.Context, options ProgressOptions) context.Context {
+	return context.WithValue(ctx, progressKey{}, options)
+}
+
+// progressOf returns the progress options carried by ctx, if any
+func progressOf(ctx context.Context) *ProgressOptions {
+	if options, ok := ctx.Value(progressKey{}).(ProgressOptions); ok && options.OnProgress != nil {
+		return &options
+	}
+
+	return nil
+}
+
+// ProgressChannel returns a ProgressFunc that sends snapshots to ch. A
+// snapshot is dropped when ch is not ready to receive it, so that a slow
+// consumer does not stall the operation; ch is not closed.
+func ProgressChannel(ch chan<- Progress) ProgressFunc {
+	return func(progress Progress) {
+		select {
+		case ch <- progress:
+		default:
+		}
+	}
+}
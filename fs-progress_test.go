@@ -0,0 +1,220 @@
+package nef_test
+
+import (
+	"context"
+	"fmt"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/internal/third/lo"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+// recorder collects the progress snapshots reported by an operation
+type recorder struct {
+	reports []nef.Progress
+}
+
+func (r *recorder) context(skip bool) context.Context {
+	return nef.WithProgress(context.Background(), nef.ProgressOptions{
+		OnProgress: func(progress nef.Progress) {
+			r.reports = append(r.reports, progress)
+		},
+		SkipPreScan: skip,
+	})
+}
+
+func (r *recorder) paths() []string {
+	paths := make([]string, 0, len(r.reports))
+	for _, progress := range r.reports {
+		paths = append(paths, progress.Path)
+	}
+
+	return paths
+}
+
+func (r *recorder) last() nef.Progress {
+	Expect(r.reports).NotTo(BeEmpty())
+
+	return r.reports[len(r.reports)-1]
+}
+
+var _ = Describe("Progress", func() {
+	var (
+		rec    *recorder
+		source fstest.MapFS
+	)
+
+	BeforeEach(func() {
+		rec = &recorder{}
+		source = fstest.MapFS{
+			"site/index.html":   &fstest.MapFile{Data: []byte("<html/>"), Mode: lab.Perms.File},
+			"site/css/site.css": &fstest.MapFile{Data: []byte("body {}"), Mode: lab.Perms.File},
+		}
+	})
+
+	DescribeTable("RemoveAllContext",
+		func(absolute, skip bool, expected nef.Progress) {
+			root := GinkgoT().TempDir()
+			fS := lo.Ternary[nef.UniversalFS](absolute,
+				nef.NewUniversalABS(),
+				nef.NewUniversalFS(nef.Rel{Root: root}),
+			)
+			base := lo.Ternary(absolute, root, ".")
+			tree := lo.Ternary(absolute, fS.Calc().Join(root, "tree"), "tree")
+
+			Expect(luna.Fixture{
+				luna.Dir("tree",
+					luna.File("a.txt", "a"),
+					luna.Dir("b", luna.File("c.txt", "c")),
+				),
+			}.Build(fS, base)).To(Succeed())
+
+			Expect(nef.RemoveAllContext(rec.context(skip), fS, tree)).To(Succeed())
+			Expect(luna.AsDirectory(tree)).NotTo(luna.ExistInFS(fS))
+
+			Expect(rec.reports).To(HaveLen(5))
+			Expect(rec.reports[0].Items).To(BeZero())
+			Expect(rec.paths()).To(ConsistOf(
+				tree,
+				fS.Calc().Join(tree, "a.txt"),
+				fS.Calc().Join(tree, "b"),
+				fS.Calc().Join(tree, "b", "c.txt"),
+				tree,
+			))
+
+			expected.Path = tree
+			Expect(rec.last()).To(Equal(expected))
+		},
+		func(absolute, skip bool, _ nef.Progress) string {
+			return fmt.Sprintf("🧪 ===> given: absolute '%v', skip pre-scan '%v', should: report progress",
+				absolute, skip,
+			)
+		},
+		Entry(nil, false, false, nef.Progress{Op: "RemoveAll", Items: 4, TotalItems: 4}),
+		Entry(nil, false, true, nef.Progress{Op: "RemoveAll", Items: 4}),
+		Entry(nil, true, false, nef.Progress{Op: "RemoveAll", Items: 4, TotalItems: 4}),
+		Entry(nil, true, true, nef.Progress{Op: "RemoveAll", Items: 4}),
+	)
+
+	DescribeTable("MakeDirAllContext",
+		func(absolute, skip bool, expected nef.Progress) {
+			root := GinkgoT().TempDir()
+			fS := lo.Ternary[nef.UniversalFS](absolute,
+				nef.NewUniversalABS(),
+				nef.NewUniversalFS(nef.Rel{Root: root}),
+			)
+			base := lo.Ternary(absolute, fS.Calc().Join(root, "a"), "a")
+			path := fS.Calc().Join(base, "b", "c")
+
+			Expect(nef.MakeDirAllContext(rec.context(skip), fS, path, lab.Perms.Dir)).To(Succeed())
+			Expect(luna.AsDirectory(path)).To(luna.ExistInFS(fS))
+
+			Expect(rec.reports).To(HaveLen(4))
+			Expect(rec.reports[0].Items).To(BeZero())
+			Expect(rec.paths()).To(Equal([]string{
+				path,
+				base,
+				fS.Calc().Join(base, "b"),
+				path,
+			}))
+
+			expected.Path = path
+			Expect(rec.last()).To(Equal(expected))
+		},
+		func(absolute, skip bool, _ nef.Progress) string {
+			return fmt.Sprintf("🧪 ===> given: absolute '%v', skip pre-scan '%v', should: report progress",
+				absolute, skip,
+			)
+		},
+		Entry(nil, false, false, nef.Progress{Op: "MakeDirAll", Items: 3, TotalItems: 3}),
+		Entry(nil, false, true, nef.Progress{Op: "MakeDirAll", Items: 3}),
+		Entry(nil, true, false, nef.Progress{Op: "MakeDirAll", Items: 3, TotalItems: 3}),
+		Entry(nil, true, true, nef.Progress{Op: "MakeDirAll", Items: 3}),
+	)
+
+	DescribeTable("CopyFSContext",
+		func(absolute, skip bool, expected nef.Progress) {
+			root := GinkgoT().TempDir()
+			fS := lo.Ternary[nef.UniversalFS](absolute,
+				nef.NewUniversalABS(),
+				nef.NewUniversalFS(nef.Rel{Root: root}),
+			)
+			dir := lo.Ternary(absolute, fS.Calc().Join(root, "copy"), "copy")
+
+			Expect(nef.CopyFSContext(rec.context(skip), fS, dir, source)).To(Succeed())
+			Expect(luna.AsFile(fS.Calc().Join(dir, "site", "css", "site.css"))).To(
+				luna.HaveFileContent(fS, "body {}"),
+			)
+
+			Expect(rec.paths()).To(ContainElements(
+				dir,
+				fS.Calc().Join(dir, "site"),
+				fS.Calc().Join(dir, "site", "index.html"),
+			))
+
+			expected.Path = fS.Calc().Join(dir, "site", "index.html")
+			Expect(rec.last()).To(Equal(expected))
+		},
+		func(absolute, skip bool, _ nef.Progress) string {
+			return fmt.Sprintf("🧪 ===> given: absolute '%v', skip pre-scan '%v', should: report progress",
+				absolute, skip,
+			)
+		},
+		Entry(nil, false, false, nef.Progress{Op: "CopyFS", Items: 5, Bytes: 14, TotalItems: 5, TotalBytes: 14}),
+		Entry(nil, false, true, nef.Progress{Op: "CopyFS", Items: 5, Bytes: 14}),
+		Entry(nil, true, false, nef.Progress{Op: "CopyFS", Items: 5, Bytes: 14, TotalItems: 5, TotalBytes: 14}),
+		Entry(nil, true, true, nef.Progress{Op: "CopyFS", Items: 5, Bytes: 14}),
+	)
+
+	When("given: progress channel", func() {
+		It("🧪 should: send progress without blocking", func() {
+			ch := make(chan nef.Progress, 1)
+			fS := nef.NewUniversalFS(nef.Rel{
+				Root: GinkgoT().TempDir(),
+			})
+			ctx := nef.WithProgress(context.Background(), nef.ProgressOptions{
+				OnProgress: nef.ProgressChannel(ch),
+			})
+
+			Expect(nef.CopyFSContext(ctx, fS, "copy", source)).To(Succeed())
+			Eventually(ch).Should(Receive(HaveField("TotalItems", int64(5))))
+		})
+	})
+
+	When("given: parallel copy", func() {
+		It("🧪 should: report progress one at a time, never going backwards", func() {
+			fS := nef.NewUniversalFS(nef.Rel{
+				Root: GinkgoT().TempDir(),
+			})
+			parallel, _ := tree(100)
+
+			Expect(nef.CopyFSWith(rec.context(false), fS, "copy", parallel, nef.CopyOptions{
+				Workers: 8,
+			})).To(Succeed())
+
+			for i := 1; i < len(rec.reports); i++ {
+				Expect(rec.reports[i].Items).To(BeNumerically(">=", rec.reports[i-1].Items))
+				Expect(rec.reports[i].Bytes).To(BeNumerically(">=", rec.reports[i-1].Bytes))
+			}
+			Expect(rec.last().Items).To(Equal(rec.last().TotalItems))
+		})
+	})
+
+	When("given: cancelled context", func() {
+		It("🧪 should: not report progress", func() {
+			fS := nef.NewUniversalFS(nef.Rel{
+				Root: GinkgoT().TempDir(),
+			})
+			ctx, cancel := context.WithCancel(rec.context(false))
+			cancel()
+
+			cancelledAt(nef.CopyFSContext(ctx, fS, "copy", source))
+			Expect(rec.reports).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,577 @@
+package nef
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 🔥 A quota file system is a decorator over a UniversalFS, that limits
+// the total size and number of the files stored under its root, so that
+// a sub tree can be handed to a client without letting it fill the disk.
+// The usage is scanned when the file system is created and thereafter
+// maintained by the operations performed through the decorator; changes
+// made to the tree by other means are not observed. Operations that would
+// exceed a limit are rejected with a QuotaError. Operations on items
+// outside the root are rejected with an invalid path error, whose reason
+// is ReasonOutsideRoot. Since the items remain under the root, Move,
+// Change and Rename only affect usage when they replace an existing item,
+// whose usage is released.
+//
+// Copy is delegated to the decorated file system, once the quota has been
+// checked, so it is only available if that file system implements it.
+
+// QuotaLimits defines the limits enforced by a QuotaFS; a zero value
+// denotes no limit.
+type QuotaLimits struct {
+	// Bytes is the maximum total size of all files
+	Bytes int64
+	// Files is the maximum number of files
+	Files int64
+}
+
+// QuotaUsage is the usage of the resources limited by a QuotaFS
+type QuotaUsage struct {
+	// Bytes is the total size of all files
+	Bytes int64
+	// Files is the number of files
+	Files int64
+}
+
+// QuotaFS is a UniversalFS that enforces QuotaLimits on the tree under
+// its root.
+type QuotaFS struct {
+	UniversalFS
+	root   string
+	limits QuotaLimits
+	mutex  sync.Mutex
+	usage  QuotaUsage
+}
+
+var (
+	_ UniversalFS   = (*QuotaFS)(nil)
+	_ ChangeTimesFS = (*QuotaFS)(nil)
+	_ ContextFS     = (*QuotaFS)(nil)
+)
+
+// NewQuotaFS creates a QuotaFS over the tree under root in fS, whose
+// initial usage is found by scanning the tree.
+func NewQuotaFS(fS UniversalFS, root string, limits QuotaLimits) (*QuotaFS, error) {
+	usage, err := measure(fS, root)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QuotaFS{
+		UniversalFS: fS,
+		root:        root,
+		limits:      limits,
+		usage:       usage,
+	}, nil
+}
+
+// Limits returns the limits enforced
+func (f *QuotaFS) Limits() QuotaLimits {
+	return f.limits
+}
+
+// Usage returns the current usage
+func (f *QuotaFS) Usage() QuotaUsage {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.usage
+}
+
+// within checks that the paths reside under the root
+func (f *QuotaFS) within(op string, paths ...string) error {
+	for _, path := range paths {
+		rel, err := f.Calc().Rel(f.root, path)
+		if err != nil {
+			return newInvalidPathError(op, path, ReasonOutsideRoot, err)
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if rel == ".." || strings.HasPrefix(rel, "../") {
+			return newInvalidPathError(op, path, ReasonOutsideRoot, nil)
+		}
+	}
+
+	return nil
+}
+
+// measure returns the size and number of files in the tree denoted by
+// path, which may be a file; a missing path has no usage.
+func measure(fS ReaderFS, path string) (QuotaUsage, error) {
+	info, err := fS.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return QuotaUsage{}, nil
+	}
+
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+
+	if !info.IsDir() {
+		return QuotaUsage{Bytes: info.Size(), Files: 1}, nil
+	}
+
+	usage := QuotaUsage{}
+	err = walkFiles(fS, path, "", func(path, _ string) error {
+		info, err := fS.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		usage.Bytes += info.Size()
+		usage.Files++
+
+		return nil
+	})
+
+	return usage, err
+}
+
+// measureFS returns the size and number of files in fsys
+func measureFS(fsys fs.FS) (QuotaUsage, error) {
+	usage := QuotaUsage{}
+	err := fs.WalkDir(fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		usage.Bytes += info.Size()
+		usage.Files++
+
+		return nil
+	})
+
+	return usage, err
+}
+
+// reserve checks that the usage can be changed by delta without
+// exceeding a limit; the caller must hold the lock.
+func (f *QuotaFS) reserve(op, path string, delta QuotaUsage) error {
+	if f.limits.Files > 0 && delta.Files > 0 && f.usage.Files+delta.Files > f.limits.Files {
+		return newQuotaError(op, path, QuotaFiles, f.limits.Files, f.usage.Files, delta.Files)
+	}
+
+	if f.limits.Bytes > 0 && delta.Bytes > 0 && f.usage.Bytes+delta.Bytes > f.limits.Bytes {
+		return newQuotaError(op, path, QuotaBytes, f.limits.Bytes, f.usage.Bytes, delta.Bytes)
+	}
+
+	return nil
+}
+
+func (f *QuotaFS) apply(delta QuotaUsage) {
+	f.usage.Bytes += delta.Bytes
+	f.usage.Files += delta.Files
+}
+
+// replacing returns the change in usage that results from replacing the
+// file denoted by name (if it exists) with one of size bytes.
+func (f *QuotaFS) replacing(name string, size int64) (QuotaUsage, error) {
+	existing, err := measure(f.UniversalFS, name)
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+
+	return QuotaUsage{
+		Bytes: size - existing.Bytes,
+		Files: 1 - existing.Files,
+	}, nil
+}
+
+// WriteFile writes the named file, unless doing so would exceed the quota
+func (f *QuotaFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	if err := f.within("WriteFile", name); err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	delta, err := f.replacing(name, int64(len(data)))
+	if err != nil {
+		return err
+	}
+
+	if err := f.reserve("WriteFile", name, delta); err != nil {
+		return err
+	}
+
+	if err := f.UniversalFS.WriteFile(name, data, perm); err != nil {
+		return err
+	}
+
+	f.apply(delta)
+
+	return nil
+}
+
+// Create creates the named file, unless doing so would exceed the file
+// quota. The file returned is an io.Writer that counts the bytes written
+// against the quota and rejects a write that would exceed it.
+func (f *QuotaFS) Create(name string) (fs.File, error) {
+	if err := f.within("Create", name); err != nil {
+		return nil, err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	delta, err := f.replacing(name, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.reserve("Create", name, delta); err != nil {
+		return nil, err
+	}
+
+	file, err := f.UniversalFS.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, ok := file.(io.Writer)
+	if !ok {
+		_ = file.Close()
+
+		return nil, &fs.PathError{Op: "create", Path: name, Err: errors.ErrUnsupported}
+	}
+
+	f.apply(delta)
+
+	return &quotaFile{
+		File:   file,
+		writer: writer,
+		fS:     f,
+		name:   name,
+	}, nil
+}
+
+// Copy copies an item, unless doing so would exceed the quota; the copy
+// is performed by the decorated file system.
+func (f *QuotaFS) Copy(from, to string) error {
+	if err := f.within("Copy", from, to); err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	source, err := measure(f.UniversalFS, from)
+	if err != nil {
+		return err
+	}
+
+	if err := f.reserve("Copy", to, source); err != nil {
+		return err
+	}
+
+	before, err := measure(f.UniversalFS, to)
+	if err != nil {
+		return err
+	}
+
+	if err := f.UniversalFS.Copy(from, to); err != nil {
+		return err
+	}
+
+	// the destination is re-measured, since the copy may have replaced or
+	// merged with existing items.
+	after, err := measure(f.UniversalFS, to)
+	if err != nil {
+		return err
+	}
+
+	f.apply(QuotaUsage{
+		Bytes: after.Bytes - before.Bytes,
+		Files: after.Files - before.Files,
+	})
+
+	return nil
+}
+
+// CopyFS copies the file system fsys into the directory dir, unless
+// doing so would exceed the quota, which is checked against the whole of
+// fsys before anything is copied. Existing files are not overwritten and
+// symbolic links are rejected; a file that fails to copy does not prevent
+// the others from being copied and the errors are joined.
+func (f *QuotaFS) CopyFS(dir string, fsys fs.FS) error {
+	return f.CopyFSContext(context.Background(), dir, fsys)
+}
+
+// CopyFSContext is the cancellable variant of CopyFS
+func (f *QuotaFS) CopyFSContext(ctx context.Context, dir string, fsys fs.FS) error {
+	if err := f.within("CopyFS", dir); err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	source, err := measureFS(fsys)
+	if err != nil {
+		return err
+	}
+
+	if err := f.reserve("CopyFS", dir, source); err != nil {
+		return err
+	}
+
+	return copyThrough(ctx, f, dir, fsys, func(name string, data []byte, perm os.FileMode) error {
+		if err := f.UniversalFS.WriteFile(name, data, perm); err != nil {
+			return err
+		}
+
+		f.apply(QuotaUsage{Bytes: int64(len(data)), Files: 1})
+
+		return nil
+	})
+}
+
+// MakeDir creates the named directory under the root
+func (f *QuotaFS) MakeDir(name string, perm os.FileMode) error {
+	if err := f.within("MakeDir", name); err != nil {
+		return err
+	}
+
+	return f.UniversalFS.MakeDir(name, perm)
+}
+
+// MakeDirAll creates the named directory, and any missing parents, under
+// the root
+func (f *QuotaFS) MakeDirAll(name string, perm os.FileMode) error {
+	if err := f.within("MakeDirAll", name); err != nil {
+		return err
+	}
+
+	return f.UniversalFS.MakeDirAll(name, perm)
+}
+
+// MakeDirAllContext is the cancellable variant of MakeDirAll
+func (f *QuotaFS) MakeDirAllContext(ctx context.Context, name string, perm os.FileMode) error {
+	if err := f.within("MakeDirAll", name); err != nil {
+		return err
+	}
+
+	return MakeDirAllContext(ctx, f.UniversalFS, name, perm)
+}
+
+// Ensure makes sure that a path exists under the root, with the semantics
+// of the decorated file system
+func (f *QuotaFS) Ensure(as PathAs) (string, error) {
+	if err := f.within("Ensure", as.Name); err != nil {
+		return "", err
+	}
+
+	return f.UniversalFS.Ensure(as)
+}
+
+// Remove removes the named file or empty directory, releasing its usage
+func (f *QuotaFS) Remove(name string) error {
+	return f.release("Remove", name, f.UniversalFS.Remove)
+}
+
+// RemoveAll removes path and any children it contains, releasing their
+// usage
+func (f *QuotaFS) RemoveAll(path string) error {
+	return f.release("RemoveAll", path, f.UniversalFS.RemoveAll)
+}
+
+// RemoveAllContext is the cancellable variant of RemoveAll; the usage of
+// the items removed is released, even if the removal is cancelled part
+// way through.
+func (f *QuotaFS) RemoveAllContext(ctx context.Context, path string) error {
+	if err := f.within("RemoveAll", path); err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	before, err := measure(f.UniversalFS, path)
+	if err != nil {
+		return err
+	}
+
+	removeErr := RemoveAllContext(ctx, f.UniversalFS, path)
+
+	after, err := measure(f.UniversalFS, path)
+	if err != nil {
+		return errors.Join(removeErr, err)
+	}
+
+	f.apply(QuotaUsage{
+		Bytes: after.Bytes - before.Bytes,
+		Files: after.Files - before.Files,
+	})
+
+	return removeErr
+}
+
+// Move moves an item within the root; the usage of an item it replaces
+// is released.
+func (f *QuotaFS) Move(from, to string) error {
+	if err := f.within("Move", from, to); err != nil {
+		return err
+	}
+
+	return f.relocate(f.UniversalFS.Move, from, to, f.into(from, to))
+}
+
+// Change renames an item within its directory, which must reside under
+// the root; the usage of an item it replaces is released.
+func (f *QuotaFS) Change(from, to string) error {
+	if err := f.within("Change", from); err != nil {
+		return err
+	}
+
+	return f.relocate(f.UniversalFS.Change, from, to,
+		joinUnder(f, f.Calc().Dir(from), to),
+	)
+}
+
+// Rename renames an item within the root; the usage of an item it
+// replaces is released.
+func (f *QuotaFS) Rename(from, to string) error {
+	if err := f.within("Rename", from, to); err != nil {
+		return err
+	}
+
+	return f.relocate(f.UniversalFS.Rename, from, to, to)
+}
+
+// into returns the path of the item from, once moved to, which may be an
+// existing directory.
+func (f *QuotaFS) into(from, to string) string {
+	if f.UniversalFS.DirectoryExists(to) {
+		return joinUnder(f, to, f.Calc().Base(from))
+	}
+
+	return to
+}
+
+// relocate performs op, which moves from to dest, then accounts for the
+// difference in usage of both paths, so that the usage of an item that
+// was replaced at dest is released.
+func (f *QuotaFS) relocate(op func(from, to string) error, from, to, dest string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	before, err := f.measureBoth(from, dest)
+	if err != nil {
+		return err
+	}
+
+	if err := op(from, to); err != nil {
+		return err
+	}
+
+	after, err := f.measureBoth(from, dest)
+	if err != nil {
+		return err
+	}
+
+	f.apply(QuotaUsage{
+		Bytes: after.Bytes - before.Bytes,
+		Files: after.Files - before.Files,
+	})
+
+	return nil
+}
+
+func (f *QuotaFS) measureBoth(from, dest string) (QuotaUsage, error) {
+	source, err := measure(f.UniversalFS, from)
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+
+	destination, err := measure(f.UniversalFS, dest)
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+
+	return QuotaUsage{
+		Bytes: source.Bytes + destination.Bytes,
+		Files: source.Files + destination.Files,
+	}, nil
+}
+
+func (f *QuotaFS) release(op, path string, remove func(string) error) error {
+	if err := f.within(op, path); err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	existing, err := measure(f.UniversalFS, path)
+	if err != nil {
+		return err
+	}
+
+	if err := remove(path); err != nil {
+		return err
+	}
+
+	f.apply(QuotaUsage{
+		Bytes: -existing.Bytes,
+		Files: -existing.Files,
+	})
+
+	return nil
+}
+
+// Chtimes changes the access and modification times of the named item
+// under the root, if the decorated file system implements ChangeTimesFS.
+func (f *QuotaFS) Chtimes(name string, atime, mtime time.Time) error {
+	if err := f.within("Chtimes", name); err != nil {
+		return err
+	}
+
+	changer, ok := f.UniversalFS.(ChangeTimesFS)
+	if !ok {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: errors.ErrUnsupported}
+	}
+
+	return changer.Chtimes(name, atime, mtime)
+}
+
+type quotaFile struct {
+	fs.File
+	writer io.Writer
+	fS     *QuotaFS
+	name   string
+}
+
+// Write writes p, unless doing so would exceed the byte quota, in which
+// case nothing is written.
+func (q *quotaFile) Write(p []byte) (int, error) {
+	q.fS.mutex.Lock()
+	defer q.fS.mutex.Unlock()
+
+	delta := QuotaUsage{Bytes: int64(len(p))}
+	if err := q.fS.reserve("Write", q.name, delta); err != nil {
+		return 0, err
+	}
+
+	n, err := q.writer.Write(p)
+	q.fS.apply(QuotaUsage{Bytes: int64(n)})
+
+	return n, err
+}
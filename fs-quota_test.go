@@ -0,0 +1,259 @@
+package nef_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing/fstest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+// copier is a MemFS that implements Copy, for files and directories
+type copier struct {
+	*luna.MemFS
+}
+
+func (c *copier) Copy(from, to string) error {
+	for name, file := range c.MapFS {
+		if name == from || strings.HasPrefix(name, from+"/") {
+			copied := *file
+			c.MapFS[to+strings.TrimPrefix(name, from)] = &copied
+		}
+	}
+
+	return nil
+}
+
+var _ = Describe("QuotaFS", func() {
+	var (
+		memFS *luna.MemFS
+	)
+
+	BeforeEach(func() {
+		memFS = luna.NewMemFS()
+		for name, content := range map[string]string{
+			"plugin/config.json":    "{}",
+			"plugin/data/cache.bin": "0123456789",
+		} {
+			memFS.MapFS[name] = &fstest.MapFile{
+				Data: []byte(content),
+				Mode: lab.Perms.File,
+			}
+		}
+	})
+
+	It("🧪 should: scan initial usage", func() {
+		fS, err := nef.NewQuotaFS(memFS, "plugin", nef.QuotaLimits{})
+		Expect(err).To(Succeed())
+		Expect(fS.Usage()).To(Equal(nef.QuotaUsage{Bytes: 12, Files: 2}))
+	})
+
+	When("given: write within quota", func() {
+		It("🧪 should: write and account for usage", func() {
+			fS, err := nef.NewQuotaFS(memFS, "plugin", nef.QuotaLimits{Bytes: 20, Files: 3})
+			Expect(err).To(Succeed())
+			Expect(fS.WriteFile("plugin/state", []byte("12345678"), lab.Perms.File)).To(Succeed())
+			Expect(fS.Usage()).To(Equal(nef.QuotaUsage{Bytes: 20, Files: 3}))
+		})
+	})
+
+	When("given: write exceeding byte quota", func() {
+		It("🧪 should: reject with quota error", func() {
+			fS, err := nef.NewQuotaFS(memFS, "plugin", nef.QuotaLimits{Bytes: 20})
+			Expect(err).To(Succeed())
+
+			err = fS.WriteFile("plugin/state", []byte("123456789"), lab.Perms.File)
+			Expect(nef.IsQuotaExceededError(err)).To(BeTrue())
+			Expect(luna.AsFile("plugin/state")).NotTo(luna.ExistInFS(memFS))
+
+			var quotaErr *nef.QuotaError
+			Expect(errors.As(err, &quotaErr)).To(BeTrue())
+			Expect(quotaErr.Resource).To(Equal(nef.QuotaBytes))
+			Expect(quotaErr.Usage).To(Equal(int64(12)))
+			Expect(quotaErr.Requested).To(Equal(int64(9)))
+
+			reason, _ := nef.ReasonOf(err)
+			Expect(reason).To(Equal(nef.ReasonQuotaExceeded))
+			Expect(fS.Usage().Bytes).To(Equal(int64(12)))
+		})
+	})
+
+	When("given: write exceeding file quota", func() {
+		It("🧪 should: reject with quota error", func() {
+			fS, err := nef.NewQuotaFS(memFS, "plugin", nef.QuotaLimits{Files: 2})
+			Expect(err).To(Succeed())
+
+			err = fS.WriteFile("plugin/state", []byte("1"), lab.Perms.File)
+			Expect(nef.IsQuotaExceededError(err)).To(BeTrue())
+
+			_, err = fS.Create("plugin/state")
+			Expect(nef.IsQuotaExceededError(err)).To(BeTrue())
+		})
+	})
+
+	When("given: copy exceeding quota", func() {
+		It("🧪 should: reject with quota error", func() {
+			fS, err := nef.NewQuotaFS(&copier{MemFS: memFS}, "plugin", nef.QuotaLimits{Bytes: 20})
+			Expect(err).To(Succeed())
+			Expect(nef.IsQuotaExceededError(fS.Copy("plugin/data", "plugin/backup"))).To(BeTrue())
+			Expect(luna.AsDirectory("plugin/backup")).NotTo(luna.ExistInFS(memFS))
+		})
+	})
+
+	When("given: copy within quota", func() {
+		It("🧪 should: copy and account for usage", func() {
+			fS, err := nef.NewQuotaFS(&copier{MemFS: memFS}, "plugin", nef.QuotaLimits{Bytes: 22, Files: 3})
+			Expect(err).To(Succeed())
+			Expect(fS.Copy("plugin/data", "plugin/backup")).To(Succeed())
+			Expect(luna.AsFile("plugin/backup/cache.bin")).To(luna.HaveFileContent(memFS, "0123456789"))
+			Expect(fS.Usage()).To(Equal(nef.QuotaUsage{Bytes: 22, Files: 3}))
+		})
+	})
+
+	When("given: copy not implemented by decorated file system", func() {
+		It("🧪 should: return its error without accounting for usage", func() {
+			fS, err := nef.NewQuotaFS(memFS, "plugin", nef.QuotaLimits{Bytes: 22})
+			Expect(err).To(Succeed())
+			Expect(fS.Copy("plugin/data", "plugin/backup")).To(MatchError(errors.ErrUnsupported))
+			Expect(fS.Usage()).To(Equal(nef.QuotaUsage{Bytes: 12, Files: 2}))
+		})
+	})
+
+	DescribeTable("outside root",
+		func(op func(fS *nef.QuotaFS) error) {
+			memFS.MapFS["other/big.bin"] = &fstest.MapFile{
+				Data: []byte("0123456789"),
+				Mode: lab.Perms.File,
+			}
+			fS, err := nef.NewQuotaFS(memFS, "plugin", nef.QuotaLimits{Bytes: 20})
+			Expect(err).To(Succeed())
+
+			err = op(fS)
+			Expect(nef.IsInvalidPathError(err)).To(BeTrue(), "%v", err)
+			reason, _ := nef.ReasonOf(err)
+			Expect(reason).To(Equal(nef.ReasonOutsideRoot))
+			Expect(fS.Usage()).To(Equal(nef.QuotaUsage{Bytes: 12, Files: 2}))
+			Expect(luna.AsFile("other/big.bin")).To(luna.ExistInFS(memFS))
+		},
+		func(op func(fS *nef.QuotaFS) error) string {
+			return "🧪 ===> given: path outside root, should: reject with invalid path error"
+		},
+		Entry("write", func(fS *nef.QuotaFS) error {
+			return fS.WriteFile("other/state", []byte("1"), lab.Perms.File)
+		}),
+		Entry("write to sibling with common prefix", func(fS *nef.QuotaFS) error {
+			return fS.WriteFile("plugins/state", []byte("1"), lab.Perms.File)
+		}),
+		Entry("remove", func(fS *nef.QuotaFS) error {
+			return fS.Remove("other/big.bin")
+		}),
+		Entry("rename out of root", func(fS *nef.QuotaFS) error {
+			return fS.Rename("plugin/config.json", "other/config.json")
+		}),
+		Entry("copy into root", func(fS *nef.QuotaFS) error {
+			return fS.Copy("other/big.bin", "plugin/big.bin")
+		}),
+		Entry("copy file system", func(fS *nef.QuotaFS) error {
+			return fS.CopyFS("other", fstest.MapFS{})
+		}),
+		Entry("make directory", func(fS *nef.QuotaFS) error {
+			return fS.MakeDir("other/sub", lab.Perms.Dir)
+		}),
+		Entry("make directory tree", func(fS *nef.QuotaFS) error {
+			return fS.MakeDirAll("other/sub/leaf", lab.Perms.Dir)
+		}),
+		Entry("ensure", func(fS *nef.QuotaFS) error {
+			_, err := fS.Ensure(nef.PathAs{Name: "other/sub", Default: "x", Perm: lab.Perms.Dir})
+			return err
+		}),
+		Entry("change times", func(fS *nef.QuotaFS) error {
+			return fS.Chtimes("other/big.bin", time.Now(), time.Now())
+		}),
+	)
+
+	When("given: rename over existing file", func() {
+		It("🧪 should: release usage of replaced file", func() {
+			fS, err := nef.NewQuotaFS(memFS, "plugin", nef.QuotaLimits{})
+			Expect(err).To(Succeed())
+
+			Expect(fS.Rename("plugin/config.json", "plugin/data/cache.bin")).To(Succeed())
+			Expect(fS.Usage()).To(Equal(nef.QuotaUsage{Bytes: 2, Files: 1}))
+		})
+	})
+
+	When("given: CopyFS within quota", func() {
+		It("🧪 should: copy and account for usage", func() {
+			fS, err := nef.NewQuotaFS(memFS, "plugin", nef.QuotaLimits{Bytes: 20, Files: 4})
+			Expect(err).To(Succeed())
+
+			Expect(fS.CopyFS("plugin/imported", fstest.MapFS{
+				"a.txt":     {Data: []byte("abc"), Mode: lab.Perms.File},
+				"sub/b.txt": {Data: []byte("de"), Mode: lab.Perms.File},
+			})).To(Succeed())
+			Expect(luna.AsFile("plugin/imported/sub/b.txt")).To(luna.HaveFileContent(memFS, "de"))
+			Expect(fS.Usage()).To(Equal(nef.QuotaUsage{Bytes: 17, Files: 4}))
+		})
+	})
+
+	When("given: CopyFS exceeding quota", func() {
+		It("🧪 should: reject with quota error", func() {
+			fS, err := nef.NewQuotaFS(memFS, "plugin", nef.QuotaLimits{Bytes: 20})
+			Expect(err).To(Succeed())
+
+			err = fS.CopyFS("plugin/imported", fstest.MapFS{
+				"a.txt": {Data: []byte("0123456789"), Mode: lab.Perms.File},
+			})
+			Expect(nef.IsQuotaExceededError(err)).To(BeTrue())
+			Expect(luna.AsFile("plugin/imported/a.txt")).NotTo(luna.ExistInFS(memFS))
+			Expect(fS.Usage()).To(Equal(nef.QuotaUsage{Bytes: 12, Files: 2}))
+		})
+	})
+
+	When("given: items removed", func() {
+		It("🧪 should: release usage", func() {
+			fS, err := nef.NewQuotaFS(memFS, "plugin", nef.QuotaLimits{Bytes: 12})
+			Expect(err).To(Succeed())
+
+			Expect(fS.Remove("plugin/config.json")).To(Succeed())
+			Expect(fS.Usage()).To(Equal(nef.QuotaUsage{Bytes: 10, Files: 1}))
+
+			Expect(fS.RemoveAll("plugin/data")).To(Succeed())
+			Expect(fS.Usage()).To(Equal(nef.QuotaUsage{}))
+
+			Expect(fS.WriteFile("plugin/state", []byte("0123456789ab"), lab.Perms.File)).To(Succeed())
+		})
+	})
+
+	When("given: relative file system", func() {
+		It("🧪 should: count bytes written via Create", func() {
+			root := GinkgoT().TempDir()
+			fS, err := nef.NewQuotaFS(nef.NewUniversalFS(nef.Rel{
+				Root: root,
+			}), ".", nef.QuotaLimits{Bytes: 8})
+			Expect(err).To(Succeed())
+
+			file, err := fS.Create("log.txt")
+			Expect(err).To(Succeed())
+			writer := file.(io.Writer)
+
+			_, err = writer.Write([]byte("12345"))
+			Expect(err).To(Succeed())
+			_, err = writer.Write([]byte("6789"))
+			Expect(nef.IsQuotaExceededError(err)).To(BeTrue())
+			Expect(file.Close()).To(Succeed())
+
+			Expect(fS.Usage()).To(Equal(nef.QuotaUsage{Bytes: 5, Files: 1}))
+
+			info, err := fS.Stat("log.txt")
+			Expect(err).To(Succeed())
+			Expect(info.Size()).To(Equal(int64(5)))
+		})
+	})
+})
@@ -1,9 +1,11 @@
 package nef
 
 import (
-	"fmt"
+	"context"
 	"io/fs"
 	"os"
+	"path/filepath"
+	"time"
 )
 
 // 🔥 An important note about using standard golang file systems (io.fs/fs.FS)
@@ -61,6 +63,59 @@ func (f *openFS) Calc() PathCalc {
 	return f.calc
 }
 
+func (f *openFS) Root() string {
+	return f.root
+}
+
+// ToAbsolute converts name, which must be valid according to fs.ValidPath,
+// into an absolute path within the root.
+func (f *openFS) ToAbsolute(name string) (string, error) {
+	rel, err := NewRelPath(name)
+	if err != nil {
+		return "", err
+	}
+
+	root, err := f.absoluteRoot()
+	if err != nil {
+		return "", err
+	}
+
+	return rel.ToAbs(root).String(), nil
+}
+
+// ToRelative converts an absolute path into a path relative to the root,
+// returning an invalid path error if the path lies outside the root.
+func (f *openFS) ToRelative(path string) (string, error) {
+	abs, err := NewAbsPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	root, err := f.absoluteRoot()
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := abs.RelTo(root)
+	if err != nil {
+		return "", err
+	}
+
+	return rel.String(), nil
+}
+
+// absoluteRoot returns the root as an absolute path; a relative root is
+// taken to be relative to the current working directory, as it is for
+// os.DirFS.
+func (f *openFS) absoluteRoot() (AbsPath, error) {
+	root, err := filepath.Abs(f.root)
+	if err != nil {
+		return AbsPath{}, newInvalidPathError("Root", f.root, ReasonInvalidPath, err)
+	}
+
+	return NewAbsPath(root)
+}
+
 // NewStatFS returns a file system rooted at rel.Root that supports Open and Stat.
 func NewStatFS(rel Rel) fs.StatFS {
 	ents := compose(sanitise(rel.Root))
@@ -139,6 +194,14 @@ func NewExistsInFS(rel Rel) ExistsInFS {
 // disambiguators
 func (f *existsInFS) Calc() PathCalc   { return f.statFS.calc }
 func (f *existsInFS) IsRelative() bool { return true }
+func (f *existsInFS) Root() string     { return f.statFS.root }
+func (f *existsInFS) ToAbsolute(name string) (string, error) {
+	return f.statFS.ToAbsolute(name)
+}
+
+func (f *existsInFS) ToRelative(path string) (string, error) {
+	return f.statFS.ToRelative(path)
+}
 
 // FileExists does file exist at the path specified
 func (f *existsInFS) FileExists(name string) bool {
@@ -200,15 +263,47 @@ type copyFS struct {
 	*openFS
 }
 
+// Copy copies the item at from to to, which must not exist; if to is an
+// existing directory, the item is copied into it, under its own name. A
+// directory is copied with its contents, in the same way as CopyFS.
 func (f *copyFS) Copy(from, to string) error {
-	return fmt.Errorf("copy not implemented yet (from: %q, to: %q)", from, to)
+	if !fs.ValidPath(from) {
+		return NewInvalidPathError("Copy", from)
+	}
+
+	if !fs.ValidPath(to) {
+		return NewInvalidPathError("Copy", to)
+	}
+
+	return copyNative(f.calc, func(name string) string {
+		return f.calc.Join(f.root, name)
+	}, from, to)
 }
 
 // CopyFS copies the file system fsys into the directory dir,
-// creating dir if necessary.
+// creating dir if necessary. Existing files are not overwritten; a file
+// that fails to copy does not prevent the others from being copied and
+// the errors are joined in walk order.
 func (f *copyFS) CopyFS(dir string, fsys fs.FS) error {
-	_ = fsys
-	return fmt.Errorf("copyFS not implemented yet (dir: %q)", dir)
+	return f.CopyFSContext(context.Background(), dir, fsys)
+}
+
+// CopyFSContext is the cancellable variant of CopyFS
+func (f *copyFS) CopyFSContext(ctx context.Context, dir string, fsys fs.FS) error {
+	return f.CopyFSWith(ctx, dir, fsys, CopyOptions{})
+}
+
+// CopyFSWith is the variant of CopyFSContext that applies options
+func (f *copyFS) CopyFSWith(ctx context.Context, dir string, fsys fs.FS, options CopyOptions) error {
+	if !fs.ValidPath(dir) {
+		return NewInvalidPathError("CopyFS", dir)
+	}
+
+	native := f.calc.Join(f.root, dir)
+	c := newCancellable(ctx, "CopyFS", dir, native, f.calc)
+	c.workers = options.Workers
+
+	return c.copyFS(native, fsys)
 }
 
 // 🎯 baseWriterFS
@@ -233,6 +328,14 @@ func NewMakeDirFS(rel Rel) MakeDirFS {
 // disambiguators
 func (f *makeDirAllFS) Calc() PathCalc   { return f.statFS.calc }
 func (f *makeDirAllFS) IsRelative() bool { return true }
+func (f *makeDirAllFS) Root() string     { return f.statFS.root }
+func (f *makeDirAllFS) ToAbsolute(name string) (string, error) {
+	return f.statFS.ToAbsolute(name)
+}
+
+func (f *makeDirAllFS) ToRelative(path string) (string, error) {
+	return f.statFS.ToRelative(path)
+}
 
 // Mkdir creates a new directory with the specified name and permission
 // bits (before umask).
@@ -269,6 +372,18 @@ func (f *makeDirAllFS) MakeDirAll(name string, perm os.FileMode) error {
 	return os.MkdirAll(path, perm)
 }
 
+// MakeDirAllContext is the cancellable variant of MakeDirAll
+func (f *makeDirAllFS) MakeDirAllContext(ctx context.Context, name string, perm os.FileMode) error {
+	if !fs.ValidPath(name) {
+		return NewInvalidPathError("MakeDirAll", name)
+	}
+
+	native := f.statFS.calc.Join(f.statFS.root, name)
+	c := newCancellable(ctx, "MakeDirAll", name, native, f.statFS.calc)
+
+	return c.makeDirAll(native, perm)
+}
+
 // Ensure makes sure that a path exists at a particular location depending
 // on the value of as.AsFile.
 //
@@ -332,6 +447,18 @@ func (f *removeFS) RemoveAll(path string) error {
 	return os.RemoveAll(f.calc.Join(f.root, f.calc.Clean(path)))
 }
 
+// RemoveAllContext is the cancellable variant of RemoveAll
+func (f *removeFS) RemoveAllContext(ctx context.Context, path string) error {
+	if !fs.ValidPath(path) {
+		return NewInvalidPathError("RemoveAll", path)
+	}
+
+	native := f.calc.Join(f.root, f.calc.Clean(path))
+	c := newCancellable(ctx, "RemoveAll", path, native, f.calc)
+
+	return c.removeAll(native)
+}
+
 // 🎯 renameFS
 
 type renameFS struct {
@@ -347,6 +474,45 @@ func (f *renameFS) Rename(from, to string) error {
 	)
 }
 
+// 🎯 changeTimesFS
+
+type changeTimesFS struct {
+	*openFS
+}
+
+// Chtimes changes the access and modification times of the named item
+func (f *changeTimesFS) Chtimes(name string, atime, mtime time.Time) error {
+	if !fs.ValidPath(name) {
+		return NewInvalidPathError("Chtimes", name)
+	}
+
+	return os.Chtimes(f.calc.Join(f.root, name), atime, mtime)
+}
+
+// 🎯 symlinkFS
+
+type symlinkFS struct {
+	*openFS
+}
+
+// Symlink creates newname as a symbolic link to oldname
+func (f *symlinkFS) Symlink(oldname, newname string) error {
+	if !fs.ValidPath(newname) {
+		return NewInvalidPathError("Symlink", newname)
+	}
+
+	return os.Symlink(oldname, f.calc.Join(f.root, newname))
+}
+
+// ReadLink returns the destination of the named symbolic link
+func (f *symlinkFS) ReadLink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", NewInvalidPathError("ReadLink", name)
+	}
+
+	return os.Readlink(f.calc.Join(f.root, name))
+}
+
 // 🎯 writeFileFS
 type writeFileFS struct {
 	*baseWriterFS
@@ -400,6 +566,59 @@ func (f *writeFileFS) WriteFile(name string, data []byte, perm os.FileMode) erro
 	return os.WriteFile(path, data, perm)
 }
 
+// 🎯 lockFS
+
+type lockFS struct {
+	*openFS
+}
+
+// Lock acquires a lock of mode on name, waiting until any conflicting
+// lock is released. It is implemented with flock on linux, darwin and
+// the BSDs and is not supported elsewhere.
+func (f *lockFS) Lock(name string, mode LockMode) (FileLock, error) {
+	return f.lock("Lock", name, mode, lockWaitForever)
+}
+
+// TryLock acquires a lock of mode on name, without waiting
+func (f *lockFS) TryLock(name string, mode LockMode) (FileLock, error) {
+	return f.lock("TryLock", name, mode, 0)
+}
+
+// LockTimeout acquires a lock of mode on name, waiting at most timeout
+func (f *lockFS) LockTimeout(name string, mode LockMode, timeout time.Duration) (FileLock, error) {
+	return f.lock("LockTimeout", name, mode, max(timeout, 0))
+}
+
+func (f *lockFS) lock(op, name string, mode LockMode, wait time.Duration) (FileLock, error) {
+	if !fs.ValidPath(name) {
+		return nil, NewInvalidPathError(op, name)
+	}
+
+	return lockNative(op, name, f.calc.Join(f.root, name), mode, wait)
+}
+
+// 🎯 watchFS
+
+type watchFS struct {
+	*openFS
+}
+
+// Watch watches name and, if it is a directory, the tree beneath it,
+// reporting events with paths relative to the root. It uses inotify on
+// linux, falling back to polling elsewhere.
+func (f *watchFS) Watch(name string) (Watcher, error) {
+	if !fs.ValidPath(name) {
+		return nil, NewInvalidPathError("Watch", name)
+	}
+
+	origin, err := f.ToAbsolute(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return watch(f.calc, name, origin)
+}
+
 // 🧩 ---> file system aggregators
 
 // 🎯 readerFS
@@ -408,11 +627,20 @@ type readerFS struct {
 	*readDirFS
 	*readFileFS
 	*statFS
+	*watchFS
 }
 
 // disambiguators
 func (f *readerFS) Calc() PathCalc   { return f.statFS.calc }
 func (f *readerFS) IsRelative() bool { return true }
+func (f *readerFS) Root() string     { return f.statFS.root }
+func (f *readerFS) ToAbsolute(name string) (string, error) {
+	return f.statFS.ToAbsolute(name)
+}
+
+func (f *readerFS) ToRelative(path string) (string, error) {
+	return f.statFS.ToRelative(path)
+}
 
 // NewReaderFS returns a file system rooted at rel.Root with stat, read-dir, existence checks, and ReadFile.
 func NewReaderFS(rel Rel) ReaderFS {
@@ -424,10 +652,21 @@ func NewReaderFS(rel Rel) ReaderFS {
 // 🎯 aggregatorFS
 type aggregatorFS struct {
 	*baseWriterFS
+	rename  *renameFS
 	mover   lazyMover
 	changer lazyChanger
 }
 
+// nativeRouteFS is the file system through which Move and Change stat
+// and rename items, unless they are routed elsewhere.
+type nativeRouteFS struct {
+	*aggregatorFS
+}
+
+func (f *nativeRouteFS) Rename(from, to string) error {
+	return f.rename.Rename(from, to)
+}
+
 // disambiguators
 // Calc returns the path calculator used by the file system.
 func (f *aggregatorFS) Calc() PathCalc   { return f.statFS.calc }
@@ -435,6 +674,19 @@ func (f *aggregatorFS) Calc() PathCalc   { return f.statFS.calc }
 // IsRelative returns true if the file system is relative.
 func (f *aggregatorFS) IsRelative() bool { return true }
 
+// Root returns the root of the file system.
+func (f *aggregatorFS) Root() string { return f.statFS.root }
+
+// ToAbsolute converts a path relative to the root into an absolute path.
+func (f *aggregatorFS) ToAbsolute(name string) (string, error) {
+	return f.statFS.ToAbsolute(name)
+}
+
+// ToRelative converts an absolute path into a path relative to the root.
+func (f *aggregatorFS) ToRelative(path string) (string, error) {
+	return f.statFS.ToRelative(path)
+}
+
 // Move is similar to rename but it has distinctly different semantics, which
 // also varies depending on whether the file system was created with overwrite
 // enabled or not.
@@ -444,11 +696,14 @@ func (f *aggregatorFS) IsRelative() bool { return true }
 // the move amounts to a rename and the client should use Rename instead of
 // move. When this scenario is detected, an error is returned.
 func (f *aggregatorFS) Move(from, to string) error {
-	return f.mover.instance(
-		f.existsInFS.queryStatusFS.statFS.root,
-		f.overwrite,
-		f,
-	).move(from, to)
+	return f.mover.instance(f.overwrite, &nativeRouteFS{f}).move(from, to)
+}
+
+// MoveVia moves an item, as Move does, but stats and renames items
+// through via, which must accept paths relative to the root of this file
+// system.
+func (f *aggregatorFS) MoveVia(via MoverFS, from, to string) error {
+	return new(lazyMover).create(f.overwrite, via).move(from, to)
 }
 
 // Change is similar to move but it has distinctly different semantics, which
@@ -460,20 +715,26 @@ func (f *aggregatorFS) Move(from, to string) error {
 // the change amounts to a move and the client should use Move instead of
 // change. When this scenario is detected, an error is returned.
 func (f *aggregatorFS) Change(from, to string) error {
-	return f.changer.instance(
-		f.existsInFS.queryStatusFS.statFS.root,
-		f.overwrite,
-		f,
-	).change(from, to)
+	return f.changer.instance(f.overwrite, &nativeRouteFS{f}).change(from, to)
+}
+
+// ChangeVia changes an item, as Change does, but stats and renames items
+// through via, which must accept paths relative to the root of this file
+// system.
+func (f *aggregatorFS) ChangeVia(via ChangerFS, from, to string) error {
+	return new(lazyChanger).create(f.overwrite, via).change(from, to)
 }
 
 // 🎯 writerFS
 type writerFS struct {
+	*changeTimesFS
 	*copyFS
+	*lockFS
 	*makeDirAllFS
 	*aggregatorFS
 	*removeFS
 	*renameFS
+	*symlinkFS
 	*writeFileFS
 }
 
@@ -492,6 +753,14 @@ func (f *writerFS) Calc() PathCalc   { return f.statFS.calc }
 
 // IsRelative returns true if the file system is relative.
 func (f *writerFS) IsRelative() bool { return true }
+func (f *writerFS) Root() string     { return f.statFS.root }
+func (f *writerFS) ToAbsolute(name string) (string, error) {
+	return f.statFS.ToAbsolute(name)
+}
+
+func (f *writerFS) ToRelative(path string) (string, error) {
+	return f.statFS.ToRelative(path)
+}
 
 // 🎯 mutatorFS
 // mutatorFS is a file system that combines a reader and writer file system.
@@ -507,6 +776,14 @@ func (f *mutatorFS) Calc() PathCalc   { return f.statFS.calc }
 
 // IsRelative returns true if the file system is relative.
 func (f *mutatorFS) IsRelative() bool { return true }
+func (f *mutatorFS) Root() string     { return f.statFS.root }
+func (f *mutatorFS) ToAbsolute(name string) (string, error) {
+	return f.statFS.ToAbsolute(name)
+}
+
+func (f *mutatorFS) ToRelative(path string) (string, error) {
+	return f.statFS.ToRelative(path)
+}
 
 func newMutatorFS(rel *Rel) *mutatorFS {
 	ents := compose(sanitise(rel.Root)).mutate(rel.Overwrite)
@@ -544,14 +821,23 @@ func (e *entities) mutate(overwrite bool) *entities {
 		overwrite:  overwrite,
 	}
 	e.writer = writerFS{
+		changeTimesFS: &changeTimesFS{
+			openFS: &e.open,
+		},
 		copyFS: &copyFS{
 			openFS: &e.open,
 		},
+		lockFS: &lockFS{
+			openFS: &e.open,
+		},
 		makeDirAllFS: &makeDirAllFS{
 			existsInFS: &e.exists,
 		},
 		aggregatorFS: &aggregatorFS{
 			baseWriterFS: writer,
+			rename: &renameFS{
+				openFS: &e.open,
+			},
 		},
 		removeFS: &removeFS{
 			openFS: &e.open,
@@ -559,6 +845,9 @@ func (e *entities) mutate(overwrite bool) *entities {
 		renameFS: &renameFS{
 			openFS: &e.open,
 		},
+		symlinkFS: &symlinkFS{
+			openFS: &e.open,
+		},
 		writeFileFS: &writeFileFS{
 			baseWriterFS: writer,
 		},
@@ -598,6 +887,9 @@ func compose(root string) *entities {
 		},
 		existsInFS: &exists,
 		statFS:     &stat,
+		watchFS: &watchFS{
+			openFS: &open,
+		},
 	}
 
 	return &entities{
@@ -0,0 +1,210 @@
+//go:build linux
+
+package nef
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CREATE | unix.IN_MODIFY | unix.IN_ATTRIB |
+	unix.IN_DELETE | unix.IN_DELETE_SELF | unix.IN_MOVED_FROM |
+	unix.IN_MOVED_TO | unix.IN_MOVE_SELF
+
+// inotifyWatcher watches a tree with inotify, which watches a single
+// directory, so every directory in the tree is watched and directories
+// created in the tree are watched as they appear. The items of such a
+// directory that are created before it is watched are reported when it
+// is, so an item may be reported as created more than once.
+type inotifyWatcher struct {
+	*watcher
+	fd      int
+	file    *os.File
+	watches map[int]string
+}
+
+func newNativeWatcher(w *watcher) (Watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	// a non blocking descriptor is serviced by the runtime poller, so a
+	// pending read is interrupted by Close; Fd must not be invoked on the
+	// file, since it would make the descriptor blocking.
+	i := &inotifyWatcher{
+		watcher: w,
+		fd:      fd,
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		watches: make(map[int]string),
+	}
+
+	if err := i.addTree(w.origin, false); err != nil {
+		_ = i.file.Close()
+
+		return nil, err
+	}
+
+	go i.run()
+
+	return i, nil
+}
+
+// Close stops the watch
+func (i *inotifyWatcher) Close() error {
+	return i.stop(i.file.Close)
+}
+
+func (i *inotifyWatcher) add(path string) error {
+	wd, err := unix.InotifyAddWatch(i.fd, path, inotifyMask)
+	if err != nil {
+		return &fs.PathError{Op: "watch", Path: path, Err: err}
+	}
+
+	i.watches[wd] = path
+
+	return nil
+}
+
+// addTree watches path, which may be a file, and every directory beneath
+// it, reporting the items found when announce is set; items that vanish
+// are ignored.
+func (i *inotifyWatcher) addTree(path string, announce bool) error {
+	return filepath.WalkDir(path, func(item string, entry fs.DirEntry, err error) error {
+		if err == nil && (entry.IsDir() || item == path) {
+			err = i.add(item)
+		}
+
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if announce && item != path && !i.emit(WatchCreate, item) {
+			return fs.SkipAll
+		}
+
+		return nil
+	})
+}
+
+// forget stops watching path and every directory beneath it
+func (i *inotifyWatcher) forget(path string) {
+	for wd, item := range i.watches {
+		if item == path || strings.HasPrefix(item, path+string(filepath.Separator)) {
+			_, _ = unix.InotifyRmWatch(i.fd, uint32(wd)) //nolint:gosec // wd is positive
+			delete(i.watches, wd)
+		}
+	}
+}
+
+func (i *inotifyWatcher) run() {
+	defer i.finish()
+
+	buffer := make([]byte, 4096*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+
+	for {
+		n, err := i.file.Read(buffer)
+		if i.closed() {
+			return
+		}
+
+		if err != nil {
+			i.fail(err)
+
+			return
+		}
+
+		if !i.dispatch(buffer[:n]) {
+			return
+		}
+	}
+}
+
+// dispatch handles the events in buffer, returning false if the watcher
+// has been closed.
+func (i *inotifyWatcher) dispatch(buffer []byte) bool {
+	for offset := 0; offset+unix.SizeofInotifyEvent <= len(buffer); {
+		raw := (*unix.InotifyEvent)(unsafe.Pointer(&buffer[offset])) //nolint:gosec // kernel layout
+		start := offset + unix.SizeofInotifyEvent
+		offset = start + int(raw.Len)
+		name := strings.TrimRight(string(buffer[start:offset]), "\x00")
+
+		if !i.handle(int(raw.Wd), raw.Mask, name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (i *inotifyWatcher) handle(wd int, mask uint32, name string) bool {
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		return i.fail(ErrCoreWatchOverflow)
+	}
+
+	dir, found := i.watches[wd]
+	if !found {
+		return true
+	}
+
+	if mask&unix.IN_IGNORED != 0 {
+		delete(i.watches, wd)
+
+		return true
+	}
+
+	path := dir
+	if name != "" {
+		path = filepath.Join(dir, name)
+	}
+
+	isDir := mask&unix.IN_ISDIR != 0
+
+	switch {
+	case mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+		if !i.emit(WatchCreate, path) {
+			return false
+		}
+
+		if isDir {
+			if err := i.addTree(path, true); err != nil {
+				return i.fail(err)
+			}
+		}
+
+	case mask&unix.IN_MODIFY != 0:
+		return i.emit(WatchWrite, path)
+
+	case mask&unix.IN_ATTRIB != 0:
+		return i.emit(WatchChmod, path)
+
+	case mask&unix.IN_DELETE != 0:
+		return i.emit(WatchRemove, path)
+
+	case mask&unix.IN_MOVED_FROM != 0:
+		if isDir {
+			i.forget(path)
+		}
+
+		return i.emit(WatchRename, path)
+
+	// the other directories are reported by their parent
+	case mask&unix.IN_DELETE_SELF != 0 && path == i.origin:
+		return i.emit(WatchRemove, path)
+
+	case mask&unix.IN_MOVE_SELF != 0 && path == i.origin:
+		return i.emit(WatchRename, path)
+	}
+
+	return !i.closed()
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package nef
+
+import (
+	"errors"
+)
+
+var errNoNativeWatcher = errors.New("native watching is not supported on this platform")
+
+// newNativeWatcher is not supported, so watching falls back to polling
+func newNativeWatcher(_ *watcher) (Watcher, error) {
+	return nil, errNoNativeWatcher
+}
@@ -0,0 +1,399 @@
+package nef
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchOp is a bit flag that denotes the kind of change reported by a
+// WatchEvent.
+type WatchOp uint8
+
+const (
+	// WatchCreate an item was created, or moved into the watched tree
+	WatchCreate WatchOp = 1 << iota
+	// WatchWrite the content of a file was written
+	WatchWrite
+	// WatchRemove an item was removed
+	WatchRemove
+	// WatchRename an item was renamed, or moved out of the watched tree;
+	// the event carries the old path and, when it remains within the
+	// tree, a WatchCreate event carries the new one
+	WatchRename
+	// WatchChmod the attributes of an item, eg its permissions or times,
+	// were changed
+	WatchChmod
+)
+
+var watchOpNames = []struct {
+	flag WatchOp
+	name string
+}{
+	{WatchCreate, "create"},
+	{WatchWrite, "write"},
+	{WatchRemove, "remove"},
+	{WatchRename, "rename"},
+	{WatchChmod, "chmod"},
+}
+
+// Has reports whether all the operations in flag are present
+func (op WatchOp) Has(flag WatchOp) bool {
+	return op&flag == flag
+}
+
+// String returns the names of the operations, separated by '|'
+func (op WatchOp) String() string {
+	names := make([]string, 0, len(watchOpNames))
+
+	for _, on := range watchOpNames {
+		if op.Has(on.flag) {
+			names = append(names, on.name)
+		}
+	}
+
+	if len(names) == 0 {
+		return "none"
+	}
+
+	return strings.Join(names, "|")
+}
+
+// WatchEvent is a change to an item being watched
+type WatchEvent struct {
+	// Path is the path of the item, in the form of the file system being
+	// watched, ie relative to the root for a relative file system
+	Path string
+	// Op is the change made to the item
+	Op WatchOp
+}
+
+// String returns the operation followed by the path
+func (e WatchEvent) String() string {
+	return fmt.Sprintf("%v: %q", e.Op, e.Path)
+}
+
+// DefaultPollInterval is the interval at which the tree is scanned, when
+// watching falls back to polling.
+const DefaultPollInterval = time.Second
+
+const watchBufferSize = 64
+
+// present maps the native path, which resides beneath origin, the native
+// path of name, to the form of the file system whose calculator is calc.
+func present(calc PathCalc, name, origin, native string) string {
+	rel, err := filepath.Rel(origin, native)
+	if err != nil {
+		return native
+	}
+
+	if rel == "." {
+		return name
+	}
+
+	// an ancestor of origin, eg a parent created by MakeDirAll
+	if levels, ok := ancestry(rel); ok {
+		for range levels {
+			name = calc.Dir(name)
+		}
+
+		return name
+	}
+
+	if name == "." {
+		return filepath.ToSlash(rel)
+	}
+
+	return calc.Join(name, filepath.ToSlash(rel))
+}
+
+// ancestry returns the number of levels above, when rel denotes an
+// ancestor, ie consists only of ".." elements.
+func ancestry(rel string) (int, bool) {
+	ups := strings.Split(filepath.ToSlash(rel), "/")
+
+	return len(ups), !slices.ContainsFunc(ups, func(up string) bool {
+		return up != ".."
+	})
+}
+
+// watch watches the native path origin, which corresponds to name on the
+// file system whose calculator is calc, with the native mechanism of the
+// platform, falling back to polling when that is unavailable.
+func watch(calc PathCalc, name, origin string) (Watcher, error) {
+	if _, err := os.Stat(origin); err != nil {
+		return nil, err
+	}
+
+	w := newWatcher(calc, name, origin)
+
+	if native, err := newNativeWatcher(w); err == nil {
+		return native, nil
+	}
+
+	return poll(w, DefaultPollInterval)
+}
+
+// NewPollWatcher watches name, and the tree beneath it if it is a
+// directory, on fS by scanning it at every interval. It is portable, but
+// can not detect a rename, which is reported as a remove and a create,
+// and misses changes that are undone within an interval.
+func NewPollWatcher(fS FSUtility, name string, interval time.Duration) (Watcher, error) {
+	origin, err := fS.ToAbsolute(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(origin); err != nil {
+		return nil, err
+	}
+
+	return poll(newWatcher(fS.Calc(), name, origin), interval)
+}
+
+func poll(w *watcher, interval time.Duration) (Watcher, error) {
+	p, err := newPollWatcher(w, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// watcher is the part of a Watcher common to all the mechanisms. The
+// events and errors are sent by a single goroutine, which invokes finish
+// when it exits.
+type watcher struct {
+	calc     PathCalc
+	name     string
+	origin   string
+	events   chan WatchEvent
+	errors   chan error
+	done     chan struct{}
+	finished chan struct{}
+	once     sync.Once
+}
+
+func newWatcher(calc PathCalc, name, origin string) *watcher {
+	return &watcher{
+		calc:     calc,
+		name:     name,
+		origin:   origin,
+		events:   make(chan WatchEvent, watchBufferSize),
+		errors:   make(chan error, 1),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+}
+
+// Events returns the channel on which events are delivered
+func (w *watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Errors returns the channel on which errors are delivered
+func (w *watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// emit sends an event for the native path, returning false if the
+// watcher has been closed.
+func (w *watcher) emit(op WatchOp, native string) bool {
+	select {
+	case w.events <- WatchEvent{Path: present(w.calc, w.name, w.origin, native), Op: op}:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+// fail sends err, returning false if the watcher has been closed.
+func (w *watcher) fail(err error) bool {
+	select {
+	case w.errors <- err:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *watcher) closed() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *watcher) finish() {
+	close(w.events)
+	close(w.errors)
+	close(w.finished)
+}
+
+// stop signals the goroutine to exit, invokes release, which must
+// unblock the goroutine if necessary, then waits for it to finish.
+func (w *watcher) stop(release func() error) error {
+	var err error
+
+	w.once.Do(func() {
+		close(w.done)
+
+		if release != nil {
+			err = release()
+		}
+
+		<-w.finished
+	})
+
+	return err
+}
+
+// 🎯 pollWatcher
+
+type pollState struct {
+	mode    fs.FileMode
+	size    int64
+	modTime time.Time
+}
+
+type pollWatcher struct {
+	*watcher
+	interval time.Duration
+	snapshot map[string]pollState
+}
+
+func newPollWatcher(w *watcher, interval time.Duration) (*pollWatcher, error) {
+	p := &pollWatcher{
+		watcher:  w,
+		interval: interval,
+	}
+
+	snapshot, err := p.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	p.snapshot = snapshot
+
+	go p.run()
+
+	return p, nil
+}
+
+// Close stops the watch
+func (p *pollWatcher) Close() error {
+	return p.stop(nil)
+}
+
+func (p *pollWatcher) run() {
+	defer p.finish()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+
+		current, err := p.scan()
+		if err != nil {
+			if !p.fail(err) {
+				return
+			}
+
+			continue
+		}
+
+		for _, event := range p.diff(current) {
+			if !p.emit(event.Op, event.Path) {
+				return
+			}
+		}
+
+		p.snapshot = current
+	}
+}
+
+// scan records the state of every item in the tree; items that vanish
+// during the scan are ignored.
+func (p *pollWatcher) scan() (map[string]pollState, error) {
+	snapshot := make(map[string]pollState)
+
+	err := filepath.WalkDir(p.origin, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil {
+			var info fs.FileInfo
+
+			if info, err = entry.Info(); err == nil {
+				snapshot[path] = pollState{
+					mode:    info.Mode(),
+					size:    info.Size(),
+					modTime: info.ModTime(),
+				}
+			}
+		}
+
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	})
+
+	return snapshot, err
+}
+
+// diff returns the events, with native paths, that transform the
+// snapshot into current, in lexical order of path.
+func (p *pollWatcher) diff(current map[string]pollState) []WatchEvent {
+	var events []WatchEvent
+
+	for path, previous := range p.snapshot {
+		state, found := current[path]
+
+		switch {
+		case !found:
+			events = append(events, WatchEvent{Path: path, Op: WatchRemove})
+
+		case state.mode.Type() != previous.mode.Type():
+			events = append(events,
+				WatchEvent{Path: path, Op: WatchRemove},
+				WatchEvent{Path: path, Op: WatchCreate},
+			)
+
+		case state.size != previous.size || !state.modTime.Equal(previous.modTime):
+			if state.mode.IsRegular() {
+				events = append(events, WatchEvent{Path: path, Op: WatchWrite})
+			}
+
+			if state.mode != previous.mode {
+				events = append(events, WatchEvent{Path: path, Op: WatchChmod})
+			}
+
+		case state.mode != previous.mode:
+			events = append(events, WatchEvent{Path: path, Op: WatchChmod})
+		}
+	}
+
+	for path := range current {
+		if _, found := p.snapshot[path]; !found {
+			events = append(events, WatchEvent{Path: path, Op: WatchCreate})
+		}
+	}
+
+	slices.SortStableFunc(events, func(a, b WatchEvent) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return events
+}
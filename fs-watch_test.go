@@ -0,0 +1,149 @@
+package nef_test
+
+import (
+	"io/fs"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+// observe waits for the watcher to report op for path, skipping any
+// other events
+func observe(w nef.Watcher, op nef.WatchOp, path string) {
+	GinkgoHelper()
+
+	Eventually(w.Events()).WithTimeout(5 * time.Second).Should(
+		Receive(Equal(nef.WatchEvent{Path: path, Op: op})),
+	)
+}
+
+var _ = Describe("WatchFS", func() {
+	var (
+		root string
+		fS   nef.UniversalFS
+	)
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+		fS = nef.NewUniversalFS(nef.Rel{
+			Root: root,
+		})
+
+		Expect(luna.Fixture{
+			luna.Dir("tree",
+				luna.File("a.txt", "a"),
+			),
+		}.Build(fS, ".")).To(Succeed())
+	})
+
+	Context("relative", func() {
+		var w nef.Watcher
+
+		BeforeEach(func() {
+			watcher, ok := fS.(nef.WatchFS)
+			Expect(ok).To(BeTrue())
+
+			var err error
+			w, err = watcher.Watch("tree")
+			Expect(err).To(Succeed())
+			DeferCleanup(w.Close)
+		})
+
+		It("🧪 should: report changes with paths relative to root", func() {
+			Expect(fS.WriteFile("tree/b.txt", []byte("b"), lab.Perms.File)).To(Succeed())
+			observe(w, nef.WatchCreate, "tree/b.txt")
+			observe(w, nef.WatchWrite, "tree/b.txt")
+
+			Expect(os.Chmod(root+"/tree/b.txt", 0o600)).To(Succeed())
+			observe(w, nef.WatchChmod, "tree/b.txt")
+
+			Expect(fS.Rename("tree/b.txt", "tree/c.txt")).To(Succeed())
+			observe(w, nef.WatchRename, "tree/b.txt")
+			observe(w, nef.WatchCreate, "tree/c.txt")
+
+			Expect(fS.Remove("tree/c.txt")).To(Succeed())
+			observe(w, nef.WatchRemove, "tree/c.txt")
+		})
+
+		It("🧪 should: report changes in new directories", func() {
+			Expect(fS.MakeDirAll("tree/x/y", lab.Perms.Dir)).To(Succeed())
+			observe(w, nef.WatchCreate, "tree/x")
+
+			Expect(fS.WriteFile("tree/x/y/z.txt", []byte("z"), lab.Perms.File)).To(Succeed())
+			observe(w, nef.WatchCreate, "tree/x/y/z.txt")
+		})
+
+		It("🧪 should: close channels", func() {
+			Expect(w.Close()).To(Succeed())
+			Eventually(w.Events()).Should(BeClosed())
+			Eventually(w.Errors()).Should(BeClosed())
+			Expect(w.Close()).To(Succeed())
+		})
+	})
+
+	Context("absolute", func() {
+		It("🧪 should: report changes with absolute paths", func() {
+			abs := nef.NewUniversalABS()
+			tree := abs.Calc().Join(root, "tree")
+
+			w, err := abs.(nef.WatchFS).Watch(tree)
+			Expect(err).To(Succeed())
+			DeferCleanup(w.Close)
+
+			Expect(abs.Remove(abs.Calc().Join(tree, "a.txt"))).To(Succeed())
+			observe(w, nef.WatchRemove, abs.Calc().Join(tree, "a.txt"))
+		})
+	})
+
+	Context("NewPollWatcher", func() {
+		It("🧪 should: report changes", func() {
+			w, err := nef.NewPollWatcher(fS, ".", 10*time.Millisecond)
+			Expect(err).To(Succeed())
+			DeferCleanup(w.Close)
+
+			Expect(fS.WriteFile("tree/b.txt", []byte("b"), lab.Perms.File)).To(Succeed())
+			observe(w, nef.WatchCreate, "tree/b.txt")
+
+			Expect(os.WriteFile(root+"/tree/a.txt", []byte("changed"), lab.Perms.File)).To(Succeed())
+			observe(w, nef.WatchWrite, "tree/a.txt")
+
+			Expect(os.Chmod(root+"/tree/a.txt", 0o600)).To(Succeed())
+			observe(w, nef.WatchChmod, "tree/a.txt")
+
+			Expect(fS.Rename("tree/b.txt", "tree/c.txt")).To(Succeed())
+			observe(w, nef.WatchRemove, "tree/b.txt")
+
+			Expect(fS.RemoveAll("tree")).To(Succeed())
+			observe(w, nef.WatchRemove, "tree")
+		})
+	})
+
+	When("given: invalid path", func() {
+		It("🧪 should: fail", func() {
+			_, err := fS.(nef.WatchFS).Watch("/tree")
+			Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+		})
+	})
+
+	When("given: missing path", func() {
+		It("🧪 should: fail", func() {
+			_, err := fS.(nef.WatchFS).Watch("missing")
+			Expect(err).To(MatchError(fs.ErrNotExist))
+		})
+	})
+
+	DescribeTable("WatchOp",
+		func(op nef.WatchOp, expected string) {
+			Expect(op.String()).To(Equal(expected))
+		},
+		Entry(nil, nef.WatchOp(0), "none"),
+		Entry(nil, nef.WatchCreate, "create"),
+		Entry(nil, nef.WatchRemove|nef.WatchRename, "remove|rename"),
+	)
+})
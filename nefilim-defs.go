@@ -1,8 +1,10 @@
 package nef
 
 import (
+	"context"
 	"io/fs"
 	"os"
+	"time"
 )
 
 // 📦 pkg: nef - contains local file system abstractions for navigation.
@@ -21,7 +23,7 @@ type (
 		Overwrite bool
 	}
 
-	// FSUtility provides the path calculator and relative-root flag used by the file system.
+	// FSUtility provides the path calculator, root and path conversions used by the file system.
 	FSUtility interface {
 		// Calc is the path calculator used by the FS
 		Calc() PathCalc
@@ -30,6 +32,19 @@ type (
 		// system should use paths that are relative to a root specified
 		// when created.
 		IsRelative() bool
+
+		// Root is the path the file system was created with; it is empty
+		// for an absolute file system.
+		Root() string
+
+		// ToAbsolute converts a path in the form accepted by the file
+		// system into an absolute path on the local file system.
+		ToAbsolute(name string) (string, error)
+
+		// ToRelative converts an absolute path into the form accepted by
+		// the file system. For a relative file system, an error is returned
+		// if the path does not reside within the root.
+		ToRelative(path string) (string, error)
 	}
 
 	// ExistsInFS contains methods that check the existence of file system items.
@@ -103,11 +118,13 @@ type (
 		Move(from, to string) error
 	}
 
-	// MoverFS extends MoveFS with existence checks and stat; used for move operations.
+	// MoverFS extends MoveFS with existence checks, stat and rename; used for
+	// move operations, which stat and rename items through it.
 	MoverFS interface {
 		MoveFS
 		ExistsInFS
 		fs.StatFS
+		RenameFS
 	}
 
 	// ChangeFS is a file system that supports changing an item (e.g. overwrite in place)
@@ -117,11 +134,27 @@ type (
 		Change(from, to string) error
 	}
 
-	// ChangerFS extends ChangeFS with existence checks and stat; used for change operations.
+	// ChangerFS extends ChangeFS with existence checks, stat and rename; used
+	// for change operations, which stat and rename items through it.
 	ChangerFS interface {
 		ChangeFS
 		ExistsInFS
 		fs.StatFS
+		RenameFS
+	}
+
+	// RoutedFS is a file system whose Move and Change can route the stat
+	// and rename calls they make internally through another file system,
+	// typically a decorator over it, so that the decorator can observe
+	// them (eg to inject faults). The paths passed to via are in the form
+	// of the routed file system. It is not part of WriterFS, so clients
+	// should detect it with a type assertion.
+	RoutedFS interface {
+		// MoveVia moves an item, as Move does, routing its calls through via
+		MoveVia(via MoverFS, from, to string) error
+		// ChangeVia changes an item, as Change does, routing its calls
+		// through via
+		ChangeVia(via ChangerFS, from, to string) error
 	}
 
 	// CopyFS is a file system that supports copying within the FS and copying another
@@ -147,6 +180,103 @@ type (
 		RemoveAll(path string) error
 	}
 
+	// ChangeTimesFS is a file system that supports changing the access and
+	// modification times of an item. It is not part of WriterFS, so clients
+	// should detect it with a type assertion.
+	ChangeTimesFS interface {
+		// Chtimes changes the access and modification times of the named
+		// item, similar to the Unix utime() or utimes() functions.
+		Chtimes(name string, atime, mtime time.Time) error
+	}
+
+	// SymlinkFS is a file system that supports symbolic links. It is not
+	// part of WriterFS, so clients should detect it with a type assertion.
+	SymlinkFS interface {
+		// Symlink creates newname as a symbolic link to oldname, which is
+		// recorded verbatim; a relative oldname is resolved relative to the
+		// directory containing the link.
+		Symlink(oldname, newname string) error
+		// ReadLink returns the destination of the named symbolic link
+		ReadLink(name string) (string, error)
+	}
+
+	// ContextFS is a file system whose long running operations can be
+	// cancelled. The operations stop at a safe point, once ctx is done, and
+	// return a CancelledError, which wraps ctx.Err() and records the progress
+	// made. It is not part of WriterFS, so clients should detect it with a
+	// type assertion, or use the package level functions of the same name
+	// (eg RemoveAllContext), which fall back to the non cancellable operation.
+	//
+	// Besides cancellation, ctx carries the options of the operations: the
+	// ProgressOptions attached with WithProgress, to which the operations
+	// report their progress. It is the only way they are passed.
+	ContextFS interface {
+		// MakeDirAllContext is the cancellable variant of MakeDirAll; it
+		// reports each directory created to the ProgressOptions of ctx.
+		MakeDirAllContext(ctx context.Context, name string, perm os.FileMode) error
+		// RemoveAllContext is the cancellable variant of RemoveAll; it
+		// reports each item removed to the ProgressOptions of ctx.
+		RemoveAllContext(ctx context.Context, path string) error
+		// CopyFSContext is the cancellable variant of CopyFS; it reports
+		// each item copied, and the bytes transferred, to the
+		// ProgressOptions of ctx.
+		CopyFSContext(ctx context.Context, dir string, fsys fs.FS) error
+	}
+
+	// CopyOptionsFS is a file system whose copy of a tree can be tuned with
+	// CopyOptions. It is not part of WriterFS, so clients should detect it
+	// with a type assertion, or use the package level function CopyFSWith,
+	// which falls back to CopyFSContext.
+	CopyOptionsFS interface {
+		// CopyFSWith is the variant of CopyFSContext that applies options;
+		// progress is reported to the ProgressOptions of ctx.
+		CopyFSWith(ctx context.Context, dir string, fsys fs.FS, options CopyOptions) error
+	}
+
+	// LockFS is a file system that supports advisory locks on items, keyed
+	// by path, which are honoured by co-operating processes. An item that
+	// does not exist is created, as an empty file, so that it can be
+	// locked. It is not part of WriterFS, so clients should detect it with
+	// a type assertion.
+	LockFS interface {
+		// Lock acquires a lock of mode on name, waiting until any
+		// conflicting lock is released.
+		Lock(name string, mode LockMode) (FileLock, error)
+		// TryLock acquires a lock of mode on name, without waiting; a
+		// LockedError is returned if a conflicting lock is held.
+		TryLock(name string, mode LockMode) (FileLock, error)
+		// LockTimeout acquires a lock of mode on name, waiting at most
+		// timeout; a LockedError is returned if a conflicting lock is still
+		// held.
+		LockTimeout(name string, mode LockMode, timeout time.Duration) (FileLock, error)
+	}
+
+	// FileLock is a lock acquired from a LockFS
+	FileLock interface {
+		// Unlock releases the lock
+		Unlock() error
+	}
+
+	// WatchFS is a file system that can watch items for changes. It is not
+	// part of ReaderFS, so clients should detect it with a type assertion.
+	WatchFS interface {
+		// Watch watches name and, if it is a directory, the tree beneath it,
+		// reporting events until the Watcher is closed.
+		Watch(name string) (Watcher, error)
+	}
+
+	// Watcher delivers the events of a watch. Both channels are closed
+	// once the watcher is closed.
+	Watcher interface {
+		// Events returns the channel on which events are delivered
+		Events() <-chan WatchEvent
+		// Errors returns the channel on which errors encountered while
+		// watching are delivered
+		Errors() <-chan error
+		// Close stops the watch
+		Close() error
+	}
+
 	// RenameFS is a file system that supports renaming an item from one path to another.
 	RenameFS interface {
 		Rename(from, to string) error
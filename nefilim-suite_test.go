@@ -56,6 +56,15 @@ type (
 		expect string
 	}
 
+	// resolveTE holds a single test case for Resolve
+	resolveTE struct {
+		given   string
+		should  string
+		path    string
+		expect  string
+		applied nef.Transformation
+	}
+
 	calcTE struct {
 		given  string
 		should string
@@ -172,6 +181,24 @@ func fakeAbsResolver(path string) (string, error) {
 	return path, nil
 }
 
+func fakeUserResolver(username string) (string, error) {
+	if username == "unknown" {
+		return "", fmt.Errorf("unknown user: %q", username)
+	}
+
+	return filepath.Join(filepath.Dir(fakeHome), username), nil
+}
+
+func fakeEnvResolver(key string) (string, bool) {
+	values := map[string]string{
+		"MUSIC": filepath.Join(fakeHome, "music"),
+		"EMPTY": "",
+	}
+	value, found := values[key]
+
+	return value, found
+}
+
 func errorHomeResolver() (string, error) {
 	return "", errors.New("failed to resolve home")
 }
@@ -220,3 +247,12 @@ func IsDifferentDirectoryChangeRejectionError(err error, reason string) {
 		fmt.Sprintf("not DifferentDirectoryChangeRejectionError, %q", reason),
 	)
 }
+
+// IsBinaryFsOpReason asserts that err is a binary fs op error with the reason specified.
+func IsBinaryFsOpReason(err error, reason nef.ErrorReason) {
+	var binaryErr *nef.BinaryOpError
+	Expect(errors.As(err, &binaryErr)).To(BeTrue(),
+		fmt.Sprintf("not BinaryOpError, %q", reason),
+	)
+	Expect(binaryErr.Reason).To(Equal(reason))
+}
@@ -0,0 +1,163 @@
+package nef
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lexical contains the path algorithms that are common to all the path
+// calculators, parameterised by the separator and comparison rules of
+// the particular calculator.
+type lexical struct {
+	sep    byte
+	clean  func(path string) string
+	volume func(path string) string
+	equal  func(a, b string) bool
+}
+
+func caseSensitive(a, b string) bool {
+	return a == b
+}
+
+func noVolume(_ string) string {
+	return ""
+}
+
+// rel is a port of filepath.Rel, that does not depend on the host
+// platform.
+func (l *lexical) rel(basePath, targetPath string) (string, error) {
+	baseVol := l.volume(basePath)
+	targetVol := l.volume(targetPath)
+	base := l.clean(basePath)
+	target := l.clean(targetPath)
+
+	if l.equal(target, base) {
+		return ".", nil
+	}
+
+	base = base[len(baseVol):]
+	target = target[len(targetVol):]
+
+	if base == "." {
+		base = ""
+	} else if base == "" && len(baseVol) > 2 { //nolint:mnd // drive letter volume is 2
+		// treat any targetPath within a UNC volume as relative to the
+		// volume root
+		base = string(l.sep)
+	}
+
+	baseRooted := base != "" && base[0] == l.sep
+	targetRooted := target != "" && target[0] == l.sep
+
+	if baseRooted != targetRooted || !l.equal(baseVol, targetVol) {
+		return "", newInvalidPathError("Rel", targetPath, ReasonNotRelative,
+			fmt.Errorf("can't make %q relative to %q", targetPath, basePath),
+		)
+	}
+
+	bl, tl := len(base), len(target)
+	var b0, bi, t0, ti int
+
+	for {
+		for bi < bl && base[bi] != l.sep {
+			bi++
+		}
+
+		for ti < tl && target[ti] != l.sep {
+			ti++
+		}
+
+		if !l.equal(target[t0:ti], base[b0:bi]) {
+			break
+		}
+
+		if bi < bl {
+			bi++
+		}
+
+		if ti < tl {
+			ti++
+		}
+
+		b0, t0 = bi, ti
+	}
+
+	if base[b0:bi] == ".." {
+		return "", newInvalidPathError("Rel", targetPath, ReasonNotRelative,
+			fmt.Errorf("can't make %q relative to %q", targetPath, basePath),
+		)
+	}
+
+	if b0 != bl {
+		// base elements left over, so climb out of them with ..
+		seps := strings.Count(base[b0:bl], string(l.sep))
+		parts := make([]string, 0, seps+2) //nolint:mnd // .. and target
+
+		for range seps + 1 {
+			parts = append(parts, "..")
+		}
+
+		if t0 != tl {
+			parts = append(parts, target[t0:])
+		}
+
+		return strings.Join(parts, string(l.sep)), nil
+	}
+
+	return target[t0:], nil
+}
+
+// commonAncestor returns the longest path that is an ancestor of, or
+// equal to, all of the paths. Unrooted paths with no element in common
+// share the ancestor ".", so it is returned for them (preceded by their
+// volume, if any). If the paths have no ancestor in common, eg they are
+// on different volumes, or some are rooted and some are not, or there are
+// no paths, then the empty string is returned.
+func (l *lexical) commonAncestor(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+
+	vol, rooted, common := l.elements(paths[0])
+
+	for _, path := range paths[1:] {
+		v, r, elements := l.elements(path)
+
+		if !l.equal(v, vol) || r != rooted {
+			return ""
+		}
+
+		i := 0
+		for i < len(common) && i < len(elements) && l.equal(common[i], elements[i]) {
+			i++
+		}
+
+		common = common[:i]
+	}
+
+	ancestor := strings.Join(common, string(l.sep))
+
+	if rooted {
+		return vol + string(l.sep) + ancestor
+	}
+
+	if ancestor == "" {
+		return vol + "."
+	}
+
+	return vol + ancestor
+}
+
+func (l *lexical) elements(path string) (vol string, rooted bool, elements []string) {
+	clean := l.clean(path)
+	vol = l.volume(clean)
+	rest := clean[len(vol):]
+	rooted = rest != "" && rest[0] == l.sep
+	rest = strings.TrimLeft(rest, string(l.sep))
+
+	if rest == "" || rest == "." {
+		return vol, rooted, []string{}
+	}
+
+	return vol, rooted, strings.Split(rest, string(l.sep))
+}
@@ -0,0 +1,111 @@
+package nef
+
+import (
+	"path"
+	"strings"
+)
+
+// PosixCalc implements PathCalc with posix path semantics, by purely
+// lexical processing. Unlike AbsoluteCalc, its behaviour does not depend
+// on the platform it is built for, so posix paths can be computed (and
+// tested) on any platform, including windows.
+type PosixCalc struct {
+}
+
+var (
+	_ PathCalc = (*PosixCalc)(nil)
+)
+
+// Base returns the last element of the path
+func (c *PosixCalc) Base(p string) string {
+	return path.Base(p)
+}
+
+// Clean returns the shortest path name equivalent to path
+// by purely lexical processing.
+func (c *PosixCalc) Clean(p string) string {
+	return path.Clean(p)
+}
+
+// Dir returns all but the last element of the path
+func (c *PosixCalc) Dir(p string) string {
+	return path.Dir(p)
+}
+
+// Elements returns the path split into segments by "/".
+func (c *PosixCalc) Elements(p string) []string {
+	if p == "" {
+		return []string{}
+	}
+
+	return strings.Split(p, separatorStr)
+}
+
+// Join joins any number of path elements into a single path
+func (c *PosixCalc) Join(elements ...string) string {
+	return path.Join(elements...)
+}
+
+// Split splits the path immediately following the final separator
+func (c *PosixCalc) Split(p string) (dir, file string) {
+	return path.Split(p)
+}
+
+// Truncate removes a trailing "/" from path, if present;
+// otherwise returns path unchanged.
+func (c *PosixCalc) Truncate(p string) string {
+	if p == "" {
+		return "."
+	}
+
+	if !strings.HasSuffix(p, separatorStr) {
+		return p
+	}
+
+	return p[:len(p)-1]
+}
+
+// Equal reports whether a and b refer to the same path, by comparing
+// their cleaned forms case-sensitively.
+func (c *PosixCalc) Equal(a, b string) bool {
+	return path.Clean(a) == path.Clean(b)
+}
+
+func (c *PosixCalc) lexical() *lexical {
+	return &lexical{
+		sep:    separator,
+		clean:  path.Clean,
+		volume: noVolume,
+		equal:  caseSensitive,
+	}
+}
+
+// Rel returns a path that is lexically equivalent to target when
+// joined to base.
+func (c *PosixCalc) Rel(base, target string) (string, error) {
+	return c.lexical().rel(base, target)
+}
+
+// IsAbs reports whether the path is absolute, ie begins with "/".
+func (c *PosixCalc) IsAbs(p string) bool {
+	return strings.HasPrefix(p, separatorStr)
+}
+
+// Ext returns the file name extension used by path.
+func (c *PosixCalc) Ext(p string) string {
+	return path.Ext(p)
+}
+
+// Match reports whether name matches the shell file name pattern, as
+// per path.Match.
+func (c *PosixCalc) Match(pattern, name string) (matched bool, err error) {
+	return path.Match(pattern, name)
+}
+
+// CommonAncestor returns the longest path that is an ancestor of (or
+// equal to) all the paths; "." is returned for relative paths with no
+// common element and the empty string if there is no ancestor, eg for a
+// mix of rooted and relative paths, or no paths.
+func (c *PosixCalc) CommonAncestor(paths ...string) string {
+	return c.lexical().commonAncestor(paths)
+}
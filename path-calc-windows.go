@@ -0,0 +1,457 @@
+package nef
+
+import (
+	"path"
+	"strings"
+)
+
+// WindowsCalc implements PathCalc with windows path semantics, by purely
+// lexical processing. Unlike AbsoluteCalc, its behaviour does not depend
+// on the platform it is built for, so windows paths can be computed
+// (and tested) on any platform. Drive letters (C:), UNC prefixes
+// (\\server\share) and device paths (\\?\, \\.\) are recognised as
+// volume names. Both '\' and '/' are accepted as separators, but paths
+// are always produced with '\'.
+type WindowsCalc struct {
+}
+
+var (
+	_ PathCalc = (*WindowsCalc)(nil)
+)
+
+const (
+	windowsSeparator = '\\'
+)
+
+var (
+	windowsSeparatorStr = string(windowsSeparator)
+)
+
+func isWindowsSeparator(c byte) bool {
+	return c == windowsSeparator || c == separator
+}
+
+// VolumeName returns the leading volume name, eg given "C:\foo\bar" it
+// returns "C:" and given "\\host\share\foo" it returns "\\host\share".
+func (c *WindowsCalc) VolumeName(path string) string {
+	return path[:c.volumeNameLen(path)]
+}
+
+func (c *WindowsCalc) volumeNameLen(path string) int {
+	switch {
+	case len(path) >= 2 && path[1] == ':' && isDriveLetter(path[0]):
+		return 2
+
+	case len(path) == 0 || !isWindowsSeparator(path[0]):
+		return 0
+
+	case hasPrefixFold(path, `\\.\UNC`):
+		return uncLen(path, len(`\\.\UNC\`))
+
+	case hasPrefixFold(path, `\\.`) ||
+		hasPrefixFold(path, `\\?`) ||
+		hasPrefixFold(path, `\??`):
+		if len(path) == 3 {
+			return 3
+		}
+
+		if !isWindowsSeparator(path[3]) {
+			return 0
+		}
+
+		if index := indexSeparator(path[4:]); index >= 0 {
+			return 4 + index
+		}
+
+		return len(path)
+
+	case len(path) >= 2 && isWindowsSeparator(path[1]):
+		return uncLen(path, 2)
+	}
+
+	return 0
+}
+
+func isDriveLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// hasPrefixFold tests whether path starts with prefix, ignoring case and
+// treating all separators as equivalent.
+func hasPrefixFold(path, prefix string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+
+	for i := range len(prefix) {
+		if isWindowsSeparator(prefix[i]) {
+			if !isWindowsSeparator(path[i]) {
+				return false
+			}
+		} else if !strings.EqualFold(path[i:i+1], prefix[i:i+1]) {
+			return false
+		}
+	}
+
+	if len(path) > len(prefix) && !isWindowsSeparator(path[len(prefix)]) {
+		return false
+	}
+
+	return true
+}
+
+// uncLen returns the length of the volume prefix of a UNC path.
+// prefixLen is the prefix prior to the start of the UNC host;
+// for example, for "//host/share", the prefixLen is len("//")==2.
+func uncLen(path string, prefixLen int) int {
+	count := 0
+
+	for i := prefixLen; i < len(path); i++ {
+		if isWindowsSeparator(path[i]) {
+			count++
+			if count == 2 {
+				return i
+			}
+		}
+	}
+
+	return len(path)
+}
+
+func indexSeparator(path string) int {
+	return strings.IndexFunc(path, func(r rune) bool {
+		return r < 0x80 && isWindowsSeparator(byte(r))
+	})
+}
+
+func lastIndexSeparator(path string) int {
+	return strings.LastIndexFunc(path, func(r rune) bool {
+		return r < 0x80 && isWindowsSeparator(byte(r))
+	})
+}
+
+// Base returns the last element of the path
+func (c *WindowsCalc) Base(path string) string {
+	if path == "" {
+		return "."
+	}
+
+	for len(path) > 0 && isWindowsSeparator(path[len(path)-1]) {
+		path = path[0 : len(path)-1]
+	}
+
+	path = path[len(c.VolumeName(path)):]
+
+	if index := lastIndexSeparator(path); index >= 0 {
+		path = path[index+1:]
+	}
+
+	if path == "" {
+		return windowsSeparatorStr
+	}
+
+	return path
+}
+
+// Clean returns the shortest path name equivalent to path
+// by purely lexical processing.
+func (c *WindowsCalc) Clean(path string) string {
+	original := path
+	volLen := c.volumeNameLen(path)
+	path = path[volLen:]
+
+	if path == "" {
+		if volLen > 1 && isWindowsSeparator(original[0]) && isWindowsSeparator(original[1]) {
+			// UNC volume name
+			return strings.ReplaceAll(original, separatorStr, windowsSeparatorStr)
+		}
+
+		return original + "."
+	}
+
+	rooted := isWindowsSeparator(path[0])
+	n := len(path)
+	out := &lexicalBuffer{
+		path:       path,
+		volAndPath: original,
+		volLen:     volLen,
+	}
+	r, dotdot := 0, 0
+
+	if rooted {
+		out.append(windowsSeparator)
+		r, dotdot = 1, 1
+	}
+
+	for r < n {
+		switch {
+		case isWindowsSeparator(path[r]):
+			r++
+
+		case path[r] == '.' && (r+1 == n || isWindowsSeparator(path[r+1])):
+			r++
+
+		case path[r] == '.' && path[r+1] == '.' && (r+2 == n || isWindowsSeparator(path[r+2])):
+			r += 2
+
+			switch {
+			case out.w > dotdot:
+				out.w--
+				for out.w > dotdot && !isWindowsSeparator(out.index(out.w)) {
+					out.w--
+				}
+
+			case !rooted:
+				if out.w > 0 {
+					out.append(windowsSeparator)
+				}
+				out.append('.')
+				out.append('.')
+				dotdot = out.w
+			}
+
+		default:
+			if rooted && out.w != 1 || !rooted && out.w != 0 {
+				out.append(windowsSeparator)
+			}
+
+			for ; r < n && !isWindowsSeparator(path[r]); r++ {
+				out.append(path[r])
+			}
+		}
+	}
+
+	if out.w == 0 {
+		out.append('.')
+	}
+
+	return strings.ReplaceAll(out.string(), separatorStr, windowsSeparatorStr)
+}
+
+// Dir returns all but the last element of the path
+func (c *WindowsCalc) Dir(path string) string {
+	vol := c.VolumeName(path)
+	i := len(path) - 1
+
+	for i >= len(vol) && !isWindowsSeparator(path[i]) {
+		i--
+	}
+
+	dir := c.Clean(path[len(vol) : i+1])
+
+	if dir == "." && len(vol) > 2 { //nolint:mnd // drive letter volume is 2
+		// must be UNC
+		return vol
+	}
+
+	return vol + dir
+}
+
+// Elements returns the path split into segments by the path separator.
+// The volume name, if present, is returned as the first element.
+func (c *WindowsCalc) Elements(path string) []string {
+	if path == "" {
+		return []string{}
+	}
+
+	vol := c.VolumeName(path)
+
+	if vol == "" {
+		return strings.Split(
+			strings.ReplaceAll(path, separatorStr, windowsSeparatorStr), windowsSeparatorStr,
+		)
+	}
+
+	rest := strings.FieldsFunc(path[len(vol):], func(r rune) bool {
+		return r < 0x80 && isWindowsSeparator(byte(r))
+	})
+
+	return append([]string{vol}, rest...)
+}
+
+// Join joins any number of path elements into a single path
+func (c *WindowsCalc) Join(elements ...string) string {
+	var (
+		builder  strings.Builder
+		lastChar byte
+	)
+
+	for _, e := range elements {
+		switch {
+		case builder.Len() == 0:
+			// add the first non-empty path element unchanged.
+
+		case isWindowsSeparator(lastChar):
+			// strip any leading separators from the next element to avoid
+			// creating a UNC path from non-UNC elements.
+			for len(e) > 0 && isWindowsSeparator(e[0]) {
+				e = e[1:]
+			}
+
+			// if the path is \ and the next element is ??, add an extra .\
+			// to create \.\?? rather than a root local device path.
+			if builder.Len() == 1 && strings.HasPrefix(e, "??") &&
+				(len(e) == len("??") || isWindowsSeparator(e[2])) {
+				builder.WriteString(`.\`)
+			}
+
+		case lastChar == ':':
+			// keep the path relative to the current directory on the drive,
+			// ie don't add a separator: Join(`C:`, `f`) = `C:f`
+
+		default:
+			builder.WriteByte(windowsSeparator)
+			lastChar = windowsSeparator
+		}
+
+		if e != "" {
+			builder.WriteString(e)
+			lastChar = e[len(e)-1]
+		}
+	}
+
+	if builder.Len() == 0 {
+		return ""
+	}
+
+	return c.Clean(builder.String())
+}
+
+// Split splits the path immediately following the final separator
+func (c *WindowsCalc) Split(path string) (dir, file string) {
+	vol := c.VolumeName(path)
+	i := len(path) - 1
+
+	for i >= len(vol) && !isWindowsSeparator(path[i]) {
+		i--
+	}
+
+	return path[:i+1], path[i+1:]
+}
+
+// Truncate removes a trailing path separator from path, if present;
+// otherwise returns path unchanged.
+func (c *WindowsCalc) Truncate(path string) string {
+	if path == "" {
+		return "."
+	}
+
+	if !isWindowsSeparator(path[len(path)-1]) {
+		return path
+	}
+
+	return path[:len(path)-1]
+}
+
+// Equal reports whether a and b refer to the same path, by comparing
+// their cleaned forms case-insensitively, as windows does.
+func (c *WindowsCalc) Equal(a, b string) bool {
+	return strings.EqualFold(c.Clean(a), c.Clean(b))
+}
+
+// lexicalBuffer is a lazily constructed path buffer, that only allocates
+// when the cleaned path differs from the original (as per filepath.Clean).
+type lexicalBuffer struct {
+	path       string
+	buf        []byte
+	w          int
+	volAndPath string
+	volLen     int
+}
+
+func (b *lexicalBuffer) index(i int) byte {
+	if b.buf != nil {
+		return b.buf[i]
+	}
+
+	return b.path[i]
+}
+
+func (b *lexicalBuffer) append(c byte) {
+	if b.buf == nil {
+		if b.w < len(b.path) && b.path[b.w] == c {
+			b.w++
+			return
+		}
+
+		b.buf = make([]byte, len(b.path))
+		copy(b.buf, b.path[:b.w])
+	}
+
+	b.buf[b.w] = c
+	b.w++
+}
+
+func (b *lexicalBuffer) string() string {
+	if b.buf == nil {
+		return b.volAndPath[:b.volLen+b.w]
+	}
+
+	return b.volAndPath[:b.volLen] + string(b.buf[:b.w])
+}
+
+func (c *WindowsCalc) lexical() *lexical {
+	return &lexical{
+		sep:    windowsSeparator,
+		clean:  c.Clean,
+		volume: c.VolumeName,
+		equal:  strings.EqualFold,
+	}
+}
+
+// Rel returns a path that is lexically equivalent to target when
+// joined to base. Path elements are compared case-insensitively.
+func (c *WindowsCalc) Rel(base, target string) (string, error) {
+	return c.lexical().rel(base, target)
+}
+
+// IsAbs reports whether the path is absolute, ie it has a volume name
+// and is rooted, or is a UNC/device path. Note that a path like \foo is
+// not absolute, because it is relative to the current drive.
+func (c *WindowsCalc) IsAbs(path string) bool {
+	length := c.volumeNameLen(path)
+
+	if length == 0 {
+		return false
+	}
+
+	if isWindowsSeparator(path[0]) && isWindowsSeparator(path[1]) {
+		// UNC or device path
+		return true
+	}
+
+	path = path[length:]
+
+	return path != "" && isWindowsSeparator(path[0])
+}
+
+// Ext returns the file name extension used by path.
+func (c *WindowsCalc) Ext(path string) string {
+	for i := len(path) - 1; i >= 0 && !isWindowsSeparator(path[i]); i-- {
+		if path[i] == '.' {
+			return path[i:]
+		}
+	}
+
+	return ""
+}
+
+// Match reports whether name matches the shell file name pattern. Both
+// separators are equivalent and, as on windows, the comparison is case
+// insensitive; '\' is a separator, so it can't be used to escape
+// meta characters.
+func (c *WindowsCalc) Match(pattern, name string) (matched bool, err error) {
+	normalise := func(p string) string {
+		return strings.ToLower(strings.ReplaceAll(p, windowsSeparatorStr, separatorStr))
+	}
+
+	return path.Match(normalise(pattern), normalise(name))
+}
+
+// CommonAncestor returns the longest path that is an ancestor of (or
+// equal to) all the paths, comparing elements case-insensitively; "." is
+// returned for relative paths with no common element and the empty
+// string if there is no ancestor, eg for paths on different volumes.
+func (c *WindowsCalc) CommonAncestor(paths ...string) string {
+	return c.lexical().commonAncestor(paths)
+}
@@ -0,0 +1,261 @@
+package nef_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+)
+
+type lexicalCalcTE struct {
+	calcTE
+	op     string
+	input  string
+	expect string
+}
+
+var _ = Describe("WindowsCalc", func() {
+	var calc *nef.WindowsCalc
+
+	BeforeEach(func() {
+		calc = &nef.WindowsCalc{}
+	})
+
+	DescribeTable("unary ops",
+		func(entry *lexicalCalcTE) {
+			var actual string
+
+			switch entry.op {
+			case "Base":
+				actual = calc.Base(entry.input)
+			case "Clean":
+				actual = calc.Clean(entry.input)
+			case "Dir":
+				actual = calc.Dir(entry.input)
+			case "Truncate":
+				actual = calc.Truncate(entry.input)
+			case "VolumeName":
+				actual = calc.VolumeName(entry.input)
+			}
+
+			Expect(actual).To(Equal(entry.expect),
+				fmt.Sprintf("💥 '%v' failed for input: '%v'", entry.op, entry.input),
+			)
+		},
+		func(entry *lexicalCalcTE) string {
+			return fmt.Sprintf("🧪 ===> %v, given: '%v', should: '%v'",
+				entry.op, entry.given, entry.should,
+			)
+		},
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "drive letter path", should: "return drive"},
+			op:     "VolumeName", input: `C:\foo\bar`, expect: `C:`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "UNC path", should: "return host and share"},
+			op:     "VolumeName", input: `\\host\share\foo`, expect: `\\host\share`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "device path", should: "return device prefix"},
+			op:     "VolumeName", input: `\\?\C:\foo`, expect: `\\?\C:`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "rooted path without volume", should: "return empty"},
+			op:     "VolumeName", input: `\foo\bar`, expect: ``,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "mixed separators", should: "return last element"},
+			op:     "Base", input: `C:\foo/bar.txt`, expect: `bar.txt`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "volume root", should: "return separator"},
+			op:     "Base", input: `C:\`, expect: `\`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "empty path", should: "return ."},
+			op:     "Base", input: ``, expect: `.`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "path with dots and forward slashes", should: "clean to backslashes"},
+			op:     "Clean", input: `C:/foo/./bar/../baz`, expect: `C:\foo\baz`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "parent beyond root", should: "stay at root"},
+			op:     "Clean", input: `C:\..\foo`, expect: `C:\foo`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "relative parent", should: "retain .."},
+			op:     "Clean", input: `..\foo\..\..\bar`, expect: `..\..\bar`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "UNC volume only", should: "return volume"},
+			op:     "Clean", input: `//host/share`, expect: `\\host\share`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "drive relative", should: "append ."},
+			op:     "Clean", input: `C:`, expect: `C:.`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "drive letter path", should: "return parent"},
+			op:     "Dir", input: `C:\foo\bar.txt`, expect: `C:\foo`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "UNC file at share root", should: "return volume"},
+			op:     "Dir", input: `\\host\share\foo.txt`, expect: `\\host\share\`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "single element", should: "return ."},
+			op:     "Dir", input: `foo.txt`, expect: `.`,
+		}),
+		Entry(nil, &lexicalCalcTE{
+			calcTE: calcTE{given: "trailing separator", should: "remove separator"},
+			op:     "Truncate", input: `C:\foo\`, expect: `C:\foo`,
+		}),
+	)
+
+	Context("Join", func() {
+		When("given: drive letter without separator", func() {
+			It("🧪 should: join relative to the drive", func() {
+				Expect(calc.Join(`C:`, `foo`, `bar`)).To(Equal(`C:foo\bar`))
+			})
+		})
+
+		When("given: UNC head", func() {
+			It("🧪 should: join onto the share", func() {
+				Expect(calc.Join(`\\host\share`, `foo`)).To(Equal(`\\host\share\foo`))
+			})
+		})
+
+		When("given: non UNC elements that resemble UNC", func() {
+			It("🧪 should: not create a UNC path", func() {
+				Expect(calc.Join(`\`, `\host`, `share`)).To(Equal(`\host\share`))
+			})
+		})
+
+		When("given: empty elements", func() {
+			It("🧪 should: ignore the empty elements", func() {
+				Expect(calc.Join(``, `foo`, ``, `bar`)).To(Equal(`foo\bar`))
+			})
+		})
+	})
+
+	Context("Split", func() {
+		It("🧪 should: split after the final separator", func() {
+			dir, file := calc.Split(`C:\foo\bar.txt`)
+			Expect(dir).To(Equal(`C:\foo\`))
+			Expect(file).To(Equal(`bar.txt`))
+		})
+	})
+
+	Context("Elements", func() {
+		When("given: path with volume", func() {
+			It("🧪 should: return volume as first element", func() {
+				Expect(calc.Elements(`C:\foo\bar`)).To(Equal([]string{`C:`, `foo`, `bar`}))
+			})
+		})
+
+		When("given: path without volume", func() {
+			It("🧪 should: split on either separator", func() {
+				Expect(calc.Elements(`foo/bar\baz`)).To(Equal([]string{`foo`, `bar`, `baz`}))
+			})
+		})
+	})
+
+	Context("Rel", func() {
+		When("given: paths that differ only in case", func() {
+			It("🧪 should: compare elements case-insensitively", func() {
+				actual, err := calc.Rel(`C:\Foo\Bar`, `c:/foo/baz/qux.txt`)
+				Expect(err).To(Succeed())
+				Expect(actual).To(Equal(`..\baz\qux.txt`))
+			})
+		})
+
+		When("given: paths on different volumes", func() {
+			It("🧪 should: return invalid path error", func() {
+				_, err := calc.Rel(`C:\foo`, `D:\foo`)
+				Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Context("IsAbs", func() {
+		It("🧪 should: require volume and root", func() {
+			Expect(calc.IsAbs(`C:\foo`)).To(BeTrue())
+			Expect(calc.IsAbs(`\\host\share\foo`)).To(BeTrue())
+			Expect(calc.IsAbs(`C:foo`)).To(BeFalse())
+			Expect(calc.IsAbs(`\foo`)).To(BeFalse())
+			Expect(calc.IsAbs(`foo`)).To(BeFalse())
+		})
+	})
+
+	Context("Ext", func() {
+		It("🧪 should: only consider final element", func() {
+			Expect(calc.Ext(`C:\foo.d\bar.txt`)).To(Equal(`.txt`))
+			Expect(calc.Ext(`C:\foo.d\bar`)).To(Equal(``))
+		})
+	})
+
+	Context("Match", func() {
+		It("🧪 should: match case-insensitively with either separator", func() {
+			matched, err := calc.Match(`foo\*.TXT`, `FOO/bar.txt`)
+			Expect(err).To(Succeed())
+			Expect(matched).To(BeTrue())
+		})
+	})
+
+	Context("CommonAncestor", func() {
+		It("🧪 should: compare elements case-insensitively", func() {
+			Expect(calc.CommonAncestor(`C:\Foo\Bar\a.txt`, `c:\foo\bar\b\c.txt`)).To(
+				Equal(`C:\Foo\Bar`),
+			)
+			Expect(calc.CommonAncestor(`C:\foo`, `D:\foo`)).To(Equal(``))
+		})
+
+		It("🧪 should: return . for relative paths with nothing in common", func() {
+			Expect(calc.CommonAncestor(`foo\bar`, `baz`)).To(Equal(`.`))
+			Expect(calc.CommonAncestor(`foo\bar`, `foo\baz`)).To(Equal(`foo`))
+		})
+	})
+
+	Context("Equal", func() {
+		It("🧪 should: compare case-insensitively", func() {
+			Expect(calc.Equal(`C:\Foo\BAR`, `c:/foo/bar/`)).To(BeTrue())
+			Expect(calc.Equal(`C:\foo`, `D:\foo`)).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("PosixCalc", func() {
+	var calc *nef.PosixCalc
+
+	BeforeEach(func() {
+		calc = &nef.PosixCalc{}
+	})
+
+	It("🧪 should: use posix semantics regardless of platform", func() {
+		Expect(calc.Join("/home", "root", "foo.txt")).To(Equal("/home/root/foo.txt"))
+		Expect(calc.Clean("/home/./root/../foo")).To(Equal("/home/foo"))
+		Expect(calc.Dir("/home/root/foo.txt")).To(Equal("/home/root"))
+		Expect(calc.Base(`C:\foo`)).To(Equal(`C:\foo`))
+		Expect(calc.Elements("/home/root")).To(Equal([]string{"", "home", "root"}))
+		Expect(calc.Truncate("/home/root/")).To(Equal("/home/root"))
+	})
+
+	It("🧪 should: compute relative paths and common ancestors", func() {
+		actual, err := calc.Rel("/home/root", "/home/other/foo.txt")
+		Expect(err).To(Succeed())
+		Expect(actual).To(Equal("../other/foo.txt"))
+		Expect(calc.CommonAncestor("/home/root/a", "/home/root/b")).To(Equal("/home/root"))
+		Expect(calc.CommonAncestor("home/a", "root/b")).To(Equal("."))
+		Expect(calc.CommonAncestor("/home/a", "home/a")).To(BeEmpty())
+		Expect(calc.IsAbs("/home")).To(BeTrue())
+		Expect(calc.Ext("/home/foo.tar.gz")).To(Equal(".gz"))
+	})
+
+	It("🧪 should: compare case-sensitively", func() {
+		Expect(calc.Equal("/home/root/", "/home/./root")).To(BeTrue())
+		Expect(calc.Equal("/home/Root", "/home/root")).To(BeFalse())
+	})
+})
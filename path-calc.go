@@ -1,8 +1,12 @@
 package nef
 
 import (
+	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
+
+	"github.com/snivilised/nefilim/internal/third/lo"
 )
 
 // PathCalc is the interface for path manipulation used by virtual file systems.
@@ -10,9 +14,14 @@ import (
 type PathCalc interface {
 	Base(path string) string
 	Clean(path string) string
+	CommonAncestor(paths ...string) string
 	Dir(name string) string
 	Elements(path string) []string
+	Ext(path string) string
+	IsAbs(path string) bool
 	Join(elements ...string) string
+	Match(pattern, name string) (matched bool, err error)
+	Rel(base, target string) (string, error)
 	Split(path string) (dir, file string)
 	Truncate(path string) string
 }
@@ -75,6 +84,47 @@ func (c *AbsoluteCalc) Elements(path string) []string {
 	return strings.Split(path, string(filepath.Separator))
 }
 
+func (c *AbsoluteCalc) lexical() *lexical {
+	return &lexical{
+		sep:    filepath.Separator,
+		clean:  filepath.Clean,
+		volume: filepath.VolumeName,
+		equal: lo.Ternary(runtime.GOOS == "windows",
+			strings.EqualFold, caseSensitive,
+		),
+	}
+}
+
+// Rel returns a path that is lexically equivalent to target when
+// joined to base, as per filepath.Rel.
+func (c *AbsoluteCalc) Rel(base, target string) (string, error) {
+	return c.lexical().rel(base, target)
+}
+
+// IsAbs reports whether the path is absolute, as per filepath.IsAbs.
+func (c *AbsoluteCalc) IsAbs(path string) bool {
+	return filepath.IsAbs(path)
+}
+
+// Ext returns the file name extension used by path.
+func (c *AbsoluteCalc) Ext(path string) string {
+	return filepath.Ext(path)
+}
+
+// Match reports whether name matches the shell file name pattern, as
+// per filepath.Match.
+func (c *AbsoluteCalc) Match(pattern, name string) (matched bool, err error) {
+	return filepath.Match(pattern, name)
+}
+
+// CommonAncestor returns the longest path that is an ancestor of (or
+// equal to) all the paths; "." is returned for relative paths with no
+// common element and the empty string if there is no ancestor, eg for a
+// mix of rooted and relative paths, or no paths.
+func (c *AbsoluteCalc) CommonAncestor(paths ...string) string {
+	return c.lexical().commonAncestor(paths)
+}
+
 const (
 	separator = '/'
 )
@@ -84,8 +134,11 @@ var (
 )
 
 // RelativeCalc implements PathCalc using "/" as the separator, for use with virtual FS paths.
-// Root can be set for context but is not used by the path operations.
+// The path operations are purely lexical and do not depend on Root.
 type RelativeCalc struct {
+	// Root is the root of the file system the calculator belongs to; use
+	// FSUtility.ToAbsolute and FSUtility.ToRelative to convert paths
+	// between the file system and the local file system.
 	Root string
 }
 
@@ -171,3 +224,43 @@ func (c *RelativeCalc) Truncate(path string) string {
 
 	return path[:strings.LastIndex(path, separatorStr)]
 }
+
+func (c *RelativeCalc) lexical() *lexical {
+	return &lexical{
+		sep:    separator,
+		clean:  path.Clean,
+		volume: noVolume,
+		equal:  caseSensitive,
+	}
+}
+
+// Rel returns a path that is lexically equivalent to target when
+// joined to base, using "/" as the separator.
+func (c *RelativeCalc) Rel(base, target string) (string, error) {
+	return c.lexical().rel(base, target)
+}
+
+// IsAbs reports whether the path is rooted, ie begins with "/". Note
+// that a rooted path is not a valid path for a relative file system.
+func (c *RelativeCalc) IsAbs(path string) bool {
+	return strings.HasPrefix(path, separatorStr)
+}
+
+// Ext returns the file name extension used by path.
+func (c *RelativeCalc) Ext(p string) string {
+	return path.Ext(p)
+}
+
+// Match reports whether name matches the shell file name pattern, as
+// per path.Match.
+func (c *RelativeCalc) Match(pattern, name string) (matched bool, err error) {
+	return path.Match(pattern, name)
+}
+
+// CommonAncestor returns the longest path that is an ancestor of (or
+// equal to) all the paths; "." is returned for relative paths with no
+// common element and the empty string if there is no ancestor, eg for a
+// mix of rooted and relative paths, or no paths.
+func (c *RelativeCalc) CommonAncestor(paths ...string) string {
+	return c.lexical().commonAncestor(paths)
+}
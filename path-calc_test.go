@@ -468,4 +468,240 @@ var _ = Describe("PathCalc", func() {
 			},
 		}),
 	)
+
+	if runtime.GOOS != "windows" {
+		DescribeTable("Rel",
+			func(entry *calcVariadicToOneTE) {
+				calcs := PathCalcs{
+					CalcTypeAbsolute: &nef.AbsoluteCalc{},
+					CalcTypeRelative: &nef.RelativeCalc{
+						Root: static.root,
+					},
+				}
+
+				for ct, calc := range calcs {
+					actual, err := calc.Rel(entry.input[0], entry.input[1])
+					Expect(err).To(Succeed())
+					Expect(actual).To(Equal(entry.expect[ct]),
+						fmt.Sprintf("💥 'Rel' failed for input: '%v' (CALC:%v)", entry.input, ct),
+					)
+				}
+			},
+			func(entry *calcVariadicToOneTE) string {
+				return fmt.Sprintf("🧪 ===> given: '%v', should: '%v'",
+					entry.given, entry.should,
+				)
+			},
+			Entry(nil, &calcVariadicToOneTE{
+				calcTE: calcTE{
+					given:  "base and target are the same",
+					should: "return .",
+				},
+				input: []string{static.foobar, "foo/./bar/"},
+				expect: map[CalcType]string{
+					CalcTypeAbsolute: ".",
+					CalcTypeRelative: ".",
+				},
+			}),
+			Entry(nil, &calcVariadicToOneTE{
+				calcTE: calcTE{
+					given:  "target is descendant of base",
+					should: "return remainder of target",
+				},
+				input: []string{"foo", static.foobarbaz},
+				expect: map[CalcType]string{
+					CalcTypeAbsolute: "bar/baz.txt",
+					CalcTypeRelative: "bar/baz.txt",
+				},
+			}),
+			Entry(nil, &calcVariadicToOneTE{
+				calcTE: calcTE{
+					given:  "target is sibling of base",
+					should: "climb out of base",
+				},
+				input: []string{static.foobar, "foo/baz/qux.txt"},
+				expect: map[CalcType]string{
+					CalcTypeAbsolute: "../baz/qux.txt",
+					CalcTypeRelative: "../baz/qux.txt",
+				},
+			}),
+			Entry(nil, &calcVariadicToOneTE{
+				calcTE: calcTE{
+					given:  "target is ancestor of base",
+					should: "return only parent references",
+				},
+				input: []string{"foo/bar/baz", "foo"},
+				expect: map[CalcType]string{
+					CalcTypeAbsolute: "../..",
+					CalcTypeRelative: "../..",
+				},
+			}),
+		)
+
+		Context("Rel", func() {
+			When("given: rooted base and unrooted target", func() {
+				It("🧪 should: return invalid path error", func() {
+					for _, calc := range []nef.PathCalc{&nef.AbsoluteCalc{}, &nef.RelativeCalc{}} {
+						_, err := calc.Rel("/foo", "bar")
+						Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+					}
+				})
+			})
+		})
+
+		DescribeTable("CommonAncestor",
+			func(entry *calcVariadicToOneTE) {
+				calcs := PathCalcs{
+					CalcTypeAbsolute: &nef.AbsoluteCalc{},
+					CalcTypeRelative: &nef.RelativeCalc{
+						Root: static.root,
+					},
+				}
+
+				for ct, calc := range calcs {
+					Expect(calc.CommonAncestor(entry.input...)).To(Equal(entry.expect[ct]),
+						fmt.Sprintf("💥 'CommonAncestor' failed for input: '%v' (CALC:%v)", entry.input, ct),
+					)
+				}
+			},
+			func(entry *calcVariadicToOneTE) string {
+				return fmt.Sprintf("🧪 ===> given: '%v', should: '%v'",
+					entry.given, entry.should,
+				)
+			},
+			Entry(nil, &calcVariadicToOneTE{
+				calcTE: calcTE{
+					given:  "no paths",
+					should: "return empty",
+				},
+				input: []string{},
+				expect: map[CalcType]string{
+					CalcTypeAbsolute: "",
+					CalcTypeRelative: "",
+				},
+			}),
+			Entry(nil, &calcVariadicToOneTE{
+				calcTE: calcTE{
+					given:  "paths with common prefix",
+					should: "return common ancestor",
+				},
+				input: []string{static.foobarbaz, "foo/bar/qux/quux.txt", "foo/bar"},
+				expect: map[CalcType]string{
+					CalcTypeAbsolute: static.foobar,
+					CalcTypeRelative: static.foobar,
+				},
+			}),
+			Entry(nil, &calcVariadicToOneTE{
+				calcTE: calcTE{
+					given:  "paths with partially matching element names",
+					should: "only match whole elements",
+				},
+				input: []string{"foo/barbaz", "foo/bar"},
+				expect: map[CalcType]string{
+					CalcTypeAbsolute: "foo",
+					CalcTypeRelative: "foo",
+				},
+			}),
+			Entry(nil, &calcVariadicToOneTE{
+				calcTE: calcTE{
+					given:  "relative paths with nothing in common",
+					should: "return .",
+				},
+				input: []string{"foo/bar", "baz"},
+				expect: map[CalcType]string{
+					CalcTypeAbsolute: ".",
+					CalcTypeRelative: ".",
+				},
+			}),
+			Entry(nil, &calcVariadicToOneTE{
+				calcTE: calcTE{
+					given:  "rooted paths with nothing in common",
+					should: "return root",
+				},
+				input: []string{"/foo/bar", "/baz"},
+				expect: map[CalcType]string{
+					CalcTypeAbsolute: "/",
+					CalcTypeRelative: "/",
+				},
+			}),
+			Entry(nil, &calcVariadicToOneTE{
+				calcTE: calcTE{
+					given:  "rooted and unrooted paths",
+					should: "return empty",
+				},
+				input: []string{"/foo/bar", "foo/bar"},
+				expect: map[CalcType]string{
+					CalcTypeAbsolute: "",
+					CalcTypeRelative: "",
+				},
+			}),
+		)
+
+		Context("IsAbs", func() {
+			It("🧪 should: identify rooted paths", func() {
+				for _, calc := range []nef.PathCalc{&nef.AbsoluteCalc{}, &nef.RelativeCalc{}} {
+					Expect(calc.IsAbs("/foo/bar")).To(BeTrue())
+					Expect(calc.IsAbs(static.foobar)).To(BeFalse())
+				}
+			})
+		})
+	}
+
+	DescribeTable("Ext",
+		func(entry *genericCalcTE[string, string]) {
+			calcs := PathCalcs{
+				CalcTypeAbsolute: &nef.AbsoluteCalc{},
+				CalcTypeRelative: &nef.RelativeCalc{
+					Root: static.root,
+				},
+			}
+
+			for ct, calc := range calcs {
+				Expect(calc.Ext(entry.input)).To(Equal(entry.expect[ct]),
+					fmt.Sprintf("💥 'Ext' failed for input: '%v' (CALC:%v)", entry.input, ct),
+				)
+			}
+		},
+		func(entry *genericCalcTE[string, string]) string {
+			return fmt.Sprintf("🧪 ===> given: '%v', should: '%v'",
+				entry.given, entry.should,
+			)
+		},
+		Entry(nil, &genericCalcTE[string, string]{
+			calcTE: calcTE{
+				given:  "path with extension",
+				should: "return extension",
+			},
+			input: static.foobarbaz,
+			expect: map[CalcType]string{
+				CalcTypeAbsolute: ".txt",
+				CalcTypeRelative: ".txt",
+			},
+		}),
+		Entry(nil, &genericCalcTE[string, string]{
+			calcTE: calcTE{
+				given:  "dot in parent directory only",
+				should: "return empty",
+			},
+			input: "foo.d/bar",
+			expect: map[CalcType]string{
+				CalcTypeAbsolute: "",
+				CalcTypeRelative: "",
+			},
+		}),
+	)
+
+	Context("Match", func() {
+		It("🧪 should: match shell pattern", func() {
+			for _, calc := range []nef.PathCalc{&nef.AbsoluteCalc{}, &nef.RelativeCalc{}} {
+				matched, err := calc.Match("*.txt", static.foo)
+				Expect(err).To(Succeed())
+				Expect(matched).To(BeTrue())
+
+				matched, err = calc.Match("*.txt", static.foobarbaz)
+				Expect(err).To(Succeed())
+				Expect(matched).To(BeFalse(), "* should not match separator")
+			}
+		})
+	})
 })
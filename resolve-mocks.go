@@ -19,11 +19,35 @@ func (f HomeUserFunc) Home() (string, error) {
 	return f()
 }
 
+// UserHomeFunc signature of function used to obtain the home directory
+// of the named user.
+type UserHomeFunc func(username string) (string, error)
+
+// UserHome function invoker, allows a function to be used in place where
+// an instance of an interface would be expected.
+func (f UserHomeFunc) UserHome(username string) (string, error) {
+	return f(username)
+}
+
+// LookupEnvFunc signature of function used to obtain the value of an
+// environment variable.
+type LookupEnvFunc func(key string) (string, bool)
+
+// LookupEnv function invoker, allows a function to be used in place where
+// an instance of an interface would be expected.
+func (f LookupEnvFunc) LookupEnv(key string) (string, bool) {
+	return f(key)
+}
+
 // ResolveMocks, used to override the internal functions used
-// to resolve the home path (os.UserHomeDir) and the abs path
-// (filepath.Abs). In normal usage, these do not need to be provided,
-// just used for testing purposes.
+// to resolve the home path (os.UserHomeDir), the abs path
+// (filepath.Abs), the home path of a named user (user.Lookup) and
+// environment variables (os.LookupEnv). In normal usage, these do not
+// need to be provided, just used for testing purposes. Any function
+// not provided falls back to its default.
 type ResolveMocks struct {
 	HomeFunc HomeUserFunc
 	AbsFunc  AbsFunc
+	UserFunc UserHomeFunc
+	EnvFunc  LookupEnvFunc
 }
@@ -1,8 +1,11 @@
 package nef
 
 import (
+	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strings"
 
 	"github.com/snivilised/nefilim/internal/third/lo"
 )
@@ -10,8 +13,13 @@ import (
 // ResolvePath performs 2 forms of path resolution. The first is resolving a
 // home path reference, via the ~ character; ~ is replaced by the user's
 // home path. The second resolves ./ or ../ relative path. The overrides
-// do not need to be provided.
+// do not need to be provided. If resolution fails, the path is returned
+// unmodified; use Resolve when the failure needs to be reported.
 func ResolvePath(path string, mocks ...ResolveMocks) string {
+	if path == "" {
+		return path
+	}
+
 	result := path
 
 	if len(mocks) > 0 {
@@ -53,3 +61,241 @@ func ResolvePath(path string, mocks ...ResolveMocks) string {
 
 	return result
 }
+
+// Transformation is a bit flag that denotes a transformation applied to
+// a path by Resolve.
+type Transformation uint8
+
+const (
+	// TransformEnv environment variable references were expanded
+	TransformEnv Transformation = 1 << iota
+	// TransformHome a leading ~ was replaced by the current user's home
+	TransformHome
+	// TransformUserHome a leading ~user was replaced by that user's home
+	TransformUserHome
+	// TransformAbs a relative path was made absolute
+	TransformAbs
+	// TransformClean the path was changed by cleaning, including the
+	// cleaning implied by home expansion and making the path absolute
+	TransformClean
+	// TransformSymlinks the path was changed by evaluating symbolic links
+	TransformSymlinks
+)
+
+var transformationNames = []struct {
+	flag Transformation
+	name string
+}{
+	{TransformEnv, "env"},
+	{TransformHome, "home"},
+	{TransformUserHome, "user-home"},
+	{TransformAbs, "abs"},
+	{TransformClean, "clean"},
+	{TransformSymlinks, "symlinks"},
+}
+
+// Has reports whether all the transformations in flag are present
+func (t Transformation) Has(flag Transformation) bool {
+	return t&flag == flag
+}
+
+// String returns the names of the transformations, separated by '|'
+func (t Transformation) String() string {
+	names := make([]string, 0, len(transformationNames))
+
+	for _, tn := range transformationNames {
+		if t.Has(tn.flag) {
+			names = append(names, tn.name)
+		}
+	}
+
+	if len(names) == 0 {
+		return "none"
+	}
+
+	return strings.Join(names, "|")
+}
+
+// ResolveOptions controls the behaviour of Resolve. The zero value expands
+// environment variables and home references, makes the path absolute and
+// cleans it, but does not evaluate symbolic links.
+type ResolveOptions struct {
+	// NoEnv disables the expansion of $VAR and ${VAR} references
+	NoEnv bool
+	// EvalSymlinks evaluates any symbolic links in the resolved path,
+	// which requires the path to exist
+	EvalSymlinks bool
+	// Mocks overrides the functions used to resolve the path
+	Mocks ResolveMocks
+}
+
+// Resolution is the result of a successful Resolve
+type Resolution struct {
+	// Original is the path as it was passed to Resolve
+	Original string
+	// Path is the resolved absolute path
+	Path string
+	// Applied denotes the transformations applied to Original to
+	// produce Path
+	Applied Transformation
+}
+
+// Resolve resolves path into a clean absolute path. Environment variable
+// references ($VAR and ${VAR}) are expanded first, then a leading ~ or
+// ~user is replaced by the corresponding home directory, then a relative
+// path is made absolute and cleaned and finally, if requested, symbolic
+// links are evaluated. Unlike ResolvePath, failure is reported as an
+// invalid path error, whose reason is ReasonUnresolved for resolution
+// failures.
+func Resolve(path string, opts ResolveOptions) (Resolution, error) {
+	resolution := Resolution{
+		Original: path,
+	}
+
+	if path == "" {
+		return resolution, NewInvalidPathError("Resolve", path)
+	}
+
+	r := newResolver(&opts.Mocks)
+	result := path
+
+	if !opts.NoEnv {
+		expanded, err := r.expand(result)
+		if err != nil {
+			return resolution, newInvalidPathError("Resolve", path, ReasonUnresolved, err)
+		}
+
+		if expanded != result {
+			resolution.Applied |= TransformEnv
+			result = expanded
+		}
+	}
+
+	if result == "" {
+		return resolution, NewInvalidPathError("Resolve", path)
+	}
+
+	// joining the home directory and making the path absolute both clean
+	// it, so whether cleaning changes the path is decided beforehand.
+	unclean := filepath.Clean(result) != result
+
+	home, applied, err := r.expandHome(result)
+	if err != nil {
+		return resolution, newInvalidPathError("Resolve", path, ReasonUnresolved, err)
+	}
+
+	resolution.Applied |= applied
+	result = home
+
+	if !filepath.IsAbs(result) {
+		abs, err := r.absolute(result)
+		if err != nil {
+			return resolution, newInvalidPathError("Resolve", path, ReasonUnresolved, err)
+		}
+
+		resolution.Applied |= TransformAbs
+		result = abs
+	}
+
+	if clean := filepath.Clean(result); clean != result || unclean {
+		resolution.Applied |= TransformClean
+		result = clean
+	}
+
+	if opts.EvalSymlinks {
+		evaluated, err := filepath.EvalSymlinks(result)
+		if err != nil {
+			return resolution, newInvalidPathError("Resolve", path, ReasonUnresolved, err)
+		}
+
+		if evaluated != result {
+			resolution.Applied |= TransformSymlinks
+			result = evaluated
+		}
+	}
+
+	resolution.Path = result
+
+	return resolution, nil
+}
+
+// resolver contains the functions used by Resolve, which are either the
+// mocks or their defaults.
+type resolver struct {
+	homeDir   HomeUserFunc
+	absolute  AbsFunc
+	userHome  UserHomeFunc
+	lookupEnv LookupEnvFunc
+}
+
+func newResolver(mocks *ResolveMocks) *resolver {
+	return &resolver{
+		homeDir:   lo.Ternary(mocks.HomeFunc != nil, mocks.HomeFunc, os.UserHomeDir),
+		absolute:  lo.Ternary(mocks.AbsFunc != nil, mocks.AbsFunc, filepath.Abs),
+		userHome:  lo.Ternary(mocks.UserFunc != nil, mocks.UserFunc, lookupUserHome),
+		lookupEnv: lo.Ternary(mocks.EnvFunc != nil, mocks.EnvFunc, os.LookupEnv),
+	}
+}
+
+func lookupUserHome(username string) (string, error) {
+	u, err := user.Lookup(username)
+	if err != nil {
+		return "", err
+	}
+
+	return u.HomeDir, nil
+}
+
+// expand expands environment variable references, returning an error
+// that names all the variables that are not defined.
+func (r *resolver) expand(path string) (string, error) {
+	var undefined []string
+
+	expanded := os.Expand(path, func(key string) string {
+		value, found := r.lookupEnv(key)
+		if !found {
+			undefined = append(undefined, key)
+		}
+
+		return value
+	})
+
+	if len(undefined) > 0 {
+		return "", fmt.Errorf("undefined environment variable(s): %v",
+			strings.Join(undefined, ", "),
+		)
+	}
+
+	return expanded, nil
+}
+
+// expandHome replaces a leading ~ or ~user with the corresponding home
+// directory; a ~ that is not followed by a separator or the end of the
+// path denotes a named user.
+func (r *resolver) expandHome(path string) (string, Transformation, error) {
+	if path[0] != '~' {
+		return path, 0, nil
+	}
+
+	end := strings.IndexFunc(path, func(c rune) bool {
+		return c == separator || c == filepath.Separator
+	})
+	end = lo.Ternary(end < 0, len(path), end)
+	username, rest := path[1:end], path[end:]
+
+	if username == "" {
+		home, err := r.homeDir()
+		if err != nil {
+			return "", 0, err
+		}
+
+		return filepath.Join(home, rest), TransformHome, nil
+	}
+
+	home, err := r.userHome(username)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return filepath.Join(home, rest), TransformUserHome, nil
+}
@@ -2,13 +2,16 @@ package nef_test
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
 )
 
 var _ = Describe("ResolvePath", Ordered, func() {
@@ -125,5 +128,198 @@ var _ = Describe("ResolvePath", Ordered, func() {
 				nef.ResolvePath("../..")
 			})
 		})
+
+		Context("and: empty path", func() {
+			It("🧪 should: return empty path", func() {
+				Expect(nef.ResolvePath("")).To(BeEmpty())
+			})
+		})
+	})
+})
+
+var _ = Describe("Resolve", func() {
+	var mocks nef.ResolveMocks
+
+	BeforeEach(func() {
+		mocks = nef.ResolveMocks{
+			HomeFunc: fakeHomeResolver,
+			AbsFunc:  fakeAbsResolver,
+			UserFunc: fakeUserResolver,
+			EnvFunc:  fakeEnvResolver,
+		}
+	})
+
+	if runtime.GOOS != "windows" {
+		DescribeTable("transformations",
+			func(entry *resolveTE) {
+				resolution, err := nef.Resolve(entry.path, nef.ResolveOptions{
+					Mocks: mocks,
+				})
+				Expect(err).To(Succeed())
+				Expect(resolution.Original).To(Equal(entry.path))
+				Expect(resolution.Path).To(Equal(entry.expect))
+				Expect(resolution.Applied).To(Equal(entry.applied), resolution.Applied.String())
+			},
+			func(entry *resolveTE) string {
+				return fmt.Sprintf("🧪 ===> given: '%v', should: '%v'", entry.given, entry.should)
+			},
+			Entry(nil, &resolveTE{
+				given:  "clean absolute path",
+				should: "return path unmodified",
+				path:   "/home/rabbitweed/foo",
+				expect: "/home/rabbitweed/foo",
+			}),
+			Entry(nil, &resolveTE{
+				given:   "unclean absolute path",
+				should:  "clean path",
+				path:    "/home/rabbitweed/./foo/",
+				expect:  "/home/rabbitweed/foo",
+				applied: nef.TransformClean,
+			}),
+			Entry(nil, &resolveTE{
+				given:   "path contains $VAR",
+				should:  "expand variable",
+				path:    "$MUSIC/foo",
+				expect:  "/home/rabbitweed/music/foo",
+				applied: nef.TransformEnv,
+			}),
+			Entry(nil, &resolveTE{
+				given:   "path contains ${VAR}",
+				should:  "expand variable",
+				path:    "${MUSIC}/foo",
+				expect:  "/home/rabbitweed/music/foo",
+				applied: nef.TransformEnv,
+			}),
+			Entry(nil, &resolveTE{
+				given:   "path contains leading ~",
+				should:  "replace ~ with home path",
+				path:    "~/foo",
+				expect:  "/home/rabbitweed/foo",
+				applied: nef.TransformHome,
+			}),
+			Entry(nil, &resolveTE{
+				given:   "path is ~",
+				should:  "return home path",
+				path:    "~",
+				expect:  "/home/rabbitweed",
+				applied: nef.TransformHome,
+			}),
+			Entry(nil, &resolveTE{
+				given:   "path contains leading ~user",
+				should:  "replace ~user with user's home path",
+				path:    "~marina/foo",
+				expect:  "/home/marina/foo",
+				applied: nef.TransformUserHome,
+			}),
+			Entry(nil, &resolveTE{
+				given:   "path is relative to cwd",
+				should:  "make path absolute",
+				path:    "./foo",
+				expect:  "/home/rabbitweed/music/xpander/foo",
+				applied: nef.TransformAbs | nef.TransformClean,
+			}),
+			Entry(nil, &resolveTE{
+				given:   "unclean relative path",
+				should:  "make path absolute and clean it",
+				path:    "./foo/../bar",
+				expect:  "/home/rabbitweed/music/xpander/bar",
+				applied: nef.TransformAbs | nef.TransformClean,
+			}),
+			Entry(nil, &resolveTE{
+				given:   "unclean path with leading ~",
+				should:  "replace ~ with home path and clean it",
+				path:    "~/foo/../bar",
+				expect:  "/home/rabbitweed/bar",
+				applied: nef.TransformHome | nef.TransformClean,
+			}),
+			Entry(nil, &resolveTE{
+				given:   "relative path with variable",
+				should:  "expand variable and make path absolute",
+				path:    "./$EMPTY/foo",
+				expect:  "/home/rabbitweed/music/xpander/foo",
+				applied: nef.TransformEnv | nef.TransformAbs | nef.TransformClean,
+			}),
+		)
+	}
+
+	DescribeTable("errors",
+		func(entry *resolveTE) {
+			_, err := nef.Resolve(entry.path, nef.ResolveOptions{
+				Mocks: mocks,
+			})
+			Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+
+			reason, _ := nef.ReasonOf(err)
+			Expect(string(reason)).To(Equal(entry.expect))
+		},
+		func(entry *resolveTE) string {
+			return fmt.Sprintf("🧪 ===> given: '%v', should: '%v'", entry.given, entry.should)
+		},
+		Entry(nil, &resolveTE{
+			given:  "empty path",
+			should: "return invalid path error",
+			path:   "",
+			expect: string(nef.ReasonInvalidPath),
+		}),
+		Entry(nil, &resolveTE{
+			given:  "path expands to empty",
+			should: "return invalid path error",
+			path:   "$EMPTY",
+			expect: string(nef.ReasonInvalidPath),
+		}),
+		Entry(nil, &resolveTE{
+			given:  "undefined variable",
+			should: "return unresolved error",
+			path:   "$UNDEFINED/foo",
+			expect: string(nef.ReasonUnresolved),
+		}),
+		Entry(nil, &resolveTE{
+			given:  "unknown user",
+			should: "return unresolved error",
+			path:   "~unknown/foo",
+			expect: string(nef.ReasonUnresolved),
+		}),
+	)
+
+	When("given: env expansion disabled", func() {
+		It("🧪 should: not expand variable", func() {
+			resolution, err := nef.Resolve("/$MUSIC", nef.ResolveOptions{
+				NoEnv: true,
+				Mocks: mocks,
+			})
+			Expect(err).To(Succeed())
+			Expect(resolution.Path).To(Equal(filepath.Clean("/$MUSIC")))
+			Expect(resolution.Applied.Has(nef.TransformEnv)).To(BeFalse())
+		})
+	})
+
+	When("given: path containing symbolic link", func() {
+		It("🧪 should: evaluate link", func() {
+			if runtime.GOOS == "windows" {
+				Skip("symbolic links require elevated privileges on windows")
+			}
+
+			dir, err := filepath.EvalSymlinks(GinkgoT().TempDir())
+			Expect(err).To(Succeed())
+			target := filepath.Join(dir, "target")
+			link := filepath.Join(dir, "link")
+			Expect(os.Mkdir(target, lab.Perms.Dir)).To(Succeed())
+			Expect(os.Symlink(target, link)).To(Succeed())
+
+			resolution, err := nef.Resolve(link, nef.ResolveOptions{
+				EvalSymlinks: true,
+			})
+			Expect(err).To(Succeed())
+			Expect(resolution.Path).To(Equal(target))
+			Expect(resolution.Applied).To(Equal(nef.TransformSymlinks))
+		})
+	})
+
+	When("No overrides provided", func() {
+		It("🧪 should: resolve against the real environment", func() {
+			resolution, err := nef.Resolve("~", nef.ResolveOptions{})
+			Expect(err).To(Succeed())
+			Expect(filepath.IsAbs(resolution.Path)).To(BeTrue())
+		})
 	})
 })
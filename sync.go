@@ -0,0 +1,478 @@
+package nef
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/snivilised/nefilim/internal/third/lo"
+)
+
+// SyncOptions controls the behaviour of Sync
+type SyncOptions struct {
+	// From is the directory in src to be synchronised, in the form accepted
+	// by src; defaults to the root of a relative file system
+	From string
+	// To is the directory in dst that receives the tree, in the form
+	// accepted by dst; defaults to the root of a relative file system
+	To string
+	// Checksum decides whether a file has changed by comparing digests
+	// of the content, rather than size and modification time
+	Checksum bool
+	// Algorithm is the hash algorithm used when Checksum is set; defaults
+	// to HashSHA256
+	Algorithm HashAlgorithm
+	// Delete removes items in the destination that are not present in the
+	// source, unless they are excluded
+	Delete bool
+	// Include, if not empty, restricts the files synchronised to those
+	// matching at least one of these patterns
+	Include []string
+	// Exclude prevents matching files and directories from being
+	// synchronised or deleted
+	Exclude []string
+	// DryRun reports the actions that would be performed, without
+	// modifying the destination
+	DryRun bool
+}
+
+// SyncReport describes the actions performed by Sync (or that would have
+// been performed for a dry run). All paths are '/' separated and relative
+// to the synchronised directories.
+type SyncReport struct {
+	// Created are the files copied to the destination that did not exist
+	Created []string
+	// Updated are the files in the destination replaced by the source
+	Updated []string
+	// Deleted are the items removed from the destination
+	Deleted []string
+	// Unchanged are the files and symbolic links that did not need to be
+	// copied
+	Unchanged []string
+	// Directories are the directories created in the destination
+	Directories []string
+	// Links are the symbolic links created, or replaced, in the destination
+	Links []string
+	// Skipped are the symbolic links that could not be recreated, because
+	// the source or the destination does not implement SymlinkFS
+	Skipped []string
+}
+
+// Sync mirrors the tree at opts.From in src into opts.To in dst, in the
+// style of rsync -a. New and changed files are copied; a file is deemed
+// changed if its size or modification time differ, or if its digest
+// differs when opts.Checksum is set. If dst implements ChangeTimesFS, the
+// modification time of each copied file is set to that of its source, so
+// that unchanged files are detected on subsequent runs. Symbolic links
+// are recreated with the same destination, if both src and dst implement
+// SymlinkFS, otherwise they are reported as skipped.
+//
+// Include and Exclude patterns use the syntax of path.Match. A pattern
+// containing a '/' is matched against the path relative to the
+// synchronised directory; otherwise it is matched against the name of
+// the item. Include patterns only apply to files and symbolic links; when
+// there are any, a directory is only created in the destination if an
+// item beneath it is copied, so that no empty directories are left for
+// the parts of the tree that are not included.
+func Sync(src ReaderFS, dst UniversalFS, opts SyncOptions) (*SyncReport, error) {
+	if opts.Algorithm == "" {
+		opts.Algorithm = HashSHA256
+	}
+
+	if _, err := opts.Algorithm.New(); err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &synchroniser{
+		src:  src,
+		dst:  dst,
+		opts: &opts,
+		report: &SyncReport{
+			Created:     []string{},
+			Updated:     []string{},
+			Deleted:     []string{},
+			Unchanged:   []string{},
+			Directories: []string{},
+			Links:       []string{},
+			Skipped:     []string{},
+		},
+		made: make(map[string]bool),
+	}
+
+	info, err := src.Stat(syncDir(src, opts.From))
+	if err != nil {
+		return s.report, err
+	}
+
+	if _, isDir := s.lookup(syncDir(dst, opts.To)); !isDir && !opts.DryRun {
+		if err := dst.MakeDirAll(syncDir(dst, opts.To), info.Mode().Perm()); err != nil {
+			return s.report, err
+		}
+	}
+
+	return s.report, s.sync("")
+}
+
+func syncDir(fS FSUtility, dir string) string {
+	if dir == "" && fS.IsRelative() {
+		return "."
+	}
+
+	return dir
+}
+
+// syncTempSuffix is appended to the name of the temporary file, that
+// receives the content of a file being copied
+const syncTempSuffix = ".nef-sync"
+
+type synchroniser struct {
+	src    ReaderFS
+	dst    UniversalFS
+	opts   *SyncOptions
+	report *SyncReport
+	// made records the directories (by relative path) that have been
+	// ensured in the destination
+	made map[string]bool
+}
+
+func (s *synchroniser) srcPath(rel string) string {
+	return lo.Ternary(rel == "",
+		syncDir(s.src, s.opts.From),
+		joinUnder(s.src, s.opts.From, rel),
+	)
+}
+
+func (s *synchroniser) dstPath(rel string) string {
+	return lo.Ternary(rel == "",
+		syncDir(s.dst, s.opts.To),
+		joinUnder(s.dst, s.opts.To, rel),
+	)
+}
+
+// sync synchronises the directory at rel, which exists in the source
+// and has already been created in the destination.
+func (s *synchroniser) sync(rel string) error {
+	entries, err := s.src.ReadDir(s.srcPath(rel))
+	if err != nil {
+		return err
+	}
+
+	// the destination directory may not exist for a dry run
+	var existing []fs.DirEntry
+
+	if _, isDir := s.lookup(s.dstPath(rel)); isDir {
+		if existing, err = s.dst.ReadDir(s.dstPath(rel)); err != nil {
+			return err
+		}
+	}
+
+	present := make(map[string]bool, len(entries))
+
+	for _, entry := range entries {
+		present[entry.Name()] = true
+		child := lo.Ternary(rel == "", entry.Name(), rel+separatorStr+entry.Name())
+
+		if s.excluded(child) {
+			continue
+		}
+
+		switch {
+		case entry.IsDir():
+			err = s.directory(child)
+		case entry.Type()&fs.ModeSymlink != 0 && s.included(child):
+			err = s.link(child)
+		case entry.Type().IsRegular() && s.included(child):
+			err = s.file(child, entry)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if !s.opts.Delete {
+		return nil
+	}
+
+	for _, entry := range existing {
+		child := lo.Ternary(rel == "", entry.Name(), rel+separatorStr+entry.Name())
+
+		if present[entry.Name()] || s.excluded(child) {
+			continue
+		}
+
+		if err := s.remove(child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// directory synchronises the directory at rel. Without Include patterns,
+// it is created up front, so that empty directories are mirrored;
+// otherwise it is only created once an item beneath it is copied.
+func (s *synchroniser) directory(rel string) error {
+	if len(s.opts.Include) == 0 {
+		if err := s.ensure(rel); err != nil {
+			return err
+		}
+	}
+
+	return s.sync(rel)
+}
+
+// ensure creates the directory at rel in the destination, along with any
+// missing ancestors, with the permissions of the source. An item that is
+// not a directory is replaced.
+func (s *synchroniser) ensure(rel string) error {
+	if rel == "" || s.made[rel] {
+		return nil
+	}
+
+	if err := s.ensure(parentOf(rel)); err != nil {
+		return err
+	}
+
+	s.made[rel] = true
+	target := s.dstPath(rel)
+
+	found, isDir := s.lookup(target)
+	if isDir {
+		return nil
+	}
+
+	if found {
+		if err := s.remove(rel); err != nil {
+			return err
+		}
+	}
+
+	info, err := s.src.Stat(s.srcPath(rel))
+	if err != nil {
+		return err
+	}
+
+	if !s.opts.DryRun {
+		if err := s.dst.MakeDir(target, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	s.report.Directories = append(s.report.Directories, rel)
+
+	return nil
+}
+
+// parentOf returns the relative path of the parent of rel, which is
+// empty for an item at the top of the tree
+func parentOf(rel string) string {
+	dir := path.Dir(rel)
+
+	return lo.Ternary(dir == ".", "", dir)
+}
+
+// link recreates the symbolic link at rel in the destination, with the
+// same destination as in the source.
+func (s *synchroniser) link(rel string) error {
+	reader, readable := s.src.(SymlinkFS)
+	writer, writable := s.dst.(SymlinkFS)
+
+	if !readable || !writable {
+		s.report.Skipped = append(s.report.Skipped, rel)
+
+		return nil
+	}
+
+	destination, err := reader.ReadLink(s.srcPath(rel))
+	if err != nil {
+		return err
+	}
+
+	target := s.dstPath(rel)
+
+	if existing, err := writer.ReadLink(target); err == nil {
+		if existing == destination {
+			s.report.Unchanged = append(s.report.Unchanged, rel)
+
+			return nil
+		}
+
+		if !s.opts.DryRun {
+			if err := s.dst.Remove(target); err != nil {
+				return err
+			}
+		}
+	} else if found, _ := s.lookup(target); found {
+		if err := s.remove(rel); err != nil {
+			return err
+		}
+	}
+
+	if err := s.ensure(parentOf(rel)); err != nil {
+		return err
+	}
+
+	s.report.Links = append(s.report.Links, rel)
+
+	if s.opts.DryRun {
+		return nil
+	}
+
+	return writer.Symlink(destination, target)
+}
+
+func (s *synchroniser) file(rel string, entry fs.DirEntry) error {
+	source, target := s.srcPath(rel), s.dstPath(rel)
+
+	info, err := entry.Info()
+	if err != nil {
+		return err
+	}
+
+	found, isDir := s.lookup(target)
+
+	switch {
+	case isDir:
+		if err := s.remove(rel); err != nil {
+			return err
+		}
+
+		s.report.Created = append(s.report.Created, rel)
+
+	case found:
+		changed, err := s.changed(source, target, info)
+		if err != nil {
+			return err
+		}
+
+		if !changed {
+			s.report.Unchanged = append(s.report.Unchanged, rel)
+			return nil
+		}
+
+		s.report.Updated = append(s.report.Updated, rel)
+
+	default:
+		s.report.Created = append(s.report.Created, rel)
+	}
+
+	if err := s.ensure(parentOf(rel)); err != nil {
+		return err
+	}
+
+	if s.opts.DryRun {
+		return nil
+	}
+
+	return s.copy(source, target, info)
+}
+
+func (s *synchroniser) changed(source, target string, info fs.FileInfo) (bool, error) {
+	existing, err := s.dst.Stat(target)
+	if err != nil {
+		return false, err
+	}
+
+	if info.Size() != existing.Size() {
+		return true, nil
+	}
+
+	if !s.opts.Checksum {
+		return !info.ModTime().Equal(existing.ModTime()), nil
+	}
+
+	from, err := Checksum(s.src, source, s.opts.Algorithm)
+	if err != nil {
+		return false, err
+	}
+
+	to, err := Checksum(s.dst, target, s.opts.Algorithm)
+	if err != nil {
+		return false, err
+	}
+
+	return from != to, nil
+}
+
+// copy copies the file content. The content is written to a temporary
+// sibling of the target, which then replaces it, so that a failed write
+// does not lose the file previously present in the destination.
+func (s *synchroniser) copy(source, target string, info fs.FileInfo) error {
+	data, err := s.src.ReadFile(source)
+	if err != nil {
+		return err
+	}
+
+	dir, name := s.dst.Calc().Split(target)
+	temp := dir + "." + name + syncTempSuffix
+
+	if err := s.dst.WriteFile(temp, data, info.Mode().Perm()); err != nil {
+		_ = s.dst.Remove(temp)
+
+		return err
+	}
+
+	if err := s.dst.Rename(temp, target); err != nil {
+		_ = s.dst.Remove(temp)
+
+		return err
+	}
+
+	if changer, ok := s.dst.(ChangeTimesFS); ok {
+		return changer.Chtimes(target, info.ModTime(), info.ModTime())
+	}
+
+	return nil
+}
+
+// lookup reports whether target exists in the destination and if so,
+// whether it is a directory. Stat is used rather than FileExists and
+// DirectoryExists, so that implicit directories (eg of a MemFS) are
+// recognised.
+func (s *synchroniser) lookup(target string) (found, isDir bool) {
+	info, err := s.dst.Stat(target)
+	if err != nil {
+		return false, false
+	}
+
+	return true, info.IsDir()
+}
+
+func (s *synchroniser) remove(rel string) error {
+	s.report.Deleted = append(s.report.Deleted, rel)
+
+	if s.opts.DryRun {
+		return nil
+	}
+
+	return s.dst.RemoveAll(s.dstPath(rel))
+}
+
+func (s *synchroniser) included(rel string) bool {
+	return len(s.opts.Include) == 0 || matchAny(s.opts.Include, rel)
+}
+
+func (s *synchroniser) excluded(rel string) bool {
+	return matchAny(s.opts.Exclude, rel)
+}
+
+// matchAny reports whether any of the patterns match rel; a pattern
+// without a separator is matched against the name only.
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		subject := lo.Ternary(strings.Contains(pattern, separatorStr), rel, path.Base(rel))
+
+		if matched, _ := path.Match(pattern, subject); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,248 @@
+package nef_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing/fstest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+var _ = Describe("Sync", func() {
+	var (
+		src     *luna.MemFS
+		dst     *luna.MemFS
+		modTime time.Time
+	)
+
+	site := func(fS *luna.MemFS, files map[string]string) {
+		for name, content := range files {
+			fS.MapFS[name] = &fstest.MapFile{
+				Data:    []byte(content),
+				Mode:    lab.Perms.File,
+				ModTime: modTime,
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		modTime = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+		src = luna.NewMemFS()
+		dst = luna.NewMemFS()
+		site(src, map[string]string{
+			"index.html":      "<html/>",
+			"css/site.css":    "body {}",
+			"img/logo.png":    "png",
+			"img/raw/big.tif": "tif",
+		})
+	})
+
+	When("given: empty destination", func() {
+		It("🧪 should: copy the whole tree", func() {
+			report, err := nef.Sync(src, dst, nef.SyncOptions{})
+			Expect(err).To(Succeed())
+			Expect(report.Created).To(ConsistOf(
+				"css/site.css", "img/logo.png", "img/raw/big.tif", "index.html",
+			))
+			Expect(report.Directories).To(ConsistOf("css", "img", "img/raw"))
+			Expect(dst.ReadFile("img/raw/big.tif")).To(Equal([]byte("tif")))
+
+			info, err := dst.Stat("css/site.css")
+			Expect(err).To(Succeed())
+			Expect(info.ModTime()).To(Equal(modTime))
+		})
+	})
+
+	When("given: previously synchronised destination", func() {
+		It("🧪 should: only copy changed files", func() {
+			_, err := nef.Sync(src, dst, nef.SyncOptions{})
+			Expect(err).To(Succeed())
+
+			site(src, map[string]string{
+				"index.html": "<html>changed</html>",
+			})
+			src.MapFS["css/site.css"].ModTime = modTime.Add(time.Hour)
+
+			report, err := nef.Sync(src, dst, nef.SyncOptions{})
+			Expect(err).To(Succeed())
+			Expect(report.Created).To(BeEmpty())
+			Expect(report.Directories).To(BeEmpty())
+			Expect(report.Updated).To(ConsistOf("css/site.css", "index.html"))
+			Expect(report.Unchanged).To(ConsistOf("img/logo.png", "img/raw/big.tif"))
+			Expect(dst.ReadFile("index.html")).To(Equal([]byte("<html>changed</html>")))
+		})
+	})
+
+	When("given: same size and time but different content", func() {
+		It("🧪 should: only detect change with checksum", func() {
+			_, err := nef.Sync(src, dst, nef.SyncOptions{})
+			Expect(err).To(Succeed())
+			dst.MapFS["img/logo.png"].Data = []byte("gif")
+
+			report, err := nef.Sync(src, dst, nef.SyncOptions{})
+			Expect(err).To(Succeed())
+			Expect(report.Updated).To(BeEmpty())
+
+			report, err = nef.Sync(src, dst, nef.SyncOptions{
+				Checksum: true,
+			})
+			Expect(err).To(Succeed())
+			Expect(report.Updated).To(ConsistOf("img/logo.png"))
+			Expect(dst.ReadFile("img/logo.png")).To(Equal([]byte("png")))
+		})
+	})
+
+	When("given: extraneous destination items", func() {
+		It("🧪 should: delete them, unless excluded", func() {
+			site(dst, map[string]string{
+				"old.html":     "old",
+				"old/page.txt": "old",
+				"keep.log":     "log",
+			})
+
+			report, err := nef.Sync(src, dst, nef.SyncOptions{
+				Delete:  true,
+				Exclude: []string{"*.log"},
+			})
+			Expect(err).To(Succeed())
+			Expect(report.Deleted).To(ConsistOf("old", "old.html"))
+			Expect(luna.AsFile("old.html")).NotTo(luna.ExistInFS(dst))
+			Expect(luna.AsFile("old/page.txt")).NotTo(luna.ExistInFS(dst))
+			Expect(luna.AsFile("keep.log")).To(luna.ExistInFS(dst))
+		})
+	})
+
+	When("given: include and exclude filters", func() {
+		It("🧪 should: only copy matching files", func() {
+			report, err := nef.Sync(src, dst, nef.SyncOptions{
+				Include: []string{"*.png", "*.tif", "css/*"},
+				Exclude: []string{"img/raw"},
+			})
+			Expect(err).To(Succeed())
+			Expect(report.Created).To(ConsistOf("css/site.css", "img/logo.png"))
+			Expect(report.Directories).To(ConsistOf("css", "img"))
+		})
+	})
+
+	When("given: include filters that exclude whole directories", func() {
+		It("🧪 should: not create empty directories", func() {
+			report, err := nef.Sync(src, dst, nef.SyncOptions{
+				Include: []string{"*.png"},
+			})
+			Expect(err).To(Succeed())
+			Expect(report.Created).To(ConsistOf("img/logo.png"))
+			Expect(report.Directories).To(ConsistOf("img"))
+			Expect(luna.AsDirectory("css")).NotTo(luna.ExistInFS(dst))
+			Expect(luna.AsDirectory("img/raw")).NotTo(luna.ExistInFS(dst))
+		})
+	})
+
+	When("given: symbolic links", func() {
+		BeforeEach(func() {
+			Expect(src.Symlink("index.html", "home.html")).To(Succeed())
+			Expect(src.Symlink("../index.html", "img/raw/index.html")).To(Succeed())
+		})
+
+		It("🧪 should: recreate links with the same destination", func() {
+			report, err := nef.Sync(src, dst, nef.SyncOptions{})
+			Expect(err).To(Succeed())
+			Expect(report.Links).To(ConsistOf("home.html", "img/raw/index.html"))
+			Expect(report.Skipped).To(BeEmpty())
+			Expect(dst.ReadLink("home.html")).To(Equal("index.html"))
+			Expect(dst.ReadLink("img/raw/index.html")).To(Equal("../index.html"))
+
+			Expect(src.Remove("home.html")).To(Succeed())
+			Expect(src.Symlink("css/site.css", "home.html")).To(Succeed())
+
+			report, err = nef.Sync(src, dst, nef.SyncOptions{})
+			Expect(err).To(Succeed())
+			Expect(report.Links).To(ConsistOf("home.html"))
+			Expect(report.Unchanged).To(ContainElement("img/raw/index.html"))
+			Expect(dst.ReadLink("home.html")).To(Equal("css/site.css"))
+		})
+
+		When("given: destination without SymlinkFS", func() {
+			It("🧪 should: report links as skipped", func() {
+				report, err := nef.Sync(src, struct{ nef.UniversalFS }{dst}, nef.SyncOptions{})
+				Expect(err).To(Succeed())
+				Expect(report.Skipped).To(ConsistOf("home.html", "img/raw/index.html"))
+				Expect(report.Links).To(BeEmpty())
+				Expect(luna.AsFile("home.html")).NotTo(luna.ExistInFS(dst))
+			})
+		})
+	})
+
+	When("given: dry run", func() {
+		It("🧪 should: report without modifying destination", func() {
+			site(dst, map[string]string{
+				"old.html": "old",
+			})
+
+			report, err := nef.Sync(src, dst, nef.SyncOptions{
+				Delete: true,
+				DryRun: true,
+			})
+			Expect(err).To(Succeed())
+			Expect(report.Created).To(HaveLen(4))
+			Expect(report.Directories).To(ConsistOf("css", "img", "img/raw"))
+			Expect(report.Deleted).To(ConsistOf("old.html"))
+			Expect(dst.MapFS).To(HaveLen(1))
+		})
+	})
+
+	DescribeTable("failed copy",
+		func(fault luna.Fault) {
+			_, err := nef.Sync(src, dst, nef.SyncOptions{})
+			Expect(err).To(Succeed())
+
+			site(src, map[string]string{
+				"index.html": "<html>changed</html>",
+			})
+
+			_, err = nef.Sync(src, luna.NewFaultFS(dst, 1, fault), nef.SyncOptions{})
+			Expect(err).To(MatchError(syscall.ENOSPC))
+			Expect(dst.ReadFile("index.html")).To(Equal([]byte("<html/>")))
+			Expect(luna.AsFile(".index.html.nef-sync")).NotTo(luna.ExistInFS(dst))
+		},
+		func(fault luna.Fault) string {
+			return fmt.Sprintf("🧪 ===> given: '%v' fault, should: preserve destination file",
+				fault.Op,
+			)
+		},
+		Entry(nil, luna.Fault{Op: "WriteFile", Err: syscall.ENOSPC, ShortWrite: 3}),
+		Entry(nil, luna.Fault{Op: "Rename", Err: syscall.ENOSPC}),
+	)
+
+	When("given: relative destination", func() {
+		It("🧪 should: synchronise memory fixture into local directory", func() {
+			root := GinkgoT().TempDir()
+			local := nef.NewUniversalFS(nef.Rel{
+				Root: root,
+			})
+
+			report, err := nef.Sync(src, local, nef.SyncOptions{
+				To: "site",
+			})
+			Expect(err).To(Succeed())
+			Expect(report.Created).To(HaveLen(4))
+
+			content, err := os.ReadFile(filepath.Join(root, "site", "img", "raw", "big.tif"))
+			Expect(err).To(Succeed())
+			Expect(content).To(Equal([]byte("tif")))
+
+			report, err = nef.Sync(src, local, nef.SyncOptions{
+				To: "site",
+			})
+			Expect(err).To(Succeed())
+			Expect(report.Unchanged).To(HaveLen(4))
+		})
+	})
+})
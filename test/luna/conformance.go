@@ -0,0 +1,767 @@
+package luna
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"testing/fstest"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+)
+
+// Capability denotes an optional behaviour of a file system being
+// subjected to the conformance suite (see RunConformance). The specs
+// that require a capability not declared by the file system are skipped;
+// instead, a method whose capability is not declared must fail with
+// errors.ErrUnsupported.
+type Capability uint32
+
+const (
+	// CapOverwrite denotes a file system that can be created in overwrite
+	// mode, where Create, WriteFile, Move and Change replace existing files.
+	CapOverwrite Capability = 1 << iota
+	// CapTentative denotes a file system that can be created in tentative
+	// mode, where Create, Move and Change refuse to replace existing files.
+	CapTentative
+	// CapMove denotes a file system that implements Move
+	CapMove
+	// CapChange denotes a file system that implements Change
+	CapChange
+	// CapCopy denotes a file system that implements Copy
+	CapCopy
+	// CapCopyFS denotes a file system that implements CopyFS
+	CapCopyFS
+)
+
+// Has reports whether all of the capabilities specified are present
+func (c Capability) Has(capability Capability) bool {
+	return c&capability == capability
+}
+
+// ConformanceFactory creates a new, empty, instance of the file system
+// under test, in overwrite or tentative mode, for every spec. The root
+// is the path, in terms of the file system, of the directory in which the
+// specs create their items, eg "." for a relative file system.
+type ConformanceFactory func(overwrite bool) (fS nef.UniversalFS, root string)
+
+// conformance is the state of a conformance spec
+type conformance struct {
+	fS        nef.UniversalFS
+	root      string
+	overwrite bool
+}
+
+// at returns the path of the named item in the root of the file system
+func (c *conformance) at(name string) string {
+	return childOf(c.fS, c.root, name)
+}
+
+func (c *conformance) build(fixture Fixture) {
+	gomega.Expect(fixture.Build(c.fS, c.root)).To(gomega.Succeed())
+}
+
+func (c *conformance) content(name string) string {
+	content, err := c.fS.ReadFile(c.at(name))
+	gomega.Expect(err).To(gomega.Succeed())
+
+	return string(content)
+}
+
+func (c *conformance) reason(err error, reason nef.ErrorReason) {
+	actual, ok := nef.ReasonOf(err)
+	gomega.Expect(ok).To(gomega.BeTrue(), "not a nef error: %v", err)
+	gomega.Expect(actual).To(gomega.Equal(reason))
+}
+
+// RunConformance registers a suite of Ginkgo specs that exercises every
+// method of the nef.UniversalFS created by factory, to prove that it
+// behaves in the same way as the built-in implementations, including the
+// overwrite/tentative semantics and the errors returned. capabilities
+// declares the optional behaviours supported by the file system; specs
+// requiring other capabilities are skipped, but the methods concerned
+// (Move, Change, Copy and CopyFS) must then fail with
+// errors.ErrUnsupported, rather than silently do nothing. The specs for the
+// optional ChangeTimesFS, ContextFS, LockFS, SymlinkFS and WatchFS
+// interfaces are skipped if not implemented. It must be invoked from
+// within a Ginkgo container, eg:
+//
+//	var _ = Describe("MyFS", func() {
+//		luna.RunConformance(func(overwrite bool) (nef.UniversalFS, string) {
+//			return NewMyFS(GinkgoT().TempDir(), overwrite), "."
+//		}, luna.CapOverwrite|luna.CapTentative)
+//	})
+func RunConformance(factory ConformanceFactory, capabilities Capability) bool {
+	return ginkgo.Describe("🧩 conformance", func() {
+		for _, mode := range []struct {
+			name       string
+			overwrite  bool
+			capability Capability
+		}{
+			{name: "tentative", overwrite: false, capability: CapTentative},
+			{name: "overwrite", overwrite: true, capability: CapOverwrite},
+		} {
+			ginkgo.Context("mode: "+mode.name, func() {
+				c := &conformance{}
+
+				ginkgo.BeforeEach(func() {
+					if !capabilities.Has(mode.capability) {
+						ginkgo.Skip("file system does not support " + mode.name + " mode")
+					}
+
+					c.fS, c.root = factory(mode.overwrite)
+					c.overwrite = mode.overwrite
+				})
+
+				conformReader(c)
+				conformMakeDir(c)
+				conformWriteFile(c)
+				conformRemove(c)
+				conformRename(c)
+				conformMove(c, capabilities)
+				conformChange(c, capabilities)
+				conformCopy(c, capabilities)
+				conformUnsupported(c, capabilities)
+				conformOptional(c)
+			})
+		}
+	})
+}
+
+func conformReader(c *conformance) {
+	ginkgo.Context("fs: ReaderFS", func() {
+		ginkgo.BeforeEach(func() {
+			c.build(Fixture{
+				Dir("site",
+					File("index.html", "<html/>"),
+					Dir("css",
+						File("site.css", "body {}"),
+					),
+				),
+			})
+		})
+
+		ginkgo.It("🧪 should: stat file and directory", func() {
+			info, err := c.fS.Stat(c.at("site/index.html"))
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(info.Name()).To(gomega.Equal("index.html"))
+			gomega.Expect(info.IsDir()).To(gomega.BeFalse())
+			gomega.Expect(info.Size()).To(gomega.Equal(int64(len("<html/>"))))
+
+			info, err = c.fS.Stat(c.at("site/css"))
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(info.IsDir()).To(gomega.BeTrue())
+		})
+
+		ginkgo.It("🧪 should: read file", func() {
+			gomega.Expect(c.content("site/index.html")).To(gomega.Equal("<html/>"))
+
+			file, err := c.fS.Open(c.at("site/css/site.css"))
+			gomega.Expect(err).To(gomega.Succeed())
+			defer file.Close() //nolint:errcheck // ok
+
+			content, err := io.ReadAll(file)
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(string(content)).To(gomega.Equal("body {}"))
+		})
+
+		ginkgo.It("🧪 should: read directory in order of name", func() {
+			entries, err := c.fS.ReadDir(c.at("site"))
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(entries).To(gomega.HaveLen(2))
+			gomega.Expect(entries[0].Name()).To(gomega.Equal("css"))
+			gomega.Expect(entries[0].IsDir()).To(gomega.BeTrue())
+			gomega.Expect(entries[1].Name()).To(gomega.Equal("index.html"))
+			gomega.Expect(entries[1].IsDir()).To(gomega.BeFalse())
+		})
+
+		ginkgo.It("🧪 should: report existence", func() {
+			gomega.Expect(AsFile(c.at("site/index.html"))).To(ExistInFS(c.fS))
+			gomega.Expect(AsDirectory(c.at("site/index.html"))).NotTo(ExistInFS(c.fS))
+			gomega.Expect(AsDirectory(c.at("site/css"))).To(ExistInFS(c.fS))
+			gomega.Expect(AsFile(c.at("site/css"))).NotTo(ExistInFS(c.fS))
+			gomega.Expect(AsFile(c.at("site/missing"))).NotTo(ExistInFS(c.fS))
+			gomega.Expect(AsDirectory(c.at("site/missing"))).NotTo(ExistInFS(c.fS))
+		})
+
+		ginkgo.When("given: item does not exist", func() {
+			ginkgo.It("🧪 should: fail with fs.ErrNotExist", func() {
+				_, err := c.fS.Stat(c.at("site/missing"))
+				gomega.Expect(err).To(gomega.MatchError(fs.ErrNotExist))
+
+				_, err = c.fS.ReadFile(c.at("site/missing"))
+				gomega.Expect(err).To(gomega.MatchError(fs.ErrNotExist))
+
+				_, err = c.fS.ReadDir(c.at("missing"))
+				gomega.Expect(err).To(gomega.MatchError(fs.ErrNotExist))
+			})
+		})
+	})
+}
+
+func conformMakeDir(c *conformance) {
+	ginkgo.Context("fs: MakeDirFS", func() {
+		ginkgo.It("🧪 should: make directory", func() {
+			gomega.Expect(c.fS.MakeDir(c.at("logs"), lab.Perms.Dir)).To(gomega.Succeed())
+			gomega.Expect(AsDirectory(c.at("logs"))).To(ExistInFS(c.fS))
+		})
+
+		ginkgo.It("🧪 should: make directory with parents", func() {
+			gomega.Expect(c.fS.MakeDirAll(c.at("a/b/c"), lab.Perms.Dir)).To(gomega.Succeed())
+			gomega.Expect(AsDirectory(c.at("a/b/c"))).To(ExistInFS(c.fS))
+
+			gomega.Expect(c.fS.MakeDirAll(c.at("a/b"), lab.Perms.Dir)).To(gomega.Succeed(),
+				"existing directory should be ignored",
+			)
+		})
+
+		ginkgo.When("given: path as file", func() {
+			ginkgo.It("🧪 should: make parent directory and return file", func() {
+				at, err := c.fS.Ensure(nef.PathAs{
+					Name:    c.at("logs/test.log"),
+					Default: "default.log",
+					Perm:    lab.Perms.Dir,
+					AsFile:  true,
+				})
+				gomega.Expect(err).To(gomega.Succeed())
+				gomega.Expect(at).To(gomega.Equal(c.at("logs/test.log")))
+				gomega.Expect(AsDirectory(c.at("logs"))).To(ExistInFS(c.fS))
+			})
+		})
+
+		ginkgo.When("given: path as directory", func() {
+			ginkgo.It("🧪 should: make directory and return default", func() {
+				at, err := c.fS.Ensure(nef.PathAs{
+					Name:    c.at("logs"),
+					Default: "default.log",
+					Perm:    lab.Perms.Dir,
+				})
+				gomega.Expect(err).To(gomega.Succeed())
+				gomega.Expect(at).To(gomega.Equal(c.at("logs/default.log")))
+				gomega.Expect(AsDirectory(c.at("logs"))).To(ExistInFS(c.fS))
+			})
+		})
+	})
+}
+
+func conformWriteFile(c *conformance) {
+	ginkgo.Context("fs: WriteFileFS", func() {
+		ginkgo.It("🧪 should: write file", func() {
+			gomega.Expect(c.fS.WriteFile(c.at("a.txt"), []byte("a"), lab.Perms.File)).To(gomega.Succeed())
+			gomega.Expect(c.content("a.txt")).To(gomega.Equal("a"))
+		})
+
+		ginkgo.It("🧪 should: create file", func() {
+			file, err := c.fS.Create(c.at("a.txt"))
+			gomega.Expect(err).To(gomega.Succeed())
+			write(file, "created")
+			gomega.Expect(c.content("a.txt")).To(gomega.Equal("created"))
+		})
+
+		ginkgo.When("given: file exists", func() {
+			ginkgo.BeforeEach(func() {
+				c.build(Fixture{
+					File("a.txt", "original"),
+				})
+			})
+
+			ginkgo.It("🧪 should: create only if overwrite", func() {
+				file, err := c.fS.Create(c.at("a.txt"))
+
+				if !c.overwrite {
+					gomega.Expect(err).To(gomega.MatchError(fs.ErrExist))
+					gomega.Expect(c.content("a.txt")).To(gomega.Equal("original"))
+
+					return
+				}
+
+				gomega.Expect(err).To(gomega.Succeed())
+				write(file, "created")
+				gomega.Expect(c.content("a.txt")).To(gomega.Equal("created"))
+			})
+
+			ginkgo.It("🧪 should: write file, if overwrite", func() {
+				if !c.overwrite {
+					ginkgo.Skip("WriteFile onto existing file is implementation defined in tentative mode")
+				}
+
+				gomega.Expect(c.fS.WriteFile(c.at("a.txt"), []byte("a"), lab.Perms.File)).To(gomega.Succeed())
+				gomega.Expect(c.content("a.txt")).To(gomega.Equal("a"))
+			})
+		})
+	})
+}
+
+func write(file fs.File, content string) {
+	writer, ok := file.(io.Writer)
+	gomega.Expect(ok).To(gomega.BeTrue(), "created file is not an io.Writer (%T)", file)
+
+	_, err := writer.Write([]byte(content))
+	gomega.Expect(err).To(gomega.Succeed())
+	gomega.Expect(file.Close()).To(gomega.Succeed())
+}
+
+func conformRemove(c *conformance) {
+	ginkgo.Context("fs: RemoveFS", func() {
+		ginkgo.BeforeEach(func() {
+			c.build(Fixture{
+				Dir("a",
+					File("b/c.txt", "c"),
+					Dir("empty"),
+				),
+			})
+		})
+
+		ginkgo.It("🧪 should: remove file", func() {
+			gomega.Expect(c.fS.Remove(c.at("a/b/c.txt"))).To(gomega.Succeed())
+			gomega.Expect(AsFile(c.at("a/b/c.txt"))).NotTo(ExistInFS(c.fS))
+		})
+
+		ginkgo.It("🧪 should: remove empty directory", func() {
+			gomega.Expect(c.fS.Remove(c.at("a/empty"))).To(gomega.Succeed())
+			gomega.Expect(AsDirectory(c.at("a/empty"))).NotTo(ExistInFS(c.fS))
+		})
+
+		ginkgo.It("🧪 should: remove tree", func() {
+			gomega.Expect(c.fS.RemoveAll(c.at("a"))).To(gomega.Succeed())
+			gomega.Expect(AsDirectory(c.at("a"))).NotTo(ExistInFS(c.fS))
+			gomega.Expect(AsFile(c.at("a/b/c.txt"))).NotTo(ExistInFS(c.fS))
+		})
+
+		ginkgo.When("given: directory not empty", func() {
+			ginkgo.It("🧪 should: fail to remove", func() {
+				gomega.Expect(c.fS.Remove(c.at("a/b"))).NotTo(gomega.Succeed())
+				gomega.Expect(AsFile(c.at("a/b/c.txt"))).To(ExistInFS(c.fS))
+			})
+		})
+
+		ginkgo.When("given: path does not exist", func() {
+			ginkgo.It("🧪 should: fail to remove with fs.ErrNotExist", func() {
+				gomega.Expect(c.fS.Remove(c.at("a/missing"))).To(gomega.MatchError(fs.ErrNotExist))
+			})
+
+			ginkgo.It("🧪 should: remove all, without error", func() {
+				gomega.Expect(c.fS.RemoveAll(c.at("a/missing"))).To(gomega.Succeed())
+			})
+		})
+	})
+}
+
+func conformRename(c *conformance) {
+	ginkgo.Context("fs: RenameFS", func() {
+		ginkgo.BeforeEach(func() {
+			c.build(Fixture{
+				Dir("from",
+					File("a.txt", "a"),
+				),
+				Dir("to"),
+			})
+		})
+
+		ginkgo.It("🧪 should: rename file", func() {
+			gomega.Expect(c.fS.Rename(c.at("from/a.txt"), c.at("to/b.txt"))).To(gomega.Succeed())
+			gomega.Expect(AsFile(c.at("from/a.txt"))).NotTo(ExistInFS(c.fS))
+			gomega.Expect(c.content("to/b.txt")).To(gomega.Equal("a"))
+		})
+
+		ginkgo.It("🧪 should: rename directory with content", func() {
+			gomega.Expect(c.fS.Rename(c.at("from"), c.at("to/sub"))).To(gomega.Succeed())
+			gomega.Expect(AsDirectory(c.at("from"))).NotTo(ExistInFS(c.fS))
+			gomega.Expect(c.content("to/sub/a.txt")).To(gomega.Equal("a"))
+		})
+
+		ginkgo.When("given: [from] does not exist", func() {
+			ginkgo.It("🧪 should: fail with fs.ErrNotExist", func() {
+				gomega.Expect(c.fS.Rename(c.at("from/missing"), c.at("to/missing"))).To(
+					gomega.MatchError(fs.ErrNotExist),
+				)
+			})
+		})
+	})
+}
+
+func conformMove(c *conformance, capabilities Capability) {
+	ginkgo.Context("fs: MoverFS", func() {
+		ginkgo.BeforeEach(func() {
+			if !capabilities.Has(CapMove) {
+				ginkgo.Skip("file system does not support Move")
+			}
+
+			c.build(Fixture{
+				Dir("from",
+					File("a.txt", "from"),
+					Dir("sub",
+						File("b.txt", "b"),
+					),
+				),
+				Dir("to"),
+				File("file.txt", "file"),
+			})
+		})
+
+		ginkgo.It("🧪 should: move file into directory", func() {
+			gomega.Expect(c.fS.Move(c.at("from/a.txt"), c.at("to"))).To(gomega.Succeed())
+			gomega.Expect(AsFile(c.at("from/a.txt"))).NotTo(ExistInFS(c.fS))
+			gomega.Expect(c.content("to/a.txt")).To(gomega.Equal("from"))
+		})
+
+		ginkgo.It("🧪 should: move file with name", func() {
+			gomega.Expect(c.fS.Move(c.at("from/a.txt"), c.at("to/a.txt"))).To(gomega.Succeed())
+			gomega.Expect(c.content("to/a.txt")).To(gomega.Equal("from"))
+		})
+
+		ginkgo.It("🧪 should: move directory into directory", func() {
+			gomega.Expect(c.fS.Move(c.at("from/sub"), c.at("to"))).To(gomega.Succeed())
+			gomega.Expect(AsDirectory(c.at("from/sub"))).NotTo(ExistInFS(c.fS))
+			gomega.Expect(c.content("to/sub/b.txt")).To(gomega.Equal("b"))
+		})
+
+		ginkgo.It("🧪 should: ignore move onto itself", func() {
+			gomega.Expect(c.fS.Move(c.at("from/a.txt"), c.at("from/a.txt"))).To(gomega.Succeed())
+			gomega.Expect(c.content("from/a.txt")).To(gomega.Equal("from"))
+		})
+
+		ginkgo.When("given: [to] in same directory", func() {
+			ginkgo.It("🧪 should: reject, use rename instead", func() {
+				err := c.fS.Move(c.at("from/a.txt"), c.at("from/b.txt"))
+				gomega.Expect(nef.IsRejectSameDirMoveError(err)).To(gomega.BeTrue(), "%v", err)
+			})
+		})
+
+		ginkgo.When("given: [from] does not exist", func() {
+			ginkgo.It("🧪 should: fail with source not found", func() {
+				err := c.fS.Move(c.at("from/missing"), c.at("to"))
+				gomega.Expect(nef.IsBinaryFsOpError(err)).To(gomega.BeTrue(), "%v", err)
+				gomega.Expect(err).To(gomega.MatchError(fs.ErrNotExist))
+				c.reason(err, nef.ReasonSourceNotFound)
+			})
+		})
+
+		ginkgo.When("given: [from] directory, [to] file", func() {
+			ginkgo.It("🧪 should: fail with directory onto file", func() {
+				err := c.fS.Move(c.at("from/sub"), c.at("file.txt"))
+				gomega.Expect(err).To(gomega.MatchError(fs.ErrExist))
+				c.reason(err, nef.ReasonDirectoryOntoFile)
+			})
+		})
+
+		ginkgo.When("given: [to] file exists, [clash]", func() {
+			ginkgo.BeforeEach(func() {
+				c.build(Fixture{
+					File("to/a.txt", "to"),
+				})
+			})
+
+			ginkgo.It("🧪 should: move only if overwrite", func() {
+				err := c.fS.Move(c.at("from/a.txt"), c.at("to"))
+
+				if !c.overwrite {
+					gomega.Expect(err).To(gomega.MatchError(fs.ErrExist))
+					c.reason(err, nef.ReasonDestinationClash)
+					gomega.Expect(c.content("to/a.txt")).To(gomega.Equal("to"))
+
+					return
+				}
+
+				gomega.Expect(err).To(gomega.Succeed())
+				gomega.Expect(c.content("to/a.txt")).To(gomega.Equal("from"))
+			})
+		})
+	})
+}
+
+func conformChange(c *conformance, capabilities Capability) {
+	ginkgo.Context("fs: ChangerFS", func() {
+		ginkgo.BeforeEach(func() {
+			if !capabilities.Has(CapChange) {
+				ginkgo.Skip("file system does not support Change")
+			}
+
+			c.build(Fixture{
+				Dir("from",
+					File("a.txt", "a"),
+					File("b.txt", "b"),
+					Dir("sub"),
+				),
+			})
+		})
+
+		ginkgo.It("🧪 should: change name of file", func() {
+			gomega.Expect(c.fS.Change(c.at("from/a.txt"), "c.txt")).To(gomega.Succeed())
+			gomega.Expect(AsFile(c.at("from/a.txt"))).NotTo(ExistInFS(c.fS))
+			gomega.Expect(c.content("from/c.txt")).To(gomega.Equal("a"))
+		})
+
+		ginkgo.It("🧪 should: change name of directory", func() {
+			gomega.Expect(c.fS.Change(c.at("from/sub"), "renamed")).To(gomega.Succeed())
+			gomega.Expect(AsDirectory(c.at("from/renamed"))).To(ExistInFS(c.fS))
+		})
+
+		ginkgo.When("given: [to] contains separator", func() {
+			ginkgo.It("🧪 should: fail with invalid path", func() {
+				err := c.fS.Change(c.at("from/a.txt"), "sub/c.txt")
+				gomega.Expect(nef.IsInvalidPathError(err)).To(gomega.BeTrue(), "%v", err)
+			})
+		})
+
+		ginkgo.When("given: [from] does not exist", func() {
+			ginkgo.It("🧪 should: fail with source not found", func() {
+				err := c.fS.Change(c.at("from/missing"), "c.txt")
+				gomega.Expect(nef.IsBinaryFsOpError(err)).To(gomega.BeTrue(), "%v", err)
+				gomega.Expect(err).To(gomega.MatchError(fs.ErrNotExist))
+				c.reason(err, nef.ReasonSourceNotFound)
+			})
+		})
+
+		ginkgo.When("given: [from] file, [to] directory", func() {
+			ginkgo.It("🧪 should: fail with file onto directory", func() {
+				err := c.fS.Change(c.at("from/a.txt"), "sub")
+				gomega.Expect(err).To(gomega.MatchError(fs.ErrExist))
+				c.reason(err, nef.ReasonFileOntoDirectory)
+			})
+		})
+
+		ginkgo.When("given: [to] file exists, [clash]", func() {
+			ginkgo.It("🧪 should: change only if overwrite", func() {
+				err := c.fS.Change(c.at("from/a.txt"), "b.txt")
+
+				if !c.overwrite {
+					gomega.Expect(err).To(gomega.MatchError(fs.ErrExist))
+					c.reason(err, nef.ReasonDestinationClash)
+					gomega.Expect(c.content("from/b.txt")).To(gomega.Equal("b"))
+
+					return
+				}
+
+				gomega.Expect(err).To(gomega.Succeed())
+				gomega.Expect(AsFile(c.at("from/a.txt"))).NotTo(ExistInFS(c.fS))
+				gomega.Expect(c.content("from/b.txt")).To(gomega.Equal("a"))
+			})
+		})
+	})
+}
+
+func conformCopy(c *conformance, capabilities Capability) {
+	ginkgo.Context("fs: CopyFS", func() {
+		ginkgo.BeforeEach(func() {
+			c.build(Fixture{
+				Dir("from",
+					File("a.txt", "a"),
+				),
+				Dir("to"),
+			})
+		})
+
+		ginkgo.It("🧪 should: copy file", func() {
+			if !capabilities.Has(CapCopy) {
+				ginkgo.Skip("file system does not support Copy")
+			}
+
+			gomega.Expect(c.fS.Copy(c.at("from/a.txt"), c.at("to/a.txt"))).To(gomega.Succeed())
+			gomega.Expect(c.content("from/a.txt")).To(gomega.Equal("a"))
+			gomega.Expect(c.content("to/a.txt")).To(gomega.Equal("a"))
+		})
+
+		ginkgo.It("🧪 should: copy file system", func() {
+			if !capabilities.Has(CapCopyFS) {
+				ginkgo.Skip("file system does not support CopyFS")
+			}
+
+			gomega.Expect(c.fS.CopyFS(c.at("to/tree"), fstest.MapFS{
+				"b/c.txt": &fstest.MapFile{Data: []byte("c"), Mode: lab.Perms.File},
+			})).To(gomega.Succeed())
+			gomega.Expect(c.content("to/tree/b/c.txt")).To(gomega.Equal("c"))
+		})
+
+		ginkgo.When("given: [from] does not exist", func() {
+			ginkgo.It("🧪 should: fail with fs.ErrNotExist", func() {
+				if !capabilities.Has(CapCopy) {
+					ginkgo.Skip("file system does not support Copy")
+				}
+
+				gomega.Expect(c.fS.Copy(c.at("from/missing"), c.at("to/missing"))).To(
+					gomega.MatchError(fs.ErrNotExist),
+				)
+			})
+		})
+	})
+}
+
+// conformUnsupported checks that the methods whose capability has not
+// been declared report that they are unsupported, without side effects.
+func conformUnsupported(c *conformance, capabilities Capability) {
+	ginkgo.Context("fs: unsupported", func() {
+		ginkgo.BeforeEach(func() {
+			c.build(Fixture{
+				Dir("from",
+					File("a.txt", "a"),
+				),
+				Dir("to"),
+			})
+		})
+
+		for _, method := range []struct {
+			name       string
+			capability Capability
+			invoke     func() error
+		}{
+			{name: "Move", capability: CapMove, invoke: func() error {
+				return c.fS.Move(c.at("from/a.txt"), c.at("to"))
+			}},
+			{name: "Change", capability: CapChange, invoke: func() error {
+				return c.fS.Change(c.at("from/a.txt"), "b.txt")
+			}},
+			{name: "Copy", capability: CapCopy, invoke: func() error {
+				return c.fS.Copy(c.at("from/a.txt"), c.at("to/a.txt"))
+			}},
+			{name: "CopyFS", capability: CapCopyFS, invoke: func() error {
+				return c.fS.CopyFS(c.at("to/tree"), fstest.MapFS{
+					"b/c.txt": &fstest.MapFile{Data: []byte("c"), Mode: lab.Perms.File},
+				})
+			}},
+		} {
+			ginkgo.It("🧪 should: fail "+method.name+" with errors.ErrUnsupported", func() {
+				if capabilities.Has(method.capability) {
+					ginkgo.Skip("file system supports " + method.name)
+				}
+
+				gomega.Expect(method.invoke()).To(gomega.MatchError(errors.ErrUnsupported))
+				gomega.Expect(c.content("from/a.txt")).To(gomega.Equal("a"))
+				gomega.Expect(AsFile(c.at("to/a.txt"))).NotTo(ExistInFS(c.fS))
+				gomega.Expect(AsDirectory(c.at("to/tree"))).NotTo(ExistInFS(c.fS))
+			})
+		}
+	})
+}
+
+func conformOptional(c *conformance) {
+	ginkgo.Context("fs: ChangeTimesFS", func() {
+		ginkgo.It("🧪 should: change modification time", func() {
+			changer, ok := c.fS.(nef.ChangeTimesFS)
+			if !ok {
+				ginkgo.Skip("file system does not implement nef.ChangeTimesFS")
+			}
+
+			c.build(Fixture{
+				File("a.txt", "a"),
+			})
+
+			stamp := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+			gomega.Expect(changer.Chtimes(c.at("a.txt"), stamp, stamp)).To(gomega.Succeed())
+
+			info, err := c.fS.Stat(c.at("a.txt"))
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(info.ModTime()).To(gomega.BeTemporally("==", stamp))
+		})
+	})
+
+	ginkgo.Context("fs: ContextFS", func() {
+		ginkgo.It("🧪 should: remove tree, unless cancelled", func() {
+			cfs, ok := c.fS.(nef.ContextFS)
+			if !ok {
+				ginkgo.Skip("file system does not implement nef.ContextFS")
+			}
+
+			c.build(Fixture{
+				File("a/b/c.txt", "c"),
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := cfs.RemoveAllContext(ctx, c.at("a"))
+			gomega.Expect(nef.IsCancelledError(err)).To(gomega.BeTrue(), "%v", err)
+			gomega.Expect(err).To(gomega.MatchError(context.Canceled))
+			gomega.Expect(AsFile(c.at("a/b/c.txt"))).To(ExistInFS(c.fS))
+
+			gomega.Expect(cfs.RemoveAllContext(context.Background(), c.at("a"))).To(gomega.Succeed())
+			gomega.Expect(AsDirectory(c.at("a"))).NotTo(ExistInFS(c.fS))
+		})
+	})
+
+	ginkgo.Context("fs: SymlinkFS", func() {
+		ginkgo.It("🧪 should: create and read symbolic link", func() {
+			linker, ok := c.fS.(nef.SymlinkFS)
+			if !ok {
+				ginkgo.Skip("file system does not implement nef.SymlinkFS")
+			}
+
+			c.build(Fixture{
+				File("a.txt", "a"),
+			})
+
+			gomega.Expect(linker.Symlink("a.txt", c.at("link.txt"))).To(gomega.Succeed())
+
+			link, err := linker.ReadLink(c.at("link.txt"))
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(link).To(gomega.Equal("a.txt"))
+			gomega.Expect(c.content("link.txt")).To(gomega.Equal("a"))
+
+			err = linker.Symlink("a.txt", c.at("link.txt"))
+			gomega.Expect(errors.Is(err, fs.ErrExist)).To(gomega.BeTrue(), "%v", err)
+		})
+	})
+
+	ginkgo.Context("fs: LockFS", func() {
+		ginkgo.It("🧪 should: exclude conflicting locks", func() {
+			locker, ok := c.fS.(nef.LockFS)
+			if !ok {
+				ginkgo.Skip("file system does not implement nef.LockFS")
+			}
+
+			shared, err := locker.TryLock(c.at("state.json"), nef.LockShared)
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(c.fS.FileExists(c.at("state.json"))).To(gomega.BeTrue())
+
+			other, err := locker.TryLock(c.at("state.json"), nef.LockShared)
+			gomega.Expect(err).To(gomega.Succeed())
+
+			_, err = locker.LockTimeout(c.at("state.json"), nef.LockExclusive, 10*time.Millisecond)
+			gomega.Expect(nef.IsLockedError(err)).To(gomega.BeTrue(), "%v", err)
+
+			gomega.Expect(shared.Unlock()).To(gomega.Succeed())
+			gomega.Expect(other.Unlock()).To(gomega.Succeed())
+
+			exclusive, err := locker.TryLock(c.at("state.json"), nef.LockExclusive)
+			gomega.Expect(err).To(gomega.Succeed())
+
+			_, err = locker.TryLock(c.at("state.json"), nef.LockShared)
+			gomega.Expect(nef.IsLockedError(err)).To(gomega.BeTrue(), "%v", err)
+			gomega.Expect(exclusive.Unlock()).To(gomega.Succeed())
+		})
+	})
+
+	ginkgo.Context("fs: WatchFS", func() {
+		ginkgo.It("🧪 should: report create and remove", func() {
+			watcher, ok := c.fS.(nef.WatchFS)
+			if !ok {
+				ginkgo.Skip("file system does not implement nef.WatchFS")
+			}
+
+			c.build(Fixture{
+				Dir("tree"),
+			})
+
+			w, err := watcher.Watch(c.at("tree"))
+			gomega.Expect(err).To(gomega.Succeed())
+			ginkgo.DeferCleanup(w.Close)
+
+			observe := func(op nef.WatchOp, name string) {
+				gomega.Eventually(w.Events()).WithTimeout(5 * time.Second).Should(
+					gomega.Receive(gomega.Equal(nef.WatchEvent{Path: c.at(name), Op: op})),
+				)
+			}
+
+			gomega.Expect(c.fS.WriteFile(c.at("tree/a.txt"), []byte("a"), lab.Perms.File)).To(gomega.Succeed())
+			observe(nef.WatchCreate, "tree/a.txt")
+
+			gomega.Expect(c.fS.Remove(c.at("tree/a.txt"))).To(gomega.Succeed())
+			observe(nef.WatchRemove, "tree/a.txt")
+		})
+	})
+}
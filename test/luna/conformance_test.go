@@ -0,0 +1,31 @@
+package luna_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+
+	nef "github.com/snivilised/nefilim"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+var _ = Describe("Conformance", func() {
+	Context("fs: relative", func() {
+		luna.RunConformance(func(overwrite bool) (nef.UniversalFS, string) {
+			return nef.NewUniversalFS(nef.Rel{
+				Root:      GinkgoT().TempDir(),
+				Overwrite: overwrite,
+			}), "."
+		}, luna.CapOverwrite|luna.CapTentative|luna.CapMove|luna.CapChange|luna.CapCopy|luna.CapCopyFS)
+	})
+
+	Context("fs: absolute", func() {
+		luna.RunConformance(func(_ bool) (nef.UniversalFS, string) {
+			return nef.NewUniversalABS(), GinkgoT().TempDir()
+		}, luna.CapOverwrite|luna.CapCopy|luna.CapCopyFS)
+	})
+
+	Context("fs: MemFS", func() {
+		luna.RunConformance(func(_ bool) (nef.UniversalFS, string) {
+			return luna.NewMemFS(), "."
+		}, luna.CapTentative)
+	})
+})
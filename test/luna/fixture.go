@@ -0,0 +1,537 @@
+package luna
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/internal/third/lo"
+	"go.yaml.in/yaml/v3"
+)
+
+// Node is an item of a Fixture; a file, directory or symbolic link.
+type Node struct {
+	// Name is the name of the item, relative to its parent; a '/'
+	// separated name denotes an item in a sub directory, whose parents are
+	// created as required.
+	Name string
+	// Dir denotes a directory
+	Dir bool
+	// Content is the content of a file
+	Content string
+	// Mode is the permission of the item; when 0, the default permission
+	// of a file or directory is used.
+	Mode fs.FileMode
+	// ModTime is the modification time of the item; when zero, the time
+	// is not set.
+	ModTime time.Time
+	// Link is the destination of a symbolic link
+	Link string
+	// Children are the items of a directory
+	Children []*Node
+}
+
+// File creates a file Node
+func File(name, content string) *Node {
+	return &Node{
+		Name:    name,
+		Content: content,
+	}
+}
+
+// Dir creates a directory Node. A directory named "." denotes the
+// directory the fixture is built in.
+func Dir(name string, children ...*Node) *Node {
+	return &Node{
+		Name:     name,
+		Dir:      true,
+		Children: children,
+	}
+}
+
+// Symlink creates a symbolic link Node, whose destination is link
+func Symlink(name, link string) *Node {
+	return &Node{
+		Name: name,
+		Link: link,
+	}
+}
+
+// WithMode sets the permission of the item
+func (n *Node) WithMode(mode fs.FileMode) *Node {
+	n.Mode = mode.Perm()
+
+	return n
+}
+
+// WithModTime sets the modification time of the item
+func (n *Node) WithModTime(modTime time.Time) *Node {
+	n.ModTime = modTime
+
+	return n
+}
+
+// Fixture is a declarative specification of a directory tree, that can be
+// written as a Go literal, in YAML (see ParseFixtureYAML) or in an indented
+// text format (see ParseFixtureText), and built into any nef.WriterFS.
+type Fixture []*Node
+
+// Build materialises the fixture into the directory root of fS. Building
+// a symbolic link requires fS to implement nef.SymlinkFS and setting a
+// modification time requires nef.ChangeTimesFS. The modification time of
+// a directory is set after its children have been built.
+func (f Fixture) Build(fS nef.WriterFS, root string) error {
+	for _, node := range f {
+		if err := node.build(fS, root); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (n *Node) build(fS nef.WriterFS, parent string) error {
+	path := lo.Ternary(n.Name == ".", parent, childOf(fS, parent, n.Name))
+
+	if dir := fS.Calc().Dir(path); strings.Contains(n.Name, "/") {
+		if err := fS.MakeDirAll(dir, lab.Perms.Dir); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case n.Link != "":
+		linker, ok := fS.(nef.SymlinkFS)
+		if !ok {
+			return fmt.Errorf("fixture: symbolic link %q requires nef.SymlinkFS", path)
+		}
+
+		if err := linker.Symlink(n.Link, path); err != nil {
+			return err
+		}
+
+		// the times of the destination would be changed, so they are not set
+		return nil
+
+	case n.Dir:
+		if n.Name != "." {
+			if err := fS.MakeDirAll(path, lo.Ternary(n.Mode == 0, lab.Perms.Dir, n.Mode)); err != nil {
+				return err
+			}
+		}
+
+		if err := Fixture(n.Children).Build(fS, path); err != nil {
+			return err
+		}
+
+	default:
+		if err := fS.WriteFile(path, []byte(n.Content), lo.Ternary(n.Mode == 0, lab.Perms.File, n.Mode)); err != nil {
+			return err
+		}
+	}
+
+	if n.ModTime.IsZero() {
+		return nil
+	}
+
+	changer, ok := fS.(nef.ChangeTimesFS)
+	if !ok {
+		return fmt.Errorf("fixture: modification time of %q requires nef.ChangeTimesFS", path)
+	}
+
+	return changer.Chtimes(path, n.ModTime, n.ModTime)
+}
+
+// DumpOptions defines which attributes are captured by DumpFixture, in
+// addition to the structure, content and symbolic links.
+type DumpOptions struct {
+	// Mode captures the permission of every item
+	Mode bool
+	// ModTime captures the modification time of every item, except
+	// symbolic links
+	ModTime bool
+}
+
+// DumpFixture reads the tree under root in fS into a Fixture. Dumping a
+// symbolic link requires fS to implement nef.SymlinkFS.
+func DumpFixture(fS nef.ReaderFS, root string, options DumpOptions) (Fixture, error) {
+	entries, err := fS.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := make(Fixture, 0, len(entries))
+
+	for _, entry := range entries {
+		path := childOf(fS, root, entry.Name())
+		node := &Node{
+			Name: entry.Name(),
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case entry.Type()&fs.ModeSymlink != 0:
+			linker, ok := fS.(nef.SymlinkFS)
+			if !ok {
+				return nil, fmt.Errorf("fixture: symbolic link %q requires nef.SymlinkFS", path)
+			}
+
+			if node.Link, err = linker.ReadLink(path); err != nil {
+				return nil, err
+			}
+
+			fixture = append(fixture, node)
+
+			continue
+
+		case entry.IsDir():
+			node.Dir = true
+
+			children, err := DumpFixture(fS, path, options)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(children) > 0 {
+				node.Children = children
+			}
+
+		default:
+			content, err := fS.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = string(content)
+		}
+
+		if options.Mode {
+			node.Mode = info.Mode().Perm()
+		}
+
+		if options.ModTime {
+			node.ModTime = info.ModTime().UTC()
+		}
+
+		fixture = append(fixture, node)
+	}
+
+	return fixture, nil
+}
+
+// 🧩 ---> yaml
+
+// yamlNode is the YAML representation of a Node, eg:
+//
+//	# a directory, containing a file and a link to it
+//	- name: site
+//	  dir: true
+//	  mode: "0755"
+//	  children:
+//	    - name: index.html
+//	      content: <html/>
+//	      mtime: 2024-03-01T12:00:00Z
+//	    - name: home.html
+//	      link: index.html
+//
+// A node with children, or whose name ends with '/', is a directory.
+type yamlNode struct {
+	Name     string      `yaml:"name"`
+	Dir      bool        `yaml:"dir,omitempty"`
+	Content  string      `yaml:"content,omitempty"`
+	Mode     string      `yaml:"mode,omitempty"`
+	ModTime  string      `yaml:"mtime,omitempty"`
+	Link     string      `yaml:"link,omitempty"`
+	Children []*yamlNode `yaml:"children,omitempty"`
+}
+
+// ParseFixtureYAML parses a fixture written in YAML, as a sequence of
+// nodes with the fields name, dir, content, mode (octal), mtime (RFC 3339),
+// link and children.
+func ParseFixtureYAML(data []byte) (Fixture, error) {
+	var nodes []*yamlNode
+	if err := yaml.Unmarshal(data, &nodes); err != nil {
+		return nil, err
+	}
+
+	return fromYAML(nodes)
+}
+
+func fromYAML(nodes []*yamlNode) (Fixture, error) {
+	fixture := make(Fixture, 0, len(nodes))
+
+	for _, y := range nodes {
+		name, dir := strings.CutSuffix(y.Name, "/")
+		node := &Node{
+			Name:    name,
+			Dir:     dir || y.Dir || len(y.Children) > 0,
+			Content: y.Content,
+			Link:    y.Link,
+		}
+
+		if err := node.attribute(y.Mode); y.Mode != "" && err != nil {
+			return nil, err
+		}
+
+		if err := node.attribute(y.ModTime); y.ModTime != "" && err != nil {
+			return nil, err
+		}
+
+		children, err := fromYAML(y.Children)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(children) > 0 {
+			node.Children = children
+		}
+
+		fixture = append(fixture, node)
+	}
+
+	return fixture, nil
+}
+
+// YAML returns the fixture in the format accepted by ParseFixtureYAML
+func (f Fixture) YAML() ([]byte, error) {
+	return yaml.Marshal(f.toYAML())
+}
+
+func (f Fixture) toYAML() []*yamlNode {
+	nodes := make([]*yamlNode, 0, len(f))
+
+	for _, node := range f {
+		y := &yamlNode{
+			Name:     node.Name,
+			Dir:      node.Dir,
+			Content:  node.Content,
+			Link:     node.Link,
+			Children: Fixture(node.Children).toYAML(),
+		}
+
+		if node.Mode != 0 {
+			y.Mode = formatMode(node.Mode)
+		}
+
+		if !node.ModTime.IsZero() {
+			y.ModTime = node.ModTime.Format(time.RFC3339Nano)
+		}
+
+		if len(y.Children) == 0 {
+			y.Children = nil
+		}
+
+		nodes = append(nodes, y)
+	}
+
+	return nodes
+}
+
+func formatMode(mode fs.FileMode) string {
+	return fmt.Sprintf("%04o", uint32(mode.Perm()))
+}
+
+// attribute sets the mode (octal with leading 0) or modification time
+// (RFC 3339) of the node from its textual form
+func (n *Node) attribute(value string) error {
+	if strings.HasPrefix(value, "0") {
+		if mode, err := strconv.ParseUint(value, 8, 32); err == nil {
+			n.Mode = fs.FileMode(mode).Perm()
+
+			return nil
+		}
+	}
+
+	modTime, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return fmt.Errorf("fixture: invalid attribute %q, expected octal mode or RFC 3339 time", value)
+	}
+
+	n.ModTime = modTime
+
+	return nil
+}
+
+// 🧩 ---> text
+
+const (
+	textIndent    = "  "
+	textContent   = ": "
+	textLink      = " -> "
+	textAttribute = " ["
+)
+
+// ParseFixtureText parses a fixture written in an indented text format,
+// with one item per line, eg:
+//
+//	site/ [0755]
+//	  index.html: <html/>
+//	  css/
+//	    site.css [0600 2024-03-01T12:00:00Z]: body {}
+//	  home.html -> index.html
+//	  notes.txt: "line 1\nline 2\n"
+//
+// A name ending in '/' denotes a directory, whose children are indented
+// beneath it. The optional attributes in square brackets are an octal
+// mode and/or an RFC 3339 modification time. The content of a file follows
+// ": " and is unquoted if it's a quoted Go string; the destination of a
+// symbolic link follows " -> ". Blank lines and lines starting with '#'
+// are ignored. Consequently, names may not contain ": ", " -> " or " [".
+func ParseFixtureText(text string) (Fixture, error) {
+	type level struct {
+		indent int
+		node   *Node
+	}
+
+	fixture := Fixture{}
+	stack := []level{}
+
+	for number, line := range strings.Split(text, "\n") {
+		body := strings.TrimLeft(line, " ")
+		indent := len(line) - len(body)
+		body = strings.TrimRight(body, " \t\r")
+
+		if body == "" || strings.HasPrefix(body, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(body, "\t") {
+			return nil, fmt.Errorf("fixture: line %v: indent with spaces, not tabs", number+1)
+		}
+
+		node, err := parseLine(body)
+		if err != nil {
+			return nil, fmt.Errorf("fixture: line %v: %w", number+1, err)
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) == 0 {
+			fixture = append(fixture, node)
+		} else {
+			parent := stack[len(stack)-1].node
+			parent.Children = append(parent.Children, node)
+		}
+
+		if node.Dir {
+			stack = append(stack, level{indent: indent, node: node})
+		}
+	}
+
+	return fixture, nil
+}
+
+func parseLine(body string) (*Node, error) {
+	head, node := body, &Node{}
+	contentAt := strings.Index(body, textContent)
+	linkAt := strings.Index(body, textLink)
+
+	switch {
+	case linkAt >= 0 && (contentAt < 0 || linkAt < contentAt):
+		head, node.Link = body[:linkAt], body[linkAt+len(textLink):]
+
+	case contentAt >= 0:
+		head, node.Content = body[:contentAt], body[contentAt+len(textContent):]
+
+		if strings.HasPrefix(node.Content, `"`) {
+			content, err := strconv.Unquote(node.Content)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted content: %w", err)
+			}
+
+			node.Content = content
+		}
+
+	default:
+		head = strings.TrimSuffix(head, ":")
+	}
+
+	if at := strings.LastIndex(head, textAttribute); at >= 0 && strings.HasSuffix(head, "]") {
+		for _, attribute := range strings.Fields(head[at+len(textAttribute) : len(head)-1]) {
+			if err := node.attribute(attribute); err != nil {
+				return nil, err
+			}
+		}
+
+		head = head[:at]
+	}
+
+	node.Name, node.Dir = strings.CutSuffix(head, "/")
+
+	if node.Name == "" {
+		return nil, errors.New("missing name")
+	}
+
+	if node.Dir && (node.Content != "" || node.Link != "") {
+		return nil, fmt.Errorf("directory %q can't have content or link", node.Name)
+	}
+
+	return node, nil
+}
+
+// Text returns the fixture in the format accepted by ParseFixtureText
+func (f Fixture) Text() string {
+	var builder strings.Builder
+
+	f.text(&builder, "")
+
+	return builder.String()
+}
+
+func (f Fixture) text(builder *strings.Builder, indent string) {
+	for _, node := range f {
+		builder.WriteString(indent + node.Name)
+
+		if node.Dir {
+			builder.WriteString("/")
+		}
+
+		var attributes []string
+
+		if node.Mode != 0 {
+			attributes = append(attributes, formatMode(node.Mode))
+		}
+
+		if !node.ModTime.IsZero() {
+			attributes = append(attributes, node.ModTime.Format(time.RFC3339Nano))
+		}
+
+		if len(attributes) > 0 {
+			builder.WriteString(textAttribute + strings.Join(attributes, " ") + "]")
+		}
+
+		switch {
+		case node.Link != "":
+			builder.WriteString(textLink + node.Link)
+
+		case !node.Dir && node.Content != "":
+			builder.WriteString(textContent + encodeContent(node.Content))
+		}
+
+		builder.WriteString("\n")
+
+		if node.Dir {
+			Fixture(node.Children).text(builder, indent+textIndent)
+		}
+	}
+}
+
+func encodeContent(content string) string {
+	if strings.ContainsAny(content, "\n\r\t") || strings.HasPrefix(content, `"`) ||
+		strings.TrimSpace(content) != content {
+		return strconv.Quote(content)
+	}
+
+	return content
+}
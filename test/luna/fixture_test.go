@@ -0,0 +1,241 @@
+package luna_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+var _ = Describe("Fixture", func() {
+	var (
+		stamp   time.Time
+		fixture luna.Fixture
+	)
+
+	BeforeEach(func() {
+		stamp = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+		fixture = luna.Fixture{
+			luna.Dir("site",
+				luna.File("index.html", "<html/>"),
+				luna.Dir("css",
+					luna.File("site.css", "body {}").WithMode(0o600).WithModTime(stamp),
+				).WithModTime(stamp),
+				luna.File("notes.txt", "line 1\nline 2\n"),
+				luna.Dir("empty"),
+				luna.Symlink("home.html", "index.html"),
+			),
+			luna.File("a/b/c.txt", "c"),
+		}
+	})
+
+	Context("Build", func() {
+		When("given: MemFS", func() {
+			It("🧪 should: materialise tree", func() {
+				memFS := luna.NewMemFS()
+				Expect(fixture.Build(memFS, ".")).To(Succeed())
+
+				Expect(".").To(luna.MatchTree(memFS, luna.Tree{
+					"site/index.html":   "<html/>",
+					"site/css/site.css": "body {}",
+					"site/notes.txt":    "line 1\nline 2\n",
+					"site/empty/":       "",
+					"site/home.html":    "<html/>",
+					"a/b/c.txt":         "c",
+				}))
+				Expect(luna.AsFile("site/css/site.css")).To(luna.HaveFileMode(memFS, 0o600))
+
+				info, err := memFS.Stat("site/css")
+				Expect(err).To(Succeed())
+				Expect(info.ModTime()).To(BeTemporally("==", stamp))
+
+				link, err := memFS.ReadLink("site/home.html")
+				Expect(err).To(Succeed())
+				Expect(link).To(Equal("index.html"))
+			})
+		})
+
+		When("given: relative file system", func() {
+			It("🧪 should: materialise tree", func() {
+				fS := nef.NewUniversalFS(nef.Rel{
+					Root: GinkgoT().TempDir(),
+				})
+				Expect(fixture.Build(fS, ".")).To(Succeed())
+
+				Expect(luna.AsDirectory("site")).To(luna.MatchTree(fS, luna.Tree{
+					"index.html":   "<html/>",
+					"css/site.css": "body {}",
+					"notes.txt":    "line 1\nline 2\n",
+					"empty/":       "",
+					"home.html":    "<html/>",
+				}))
+				Expect(luna.AsFile("site/css/site.css")).To(luna.HaveFileMode(fS, 0o600))
+
+				info, err := fS.Stat("site/css")
+				Expect(err).To(Succeed())
+				Expect(info.ModTime()).To(BeTemporally("==", stamp))
+			})
+		})
+
+		When("given: file system without symbolic links", func() {
+			It("🧪 should: return error", func() {
+				fS := nef.NewUniversalABS()
+				Expect(luna.Fixture{
+					luna.Symlink("link", "target"),
+				}.Build(&withoutSymlinks{UniversalFS: fS}, GinkgoT().TempDir())).To(
+					MatchError(ContainSubstring("requires nef.SymlinkFS")),
+				)
+			})
+		})
+	})
+
+	Context("DumpFixture", func() {
+		It("🧪 should: dump tree built from fixture", func() {
+			memFS := luna.NewMemFS()
+			Expect(fixture.Build(memFS, ".")).To(Succeed())
+
+			dumped, err := luna.DumpFixture(memFS, "site", luna.DumpOptions{})
+			Expect(err).To(Succeed())
+			Expect(dumped.Text()).To(Equal(`css/
+  site.css: body {}
+empty/
+home.html -> index.html
+index.html: <html/>
+notes.txt: "line 1\nline 2\n"
+`))
+		})
+
+		When("given: mode and mod time", func() {
+			It("🧪 should: dump attributes", func() {
+				fS := nef.NewUniversalFS(nef.Rel{
+					Root: GinkgoT().TempDir(),
+				})
+				Expect(fixture.Build(fS, ".")).To(Succeed())
+
+				dumped, err := luna.DumpFixture(fS, "site/css", luna.DumpOptions{
+					Mode:    true,
+					ModTime: true,
+				})
+				Expect(err).To(Succeed())
+				Expect(dumped).To(Equal(luna.Fixture{
+					luna.File("site.css", "body {}").WithMode(0o600).WithModTime(stamp),
+				}))
+			})
+		})
+	})
+
+	Context("text format", func() {
+		It("🧪 should: parse", func() {
+			parsed, err := luna.ParseFixtureText(`
+# web site
+site/
+  index.html: <html/>
+  css/ [2024-03-01T12:00:00Z]
+    site.css [0600 2024-03-01T12:00:00Z]: body {}
+  notes.txt: "line 1\nline 2\n"
+  empty/
+  home.html -> index.html
+a/b/c.txt: c
+`)
+			Expect(err).To(Succeed())
+			Expect(parsed).To(Equal(luna.Fixture{
+				luna.Dir("site",
+					luna.File("index.html", "<html/>"),
+					luna.Dir("css",
+						luna.File("site.css", "body {}").WithMode(0o600).WithModTime(stamp),
+					).WithModTime(stamp),
+					luna.File("notes.txt", "line 1\nline 2\n"),
+					luna.Dir("empty"),
+					luna.Symlink("home.html", "index.html"),
+				),
+				luna.File("a/b/c.txt", "c"),
+			}))
+		})
+
+		It("🧪 should: round trip", func() {
+			parsed, err := luna.ParseFixtureText(fixture.Text())
+			Expect(err).To(Succeed())
+			Expect(parsed).To(Equal(fixture))
+		})
+
+		DescribeTable("invalid",
+			func(text, reason string) {
+				_, err := luna.ParseFixtureText(text)
+				Expect(err).To(MatchError(ContainSubstring(reason)))
+			},
+			Entry(nil, "site/: content", "can't have content"),
+			Entry(nil, "a.txt [rwx]", "invalid attribute"),
+			Entry(nil, `a.txt: "unterminated`, "invalid quoted content"),
+			Entry(nil, "\tsite/", "tabs"),
+		)
+	})
+
+	Context("YAML", func() {
+		It("🧪 should: parse", func() {
+			parsed, err := luna.ParseFixtureYAML([]byte(`
+- name: site/
+  children:
+    - name: css
+      mtime: 2024-03-01T12:00:00Z
+      children:
+        - name: site.css
+          content: body {}
+          mode: "0600"
+          mtime: 2024-03-01T12:00:00Z
+    - name: empty
+      dir: true
+    - name: home.html
+      link: index.html
+`))
+			Expect(err).To(Succeed())
+			Expect(parsed).To(Equal(luna.Fixture{
+				luna.Dir("site",
+					luna.Dir("css",
+						luna.File("site.css", "body {}").WithMode(0o600).WithModTime(stamp),
+					).WithModTime(stamp),
+					luna.Dir("empty"),
+					luna.Symlink("home.html", "index.html"),
+				),
+			}))
+		})
+
+		It("🧪 should: parse documented example", func() {
+			parsed, err := luna.ParseFixtureYAML([]byte(`
+# a directory, containing a file and a link to it
+- name: site
+  dir: true
+  mode: "0755"
+  children:
+    - name: index.html
+      content: <html/>
+      mtime: 2024-03-01T12:00:00Z
+    - name: home.html
+      link: index.html
+`))
+			Expect(err).To(Succeed())
+			Expect(parsed).To(Equal(luna.Fixture{
+				luna.Dir("site",
+					luna.File("index.html", "<html/>").WithModTime(stamp),
+					luna.Symlink("home.html", "index.html"),
+				).WithMode(0o755),
+			}))
+		})
+
+		It("🧪 should: round trip", func() {
+			data, err := fixture.YAML()
+			Expect(err).To(Succeed())
+
+			parsed, err := luna.ParseFixtureYAML(data)
+			Expect(err).To(Succeed())
+			Expect(parsed).To(Equal(fixture))
+		})
+	})
+})
+
+// withoutSymlinks hides the nef.SymlinkFS implementation of a file system
+type withoutSymlinks struct {
+	nef.UniversalFS
+}
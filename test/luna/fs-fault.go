@@ -0,0 +1,424 @@
+package luna
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	nef "github.com/snivilised/nefilim"
+	"github.com/snivilised/nefilim/internal/third/lo"
+)
+
+// Fault defines a failure injected by FaultFS into the operations it
+// matches. Typical errors are syscall.EACCES, syscall.ENOSPC and
+// syscall.EXDEV, which are wrapped in an fs.PathError (or os.LinkError for
+// operations that take 2 paths), so they can be detected with errors.Is
+// in the same way as a genuine failure, eg errors.Is(err, fs.ErrPermission).
+type Fault struct {
+	// Op is the name of the operation to fail, which is the name of the
+	// method, eg "WriteFile", or "Write" for a write to a file returned by
+	// Create; empty matches any operation.
+	Op string
+	// Pattern is matched (see path.Match) against the path(s) of the
+	// operation; a pattern containing a '/' is matched against the whole
+	// path, otherwise against the base name. Empty matches any path.
+	Pattern string
+	// Nth denotes that the fault only fires on the Nth (1 based) matching
+	// call; when 0, the fault fires on every matching call, subject to
+	// Probability.
+	Nth int
+	// Probability is the chance (0 < p < 1) that the fault fires on a
+	// matching call, determined by the seeded random source of the FaultFS;
+	// 0 denotes certainty. Ignored when Nth is specified.
+	Probability float64
+	// Err is the error returned when the fault fires; may be nil for a
+	// fault that only introduces latency.
+	Err error
+	// ShortWrite is the number of bytes that are actually written by a
+	// WriteFile or Write that the fault fires on, after which Err (or
+	// io.ErrShortWrite if Err is nil) is returned.
+	ShortWrite int
+	// Latency is the delay introduced before the operation proceeds or fails
+	Latency time.Duration
+}
+
+type faultState struct {
+	Fault
+	calls int
+}
+
+func (s *faultState) matches(op string, paths []string) bool {
+	if s.Op != "" && s.Op != op {
+		return false
+	}
+
+	if s.Pattern == "" {
+		return true
+	}
+
+	return slices.ContainsFunc(paths, func(p string) bool {
+		subject := lo.Ternary(strings.Contains(s.Pattern, "/"), p, path.Base(p))
+		matched, _ := path.Match(s.Pattern, subject)
+
+		return matched
+	})
+}
+
+func (s *faultState) fires(random *rand.Rand) bool {
+	if s.Nth > 0 {
+		return s.calls == s.Nth
+	}
+
+	return s.Probability <= 0 || random.Float64() < s.Probability
+}
+
+// FaultFS is a decorator over a UniversalFS, that injects faults into
+// its operations, so that tests can drive the error handling of client
+// code. Random faults are determined by the seed, so a failing run can be
+// reproduced. FileExists and DirectoryExists are not subject to faults,
+// since they can't report an error.
+//
+// If the decorated file system is a RoutedFS (as the relative file system
+// is), the stat and rename calls made by Move and Change are routed
+// through the FaultFS, so they are subject to faults on "Stat" and
+// "Rename", which drive the failure branches within those operations.
+type FaultFS struct {
+	nef.UniversalFS
+	mutex  sync.Mutex
+	random *rand.Rand
+	faults []*faultState
+	fired  int
+}
+
+var (
+	_ nef.UniversalFS   = (*FaultFS)(nil)
+	_ nef.ChangeTimesFS = (*FaultFS)(nil)
+	_ nef.MoverFS       = (*FaultFS)(nil)
+	_ nef.ChangerFS     = (*FaultFS)(nil)
+)
+
+// NewFaultFS creates a FaultFS over fS, that injects faults, whose
+// random source is seeded with seed.
+func NewFaultFS(fS nef.UniversalFS, seed uint64, faults ...Fault) *FaultFS {
+	f := &FaultFS{
+		UniversalFS: fS,
+		random:      rand.New(rand.NewPCG(seed, seed)), //nolint:gosec // ok, reproducible
+	}
+
+	for _, fault := range faults {
+		f.Inject(fault)
+	}
+
+	return f
+}
+
+// Inject adds a fault
+func (f *FaultFS) Inject(fault Fault) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.faults = append(f.faults, &faultState{Fault: fault})
+}
+
+// Reset removes all faults
+func (f *FaultFS) Reset() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.faults = nil
+	f.fired = 0
+}
+
+// Fired returns the number of times a fault has fired
+func (f *FaultFS) Fired() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.fired
+}
+
+// fire returns the first fault that fires for the operation, after
+// waiting for its latency. Every matching fault counts the call.
+func (f *FaultFS) fire(op string, paths ...string) *Fault {
+	f.mutex.Lock()
+
+	var fired *Fault
+
+	for _, state := range f.faults {
+		if !state.matches(op, paths) {
+			continue
+		}
+
+		state.calls++
+
+		if state.fires(f.random) && fired == nil {
+			fired = &state.Fault
+			f.fired++
+		}
+	}
+
+	f.mutex.Unlock()
+
+	if fired != nil && fired.Latency > 0 {
+		time.Sleep(fired.Latency)
+	}
+
+	return fired
+}
+
+func (f *FaultFS) unary(op, name string) error {
+	if fault := f.fire(op, name); fault != nil && fault.Err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: fault.Err}
+	}
+
+	return nil
+}
+
+func (f *FaultFS) binary(op, from, to string) error {
+	if fault := f.fire(op, from, to); fault != nil && fault.Err != nil {
+		return &os.LinkError{Op: op, Old: from, New: to, Err: fault.Err}
+	}
+
+	return nil
+}
+
+// Open opens the named item, unless a fault fires
+func (f *FaultFS) Open(name string) (fs.File, error) {
+	if err := f.unary("Open", name); err != nil {
+		return nil, err
+	}
+
+	return f.UniversalFS.Open(name)
+}
+
+// Stat returns the info of the named item, unless a fault fires
+func (f *FaultFS) Stat(name string) (fs.FileInfo, error) {
+	if err := f.unary("Stat", name); err != nil {
+		return nil, err
+	}
+
+	return f.UniversalFS.Stat(name)
+}
+
+// ReadDir reads the named directory, unless a fault fires
+func (f *FaultFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := f.unary("ReadDir", name); err != nil {
+		return nil, err
+	}
+
+	return f.UniversalFS.ReadDir(name)
+}
+
+// ReadFile reads the named file, unless a fault fires
+func (f *FaultFS) ReadFile(name string) ([]byte, error) {
+	if err := f.unary("ReadFile", name); err != nil {
+		return nil, err
+	}
+
+	return f.UniversalFS.ReadFile(name)
+}
+
+// MakeDir creates the named directory, unless a fault fires
+func (f *FaultFS) MakeDir(name string, perm os.FileMode) error {
+	if err := f.unary("MakeDir", name); err != nil {
+		return err
+	}
+
+	return f.UniversalFS.MakeDir(name, perm)
+}
+
+// MakeDirAll creates the named directory and any missing parents, unless
+// a fault fires
+func (f *FaultFS) MakeDirAll(name string, perm os.FileMode) error {
+	if err := f.unary("MakeDirAll", name); err != nil {
+		return err
+	}
+
+	return f.UniversalFS.MakeDirAll(name, perm)
+}
+
+// Ensure makes sure that a path exists, unless a fault fires
+func (f *FaultFS) Ensure(as nef.PathAs) (string, error) {
+	if err := f.unary("Ensure", as.Name); err != nil {
+		return "", err
+	}
+
+	return f.UniversalFS.Ensure(as)
+}
+
+// Move moves an item, unless a fault fires; the stat and rename calls it
+// makes are subject to faults, if the decorated file system is a RoutedFS.
+func (f *FaultFS) Move(from, to string) error {
+	if err := f.binary("Move", from, to); err != nil {
+		return err
+	}
+
+	if routed, ok := f.UniversalFS.(nef.RoutedFS); ok {
+		return routed.MoveVia(f, from, to)
+	}
+
+	return f.UniversalFS.Move(from, to)
+}
+
+// Change renames an item within its directory, unless a fault fires; the
+// stat and rename calls it makes are subject to faults, if the decorated
+// file system is a RoutedFS.
+func (f *FaultFS) Change(from, to string) error {
+	if err := f.binary("Change", from, to); err != nil {
+		return err
+	}
+
+	if routed, ok := f.UniversalFS.(nef.RoutedFS); ok {
+		return routed.ChangeVia(f, from, to)
+	}
+
+	return f.UniversalFS.Change(from, to)
+}
+
+// Copy copies an item, unless a fault fires
+func (f *FaultFS) Copy(from, to string) error {
+	if err := f.binary("Copy", from, to); err != nil {
+		return err
+	}
+
+	return f.UniversalFS.Copy(from, to)
+}
+
+// CopyFS copies fsys into dir, unless a fault fires
+func (f *FaultFS) CopyFS(dir string, fsys fs.FS) error {
+	if err := f.unary("CopyFS", dir); err != nil {
+		return err
+	}
+
+	return f.UniversalFS.CopyFS(dir, fsys)
+}
+
+// Remove removes the named item, unless a fault fires
+func (f *FaultFS) Remove(name string) error {
+	if err := f.unary("Remove", name); err != nil {
+		return err
+	}
+
+	return f.UniversalFS.Remove(name)
+}
+
+// RemoveAll removes path and any children, unless a fault fires
+func (f *FaultFS) RemoveAll(path string) error {
+	if err := f.unary("RemoveAll", path); err != nil {
+		return err
+	}
+
+	return f.UniversalFS.RemoveAll(path)
+}
+
+// Rename renames an item, unless a fault fires
+func (f *FaultFS) Rename(from, to string) error {
+	if err := f.binary("Rename", from, to); err != nil {
+		return err
+	}
+
+	return f.UniversalFS.Rename(from, to)
+}
+
+// Chtimes changes the times of the named item, unless a fault fires; an
+// errors.ErrUnsupported error is returned if the decorated file system is
+// not a ChangeTimesFS.
+func (f *FaultFS) Chtimes(name string, atime, mtime time.Time) error {
+	if err := f.unary("Chtimes", name); err != nil {
+		return err
+	}
+
+	changer, ok := f.UniversalFS.(nef.ChangeTimesFS)
+	if !ok {
+		return &fs.PathError{Op: "Chtimes", Path: name, Err: errors.ErrUnsupported}
+	}
+
+	return changer.Chtimes(name, atime, mtime)
+}
+
+// WriteFile writes the named file, unless a fault fires; a short write
+// fault writes the truncated data before failing.
+func (f *FaultFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	fault := f.fire("WriteFile", name)
+	if fault == nil {
+		return f.UniversalFS.WriteFile(name, data, perm)
+	}
+
+	if fault.ShortWrite > 0 && fault.ShortWrite < len(data) {
+		if err := f.UniversalFS.WriteFile(name, data[:fault.ShortWrite], perm); err != nil {
+			return err
+		}
+
+		return &fs.PathError{Op: "WriteFile", Path: name,
+			Err: lo.Ternary(fault.Err != nil, fault.Err, io.ErrShortWrite),
+		}
+	}
+
+	if fault.Err != nil {
+		return &fs.PathError{Op: "WriteFile", Path: name, Err: fault.Err}
+	}
+
+	return f.UniversalFS.WriteFile(name, data, perm)
+}
+
+// Create creates the named file, unless a fault fires; the "Write"
+// operation of the file returned is also subject to faults.
+func (f *FaultFS) Create(name string) (fs.File, error) {
+	if err := f.unary("Create", name); err != nil {
+		return nil, err
+	}
+
+	file, err := f.UniversalFS.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &faultFile{
+		File: file,
+		fS:   f,
+		name: name,
+	}, nil
+}
+
+type faultFile struct {
+	fs.File
+	fS   *FaultFS
+	name string
+}
+
+// Write writes p to the file, unless a fault fires
+func (f *faultFile) Write(p []byte) (int, error) {
+	writer, ok := f.File.(io.Writer)
+	if !ok {
+		return 0, &fs.PathError{Op: "Write", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	fault := f.fS.fire("Write", f.name)
+	if fault == nil {
+		return writer.Write(p)
+	}
+
+	if fault.ShortWrite > 0 && fault.ShortWrite < len(p) {
+		n, err := writer.Write(p[:fault.ShortWrite])
+		if err != nil {
+			return n, err
+		}
+
+		return n, &fs.PathError{Op: "Write", Path: f.name,
+			Err: lo.Ternary(fault.Err != nil, fault.Err, io.ErrShortWrite),
+		}
+	}
+
+	if fault.Err != nil {
+		return 0, &fs.PathError{Op: "Write", Path: f.name, Err: fault.Err}
+	}
+
+	return writer.Write(p)
+}
@@ -0,0 +1,176 @@
+package luna_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+var _ = Describe("FaultFS", func() {
+	var memFS *luna.MemFS
+
+	BeforeEach(func() {
+		memFS = luna.NewMemFS()
+	})
+
+	When("given: fault on Nth call", func() {
+		It("🧪 should: only fail that call", func() {
+			fS := luna.NewFaultFS(memFS, 0, luna.Fault{
+				Op:  "WriteFile",
+				Nth: 2,
+				Err: syscall.ENOSPC,
+			})
+
+			Expect(fS.WriteFile("a.txt", data, lab.Perms.File)).To(Succeed())
+			err := fS.WriteFile("b.txt", data, lab.Perms.File)
+			Expect(errors.Is(err, syscall.ENOSPC)).To(BeTrue())
+			Expect(fS.WriteFile("c.txt", data, lab.Perms.File)).To(Succeed())
+			Expect(fS.FileExists("b.txt")).To(BeFalse())
+			Expect(fS.Fired()).To(Equal(1))
+		})
+	})
+
+	When("given: fault selected by path pattern", func() {
+		It("🧪 should: only fail matching paths", func() {
+			fS := luna.NewFaultFS(memFS, 0, luna.Fault{
+				Op:      "Rename",
+				Pattern: "locked/*",
+				Err:     syscall.EXDEV,
+			}, luna.Fault{
+				Pattern: "*.key",
+				Err:     syscall.EACCES,
+			})
+			Expect(fS.WriteFile("a.txt", data, lab.Perms.File)).To(Succeed())
+
+			err := fS.Rename("a.txt", "locked/a.txt")
+			Expect(errors.Is(err, syscall.EXDEV)).To(BeTrue())
+
+			_, err = fS.ReadFile("secret.key")
+			Expect(err).To(MatchError(fs.ErrPermission))
+			Expect(fS.Rename("a.txt", "b.txt")).To(Succeed())
+		})
+	})
+
+	When("given: short write", func() {
+		It("🧪 should: write truncated content", func() {
+			fS := luna.NewFaultFS(memFS, 0, luna.Fault{
+				Op:         "WriteFile",
+				ShortWrite: 4,
+			}, luna.Fault{
+				Op:         "Write",
+				ShortWrite: 2,
+				Err:        syscall.ENOSPC,
+			})
+
+			err := fS.WriteFile("a.txt", data, lab.Perms.File)
+			Expect(err).To(MatchError(io.ErrShortWrite))
+			Expect(memFS.ReadFile("a.txt")).To(Equal(data[:4]))
+
+			file, err := fS.Create("b.txt")
+			Expect(err).To(Succeed())
+			n, err := file.(io.Writer).Write(data)
+			Expect(n).To(Equal(2))
+			Expect(errors.Is(err, syscall.ENOSPC)).To(BeTrue())
+		})
+	})
+
+	When("given: random faults with same seed", func() {
+		It("🧪 should: fail the same calls", func() {
+			outcomes := func() []bool {
+				fS := luna.NewFaultFS(memFS, 42, luna.Fault{
+					Op:          "Stat",
+					Probability: 0.5,
+					Err:         syscall.EACCES,
+				})
+				results := make([]bool, 0, 32)
+
+				for range 32 {
+					_, err := fS.Stat("a.txt")
+					results = append(results, errors.Is(err, syscall.EACCES))
+				}
+
+				return results
+			}
+
+			first := outcomes()
+			Expect(first).To(ContainElement(true))
+			Expect(first).To(ContainElement(false))
+			Expect(outcomes()).To(Equal(first))
+		})
+	})
+
+	When("given: latency", func() {
+		It("🧪 should: delay the operation", func() {
+			fS := luna.NewFaultFS(memFS, 0, luna.Fault{
+				Op:      "MakeDir",
+				Latency: 20 * time.Millisecond,
+			})
+
+			start := time.Now()
+			Expect(fS.MakeDir("slow", lab.Perms.Dir)).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically(">=", 20*time.Millisecond))
+		})
+	})
+
+	DescribeTable("fault on call made internally",
+		func(op, method string, fault error) {
+			fS := luna.NewFaultFS(nef.NewUniversalFS(nef.Rel{
+				Root: GinkgoT().TempDir(),
+			}), 0)
+			Expect(fS.WriteFile("a.txt", data, lab.Perms.File)).To(Succeed())
+			Expect(fS.MakeDir("archive", lab.Perms.Dir)).To(Succeed())
+			fS.Inject(luna.Fault{
+				Op:  op,
+				Err: fault,
+			})
+
+			var err error
+			if method == "Move" {
+				err = fS.Move("a.txt", "archive")
+			} else {
+				err = fS.Change("a.txt", "b.txt")
+			}
+
+			Expect(err).To(MatchError(fault))
+			Expect(fS.Fired()).To(Equal(1))
+			Expect(fS.FileExists("a.txt")).To(BeTrue())
+		},
+		func(op, method string, _ error) string {
+			return fmt.Sprintf("🧪 ===> given: fault on '%v', should: fail %v", op, method)
+		},
+		Entry(nil, "Stat", "Move", syscall.EACCES),
+		Entry(nil, "Rename", "Move", syscall.EXDEV),
+		Entry(nil, "Stat", "Change", syscall.EACCES),
+		Entry(nil, "Rename", "Change", syscall.EXDEV),
+	)
+
+	When("given: decorated file system without Chtimes", func() {
+		It("🧪 should: return unsupported error", func() {
+			fS := luna.NewFaultFS(struct{ nef.UniversalFS }{memFS}, 0)
+			Expect(fS.WriteFile("a.txt", data, lab.Perms.File)).To(Succeed())
+			Expect(fS.Chtimes("a.txt", time.Now(), time.Now())).To(MatchError(errors.ErrUnsupported))
+		})
+	})
+
+	When("given: faults reset", func() {
+		It("🧪 should: no longer fail", func() {
+			fS := luna.NewFaultFS(memFS, 0, luna.Fault{
+				Err: syscall.EACCES,
+			})
+			Expect(fS.MakeDir("a", lab.Perms.Dir)).NotTo(Succeed())
+
+			fS.Reset()
+			Expect(fS.MakeDir("a", lab.Perms.Dir)).To(Succeed())
+		})
+	})
+})
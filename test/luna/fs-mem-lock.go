@@ -0,0 +1,176 @@
+package luna
+
+import (
+	"io/fs"
+	"path"
+	"sync"
+	"testing/fstest"
+	"time"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+)
+
+// memLocks is the in-process lock table of a MemFS. Any change to the
+// locks held is broadcast by closing changed, so that waiters can retry.
+type memLocks struct {
+	mu      sync.Mutex
+	held    map[string]*memLockState
+	changed chan struct{}
+}
+
+type memLockState struct {
+	exclusive bool
+	shared    int
+}
+
+func newMemLocks() *memLocks {
+	return &memLocks{
+		held:    make(map[string]*memLockState),
+		changed: make(chan struct{}),
+	}
+}
+
+// Lock acquires a lock of mode on name, waiting until any conflicting
+// lock is released. The locks are held in process, with the same
+// semantics as those of the relative file system, so that locking can be
+// tested without multiple processes.
+func (f *MemFS) Lock(name string, mode nef.LockMode) (nef.FileLock, error) {
+	return f.lock("Lock", name, mode, -1)
+}
+
+// TryLock acquires a lock of mode on name, without waiting
+func (f *MemFS) TryLock(name string, mode nef.LockMode) (nef.FileLock, error) {
+	return f.lock("TryLock", name, mode, 0)
+}
+
+// LockTimeout acquires a lock of mode on name, waiting at most timeout
+func (f *MemFS) LockTimeout(name string, mode nef.LockMode, timeout time.Duration) (nef.FileLock, error) {
+	return f.lock("LockTimeout", name, mode, max(timeout, 0))
+}
+
+func (f *MemFS) lock(op, name string, mode nef.LockMode, wait time.Duration) (nef.FileLock, error) {
+	if !fs.ValidPath(name) {
+		return nil, nef.NewInvalidPathError(op, name)
+	}
+
+	var deadline <-chan time.Time
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		deadline = timer.C
+	}
+
+	for {
+		f.locks.mu.Lock()
+
+		if err := f.ensureLockable(op, name); err != nil {
+			f.locks.mu.Unlock()
+
+			return nil, err
+		}
+
+		if f.locks.grant(name, mode) {
+			f.locks.mu.Unlock()
+
+			return &memLock{locks: f.locks, name: name, mode: mode}, nil
+		}
+
+		changed := f.locks.changed
+		f.locks.mu.Unlock()
+
+		if wait == 0 {
+			return nil, nef.NewLockedError(op, name, mode)
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return nil, nef.NewLockedError(op, name, mode)
+		}
+	}
+}
+
+// ensureLockable creates name, as an empty file, if it does not exist;
+// its parent must exist. It is invoked with the lock table locked and
+// locks the mutex of MemFS, which guards the map and the watchers.
+func (f *MemFS) ensureLockable(op, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.MapFS.Stat(name); err == nil {
+		return nil
+	}
+
+	if parent := path.Dir(name); parent != "." && !f.isDir(parent) {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	f.MapFS[name] = &fstest.MapFile{Mode: lab.Perms.File}
+	f.notify(nef.WatchCreate, name)
+
+	return nil
+}
+
+// grant records a lock of mode on name, if it does not conflict with
+// the locks held
+func (l *memLocks) grant(name string, mode nef.LockMode) bool {
+	state, found := l.held[name]
+	if !found {
+		state = &memLockState{}
+		l.held[name] = state
+	}
+
+	if state.exclusive || (mode == nef.LockExclusive && state.shared > 0) {
+		return false
+	}
+
+	if mode == nef.LockExclusive {
+		state.exclusive = true
+	} else {
+		state.shared++
+	}
+
+	return true
+}
+
+func (l *memLocks) release(name string, mode nef.LockMode) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := l.held[name]
+	if mode == nef.LockExclusive {
+		state.exclusive = false
+	} else {
+		state.shared--
+	}
+
+	if !state.exclusive && state.shared == 0 {
+		delete(l.held, name)
+	}
+
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// memLock is a lock held on an item of a MemFS
+type memLock struct {
+	locks *memLocks
+	name  string
+	mode  nef.LockMode
+	once  sync.Once
+}
+
+// Unlock releases the lock; releasing it again returns fs.ErrClosed
+func (l *memLock) Unlock() error {
+	err := fs.ErrClosed
+
+	l.once.Do(func() {
+		l.locks.release(l.name, l.mode)
+		err = nil
+	})
+
+	return err
+}
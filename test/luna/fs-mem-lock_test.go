@@ -0,0 +1,83 @@
+package luna_test
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+var _ = Describe("MemFS Lock", func() {
+	var memFS *luna.MemFS
+
+	BeforeEach(func() {
+		memFS = luna.NewMemFS()
+	})
+
+	It("🧪 should: wait until lock released by another goroutine", func() {
+		lock, err := memFS.Lock("state.json", nef.LockExclusive)
+		Expect(err).To(Succeed())
+
+		acquired := make(chan nef.FileLock)
+		go func() {
+			defer GinkgoRecover()
+
+			second, err := memFS.Lock("state.json", nef.LockExclusive)
+			Expect(err).To(Succeed())
+			acquired <- second
+		}()
+
+		Consistently(acquired).WithTimeout(20 * time.Millisecond).ShouldNot(Receive())
+		Expect(lock.Unlock()).To(Succeed())
+
+		var second nef.FileLock
+		Eventually(acquired).Should(Receive(&second))
+		Expect(second.Unlock()).To(Succeed())
+		Expect(second.Unlock()).To(MatchError(fs.ErrClosed))
+	})
+
+	When("given: items written and read while locked", func() {
+		It("🧪 should: guard the map against concurrent use", func() {
+			w, err := memFS.Watch(".")
+			Expect(err).To(Succeed())
+			defer w.Close()
+
+			var wg sync.WaitGroup
+
+			for i := range 8 {
+				wg.Go(func() {
+					defer GinkgoRecover()
+
+					lock, err := memFS.Lock("state.json", nef.LockShared)
+					Expect(err).To(Succeed())
+					defer lock.Unlock() //nolint:errcheck // test
+
+					name := fmt.Sprintf("item-%v.txt", i)
+					Expect(memFS.WriteFile(name, []byte(name), lab.Perms.File)).To(Succeed())
+					Expect(memFS.ReadFile(name)).To(Equal([]byte(name)))
+					_, err = memFS.ReadDir(".")
+					Expect(err).To(Succeed())
+				})
+			}
+			wg.Wait()
+
+			entries, err := memFS.ReadDir(".")
+			Expect(err).To(Succeed())
+			Expect(entries).To(HaveLen(9))
+		})
+	})
+
+	When("given: missing parent", func() {
+		It("🧪 should: fail", func() {
+			_, err := memFS.TryLock("missing/state.json", nef.LockShared)
+			Expect(err).To(MatchError(fs.ErrNotExist))
+		})
+	})
+})
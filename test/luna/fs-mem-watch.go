@@ -0,0 +1,110 @@
+package luna
+
+import (
+	"io/fs"
+	"slices"
+	"strings"
+
+	nef "github.com/snivilised/nefilim"
+)
+
+const memWatchBufferSize = 1024
+
+// Watch watches name and, if it is a directory, the tree beneath it. The
+// events are delivered by the operations of MemFS before they return, so
+// they can be received without waiting. A watcher whose events are not
+// received loses the events that do not fit in its buffer and reports
+// nef.ErrCoreWatchOverflow instead.
+func (f *MemFS) Watch(name string) (nef.Watcher, error) {
+	if !fs.ValidPath(name) {
+		return nil, nef.NewInvalidPathError("Watch", name)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.MapFS.Stat(name); err != nil {
+		return nil, &fs.PathError{Op: "watch", Path: name, Err: fs.ErrNotExist}
+	}
+
+	w := &memWatcher{
+		owner:  f,
+		name:   name,
+		events: make(chan nef.WatchEvent, memWatchBufferSize),
+		errors: make(chan error, 1),
+	}
+	f.watchers = append(f.watchers, w)
+
+	return w, nil
+}
+
+// notify delivers an event for the item at name to the watchers whose
+// tree contains it; it is invoked with the mutex of MemFS locked
+func (f *MemFS) notify(op nef.WatchOp, name string) {
+	for _, w := range f.watchers {
+		if w.contains(name) {
+			w.deliver(nef.WatchEvent{Path: name, Op: op})
+		}
+	}
+}
+
+// replaced notifies the watchers that the file at name has been stored.
+// An item that existed, such as a dangling symbolic link that Stat does
+// not find, is not reported as created; as with inotify, its replacement
+// is only reported as a write.
+func (f *MemFS) replaced(name string, existed, written bool) {
+	if !existed {
+		f.notify(nef.WatchCreate, name)
+	}
+
+	if written {
+		f.notify(nef.WatchWrite, name)
+	}
+}
+
+// memWatcher is the nef.Watcher of a MemFS
+type memWatcher struct {
+	owner  *MemFS
+	name   string
+	events chan nef.WatchEvent
+	errors chan error
+}
+
+// Events returns the channel on which events are delivered
+func (w *memWatcher) Events() <-chan nef.WatchEvent {
+	return w.events
+}
+
+// Errors returns the channel on which errors are delivered
+func (w *memWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Close stops the watch
+func (w *memWatcher) Close() error {
+	w.owner.mu.Lock()
+	defer w.owner.mu.Unlock()
+
+	if index := slices.Index(w.owner.watchers, w); index >= 0 {
+		w.owner.watchers = slices.Delete(w.owner.watchers, index, index+1)
+		close(w.events)
+		close(w.errors)
+	}
+
+	return nil
+}
+
+func (w *memWatcher) contains(name string) bool {
+	return w.name == "." || name == w.name || strings.HasPrefix(name, w.name+"/")
+}
+
+func (w *memWatcher) deliver(event nef.WatchEvent) {
+	select {
+	case w.events <- event:
+	default:
+		select {
+		case w.errors <- nef.ErrCoreWatchOverflow:
+		default:
+		}
+	}
+}
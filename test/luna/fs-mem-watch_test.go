@@ -0,0 +1,147 @@
+package luna_test
+
+import (
+	"fmt"
+	"io/fs"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+// pending returns the events that have already been delivered to w
+func pending(w nef.Watcher) []nef.WatchEvent {
+	var events []nef.WatchEvent
+
+	for {
+		select {
+		case event := <-w.Events():
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+var _ = Describe("MemFS Watch", func() {
+	var (
+		memFS *luna.MemFS
+		w     nef.Watcher
+	)
+
+	BeforeEach(func() {
+		memFS = luna.NewMemFS()
+		Expect(memFS.MakeDirAll("tree", lab.Perms.Dir)).To(Succeed())
+		Expect(memFS.MakeDirAll("other", lab.Perms.Dir)).To(Succeed())
+
+		var err error
+		w, err = memFS.Watch("tree")
+		Expect(err).To(Succeed())
+	})
+
+	It("🧪 should: deliver events before operation returns", func() {
+		Expect(memFS.WriteFile("tree/a.txt", []byte("a"), lab.Perms.File)).To(Succeed())
+		Expect(memFS.MakeDirAll("tree/x/y", lab.Perms.Dir)).To(Succeed())
+		Expect(memFS.Chtimes("tree/a.txt", time.Time{}, time.Now())).To(Succeed())
+		Expect(memFS.Rename("tree/a.txt", "tree/x/a.txt")).To(Succeed())
+		Expect(memFS.WriteFile("other/b.txt", nil, lab.Perms.File)).To(Succeed())
+		Expect(memFS.RemoveAll("tree/x")).To(Succeed())
+
+		Expect(pending(w)).To(Equal([]nef.WatchEvent{
+			{Path: "tree/a.txt", Op: nef.WatchCreate},
+			{Path: "tree/a.txt", Op: nef.WatchWrite},
+			{Path: "tree/x", Op: nef.WatchCreate},
+			{Path: "tree/x/y", Op: nef.WatchCreate},
+			{Path: "tree/a.txt", Op: nef.WatchChmod},
+			{Path: "tree/a.txt", Op: nef.WatchRename},
+			{Path: "tree/x/a.txt", Op: nef.WatchCreate},
+			{Path: "tree/x/y", Op: nef.WatchRemove},
+			{Path: "tree/x/a.txt", Op: nef.WatchRemove},
+			{Path: "tree/x", Op: nef.WatchRemove},
+		}))
+	})
+
+	It("🧪 should: deliver writes to created file", func() {
+		file, err := memFS.Create("tree/a.txt")
+		Expect(err).To(Succeed())
+
+		writer, ok := file.(interface{ Write([]byte) (int, error) })
+		Expect(ok).To(BeTrue())
+		_, err = writer.Write([]byte("a"))
+		Expect(err).To(Succeed())
+
+		Expect(pending(w)).To(Equal([]nef.WatchEvent{
+			{Path: "tree/a.txt", Op: nef.WatchCreate},
+			{Path: "tree/a.txt", Op: nef.WatchWrite},
+		}))
+	})
+
+	It("🧪 should: deliver creates for children of renamed directory", func() {
+		Expect(memFS.MakeDirAll("tree/x/y", lab.Perms.Dir)).To(Succeed())
+		Expect(memFS.WriteFile("tree/x/a.txt", nil, lab.Perms.File)).To(Succeed())
+		Expect(memFS.WriteFile("tree/x/y/b.txt", nil, lab.Perms.File)).To(Succeed())
+		_ = pending(w)
+
+		Expect(memFS.Rename("tree/x", "tree/z")).To(Succeed())
+		Expect(pending(w)).To(Equal([]nef.WatchEvent{
+			{Path: "tree/x", Op: nef.WatchRename},
+			{Path: "tree/z", Op: nef.WatchCreate},
+			{Path: "tree/z/a.txt", Op: nef.WatchCreate},
+			{Path: "tree/z/y", Op: nef.WatchCreate},
+			{Path: "tree/z/y/b.txt", Op: nef.WatchCreate},
+		}))
+	})
+
+	DescribeTable("given: dangling symbolic link replaced",
+		func(_ string, replace func(name string) error) {
+			Expect(memFS.Symlink("missing.txt", "tree/a.txt")).To(Succeed())
+			Expect(pending(w)).To(HaveLen(1))
+
+			Expect(replace("tree/a.txt")).To(Succeed())
+			Expect(pending(w)).To(Equal([]nef.WatchEvent{
+				{Path: "tree/a.txt", Op: nef.WatchWrite},
+			}))
+		},
+		func(op string, _ func(name string) error) string {
+			return fmt.Sprintf("🧪 ===> op: '%v', should: deliver write without create", op)
+		},
+		Entry(nil, "WriteFile", func(name string) error {
+			return memFS.WriteFile(name, []byte("a"), lab.Perms.File)
+		}),
+		Entry(nil, "Create", func(name string) error {
+			_, err := memFS.Create(name)
+
+			return err
+		}),
+	)
+
+	When("given: closed watcher", func() {
+		It("🧪 should: not deliver events", func() {
+			Expect(w.Close()).To(Succeed())
+			Expect(w.Close()).To(Succeed())
+			Expect(memFS.WriteFile("tree/a.txt", []byte("a"), lab.Perms.File)).To(Succeed())
+			Expect(w.Events()).To(BeClosed())
+		})
+	})
+
+	When("given: events not received", func() {
+		It("🧪 should: report overflow", func() {
+			for range 1100 {
+				Expect(memFS.Chtimes("tree", time.Time{}, time.Now())).To(Succeed())
+			}
+
+			Expect(w.Errors()).To(Receive(MatchError(nef.ErrCoreWatchOverflow)))
+		})
+	})
+
+	When("given: missing path", func() {
+		It("🧪 should: fail", func() {
+			_, err := memFS.Watch("missing")
+			Expect(err).To(MatchError(fs.ErrNotExist))
+		})
+	})
+})
@@ -1,10 +1,15 @@
 package luna
 
 import (
+	"errors"
 	"io"
 	"io/fs"
 	"os"
+	"path"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
 	"testing/fstest"
 	"time"
 
@@ -16,13 +21,30 @@ import (
 // MemFS is a memory fs based on fstest.MapFS intended to be used in
 // unit tests. Clients can embed and override the methods defined here
 // without having to provide a full implementation from scratch.
+//
+// MemFS is safe for concurrent use: the map and the watchers are guarded
+// by a read/write mutex, so that items can be read and written from
+// multiple goroutines, eg while they are locked. The map may also be
+// accessed directly, to set up a test, but not concurrently with the
+// methods of MemFS.
 type MemFS struct {
 	fstest.MapFS
-	calc nef.PathCalc
+	calc     nef.PathCalc
+	mu       sync.RWMutex
+	watchers []*memWatcher
+	locks    *memLocks
 }
 
 var (
-	_ nef.UniversalFS = (*MemFS)(nil)
+	_ nef.UniversalFS   = (*MemFS)(nil)
+	_ nef.ChangeTimesFS = (*MemFS)(nil)
+	_ nef.SymlinkFS     = (*MemFS)(nil)
+	_ nef.WatchFS       = (*MemFS)(nil)
+	_ nef.LockFS        = (*MemFS)(nil)
+)
+
+const (
+	memRoot = "/"
 )
 
 // NewMemFS returns a new in-memory file system implementing nef.UniversalFS for tests.
@@ -30,6 +52,7 @@ func NewMemFS() *MemFS {
 	return &MemFS{
 		MapFS: fstest.MapFS{},
 		calc:  &nef.RelativeCalc{},
+		locks: newMemLocks(),
 	}
 }
 
@@ -43,8 +66,107 @@ func (f *MemFS) IsRelative() bool {
 	return true
 }
 
+// Root returns the virtual root of the memory file system, which is always
+// "/", regardless of platform.
+func (f *MemFS) Root() string {
+	return memRoot
+}
+
+// ToAbsolute converts name, which must be a valid relative path, into an
+// absolute path beneath the virtual root.
+func (f *MemFS) ToAbsolute(name string) (string, error) {
+	rel, err := nef.NewRelPath(name)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(memRoot, rel.String()), nil
+}
+
+// ToRelative converts an absolute path beneath the virtual root into a
+// relative path. Since every absolute path resides beneath the virtual
+// root, only paths that are not rooted are rejected.
+func (f *MemFS) ToRelative(abs string) (string, error) {
+	if !strings.HasPrefix(abs, memRoot) {
+		return "", nef.NewInvalidPathError("ToRelative", abs)
+	}
+
+	rel := strings.TrimPrefix(path.Clean(abs), memRoot)
+
+	return lo.Ternary(rel == "", ".", rel), nil
+}
+
+// Open opens the named item for reading
+func (f *MemFS) Open(name string) (fs.File, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.MapFS.Open(name)
+}
+
+// Stat returns a FileInfo describing the named item, following symbolic
+// links
+func (f *MemFS) Stat(name string) (fs.FileInfo, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.MapFS.Stat(name)
+}
+
+// Lstat returns a FileInfo describing the named item, without following
+// a symbolic link
+func (f *MemFS) Lstat(name string) (fs.FileInfo, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.MapFS.Lstat(name)
+}
+
+// ReadFile reads the named file and returns its contents
+func (f *MemFS) ReadFile(name string) ([]byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.MapFS.ReadFile(name)
+}
+
+// ReadDir reads the named directory and returns its entries, sorted by
+// name
+func (f *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.MapFS.ReadDir(name)
+}
+
+// ReadLink returns the destination of the named symbolic link
+func (f *MemFS) ReadLink(name string) (string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.MapFS.ReadLink(name)
+}
+
+// Glob returns the names of the items matching pattern
+func (f *MemFS) Glob(pattern string) ([]string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.MapFS.Glob(pattern)
+}
+
+// Sub returns a file system corresponding to the sub tree rooted at dir,
+// which reads through MemFS, so that it is guarded by the same mutex.
+func (f *MemFS) Sub(dir string) (fs.FS, error) {
+	// the wrapper hides Sub, which fs.Sub would otherwise invoke
+	return fs.Sub(struct{ fs.FS }{f}, dir)
+}
+
 // FileExists reports whether a regular file exists at name.
 func (f *MemFS) FileExists(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
 	if mapFile, found := f.MapFS[name]; found && !mapFile.Mode.IsDir() {
 		return true
 	}
@@ -54,6 +176,13 @@ func (f *MemFS) FileExists(name string) bool {
 
 // DirectoryExists reports whether a directory exists at name.
 func (f *MemFS) DirectoryExists(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.isDir(name)
+}
+
+func (f *MemFS) isDir(name string) bool {
 	if mapFile, found := f.MapFS[name]; found && mapFile.Mode.IsDir() {
 		return true
 	}
@@ -63,14 +192,20 @@ func (f *MemFS) DirectoryExists(name string) bool {
 
 // Create creates or truncates the named file; returns fs.ErrExist if it already exists.
 func (f *MemFS) Create(name string) (fs.File, error) {
-	if _, err := f.Stat(name); err == nil {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// as with WriteFile, a dangling symbolic link is replaced
+	_, existed := f.MapFS[name]
+	if _, err := f.MapFS.Stat(name); err == nil {
 		return nil, fs.ErrExist
 	}
 
-	adapter := &FileAdapter{name: name}
-	f.MapFS[name] = &fstest.MapFile{Data: adapter.data}
+	mapFile := &fstest.MapFile{Mode: lab.Perms.File}
+	f.MapFS[name] = mapFile
+	f.replaced(name, existed, existed)
 
-	return adapter, nil
+	return &FileAdapter{name: name, mapFile: mapFile, owner: f}, nil
 }
 
 // MakeDir creates a single directory at name with the given permissions.
@@ -79,10 +214,14 @@ func (f *MemFS) MakeDir(name string, perm os.FileMode) error {
 		return nef.NewInvalidPathError("MakeDir", name)
 	}
 
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	if _, found := f.MapFS[name]; !found {
 		f.MapFS[name] = &fstest.MapFile{
 			Mode: perm | os.ModeDir,
 		}
+		f.notify(nef.WatchCreate, name)
 	}
 
 	return nil
@@ -94,6 +233,9 @@ func (f *MemFS) MakeDirAll(name string, perm os.FileMode) error {
 		return nef.NewInvalidPathError("MakeDirAll", name)
 	}
 
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	segments := strings.Split(name, "/")
 
 	_ = lo.Reduce(segments,
@@ -105,6 +247,7 @@ func (f *MemFS) MakeDirAll(name string, perm os.FileMode) error {
 				f.MapFS[path] = &fstest.MapFile{
 					Mode: perm | os.ModeDir,
 				}
+				f.notify(nef.WatchCreate, path)
 			}
 
 			return acc
@@ -114,65 +257,119 @@ func (f *MemFS) MakeDirAll(name string, perm os.FileMode) error {
 	return nil
 }
 
-// Ensure is not currently implemented on MemFS
-func (f *MemFS) Ensure(_ nef.PathAs) (string, error) {
-	return "", nil
+// Ensure makes sure that a path exists at a particular location depending
+// on the value of as.AsFile; see nef.MakeDirFS for details.
+func (f *MemFS) Ensure(as nef.PathAs) (string, error) {
+	if !fs.ValidPath(as.Name) {
+		return "", nef.NewInvalidPathError("Ensure", as.Name)
+	}
+
+	if as.AsFile {
+		directory, file := f.calc.Split(as.Name)
+		if directory = f.calc.Clean(directory); directory == "." {
+			return file, nil
+		}
+
+		return f.calc.Join(directory, file), f.MakeDirAll(directory, as.Perm)
+	}
+
+	return f.calc.Clean(f.calc.Join(as.Name, as.Default)), f.MakeDirAll(as.Name, as.Perm)
 }
 
-// Move is not currently implemented on MemFS
-func (f *MemFS) Move(_, _ string) error {
-	return nil
+// Move is not currently implemented on MemFS, so it fails with
+// errors.ErrUnsupported
+func (f *MemFS) Move(from, to string) error {
+	return &os.LinkError{Op: "Move", Old: from, New: to, Err: errors.ErrUnsupported}
 }
 
-// Change is not currently implemented on MemFS
-func (f *MemFS) Change(_, _ string) error {
-	return nil
+// Change is not currently implemented on MemFS, so it fails with
+// errors.ErrUnsupported
+func (f *MemFS) Change(from, to string) error {
+	return &os.LinkError{Op: "Change", Old: from, New: to, Err: errors.ErrUnsupported}
 }
 
-// Copy is not currently implemented on MemFS
-func (f *MemFS) Copy(_, _ string) error {
-	return nil
+// Copy is not currently implemented on MemFS, so it fails with
+// errors.ErrUnsupported
+func (f *MemFS) Copy(from, to string) error {
+	return &os.LinkError{Op: "Copy", Old: from, New: to, Err: errors.ErrUnsupported}
 }
 
-// CopyFS copies the given fs.FS into the directory; not implemented on MemFS (returns nil).
-func (f *MemFS) CopyFS(_ string, _ fs.FS) error {
-	return nil
+// CopyFS is not currently implemented on MemFS, so it fails with
+// errors.ErrUnsupported
+func (f *MemFS) CopyFS(dir string, _ fs.FS) error {
+	return &fs.PathError{Op: "CopyFS", Path: dir, Err: errors.ErrUnsupported}
 }
 
 // Remove removes the named file or (empty) directory.
 // If there is an error, it will be of type *PathError.
 func (f *MemFS) Remove(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	if _, found := f.MapFS[name]; found {
+		if len(f.children(name)) > 0 {
+			return &fs.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+		}
+
 		delete(f.MapFS, name)
+		f.notify(nef.WatchRemove, name)
+
 		return nil
 	}
 
 	return os.ErrNotExist
 }
 
-// RemoveAll removes path and any children; returns os.ErrNotExist if path does not exist.
+// RemoveAll removes path and any children. If the path does not exist,
+// RemoveAll returns nil (no error).
 func (f *MemFS) RemoveAll(path string) error {
-	keys := lo.Keys(f.MapFS)
-	matched := lo.Filter(keys, func(item string, _ int) bool {
-		return strings.HasPrefix(item, path)
-	})
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-	if len(matched) == 0 {
-		return os.ErrNotExist
-	}
+	children := f.children(path)
+	slices.Sort(children)
 
-	for _, item := range matched {
+	for _, item := range slices.Backward(children) {
 		delete(f.MapFS, item)
+		f.notify(nef.WatchRemove, item)
+	}
+
+	if _, found := f.MapFS[path]; found {
+		delete(f.MapFS, path)
+		f.notify(nef.WatchRemove, path)
 	}
 
 	return nil
 }
 
-// Rename renames the item at from to to; returns os.ErrNotExist if from does not exist.
+// Rename renames the item at from, along with any children, to to;
+// returns os.ErrNotExist if from does not exist. As with the native
+// watchers, the children of a directory are reported as created under
+// to, in walk order, after the directory itself.
 func (f *MemFS) Rename(from, to string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	if item, found := f.MapFS[from]; found {
+		children := f.children(from)
+		slices.SortFunc(children, func(a, b string) int {
+			return slices.Compare(strings.Split(a, "/"), strings.Split(b, "/"))
+		})
+
+		for i, child := range children {
+			f.MapFS[to+strings.TrimPrefix(child, from)] = f.MapFS[child]
+			delete(f.MapFS, child)
+			children[i] = to + strings.TrimPrefix(child, from)
+		}
+
 		delete(f.MapFS, from)
 		f.MapFS[to] = item
+		f.notify(nef.WatchRename, from)
+		f.notify(nef.WatchCreate, to)
+
+		for _, child := range children {
+			f.notify(nef.WatchCreate, child)
+		}
 
 		return nil
 	}
@@ -180,9 +377,62 @@ func (f *MemFS) Rename(from, to string) error {
 	return os.ErrNotExist
 }
 
+// children returns the paths of all the descendants of the directory name
+func (f *MemFS) children(name string) []string {
+	return lo.Filter(lo.Keys(f.MapFS), func(item string, _ int) bool {
+		return strings.HasPrefix(item, name+"/")
+	})
+}
+
+// Chtimes sets the modification time of the named item; the access time
+// is not recorded by MemFS.
+func (f *MemFS) Chtimes(name string, _, mtime time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	mapFile, found := f.MapFS[name]
+	if !found {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrNotExist}
+	}
+
+	mapFile.ModTime = mtime
+	f.notify(nef.WatchChmod, name)
+
+	return nil
+}
+
+// Symlink creates newname as a symbolic link to oldname; returns
+// fs.ErrExist if newname already exists.
+func (f *MemFS) Symlink(oldname, newname string) error {
+	if !fs.ValidPath(newname) {
+		return nef.NewInvalidPathError("Symlink", newname)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, found := f.MapFS[newname]; found {
+		return &fs.PathError{Op: "symlink", Path: newname, Err: fs.ErrExist}
+	}
+
+	f.MapFS[newname] = &fstest.MapFile{
+		Data: []byte(oldname),
+		Mode: fs.ModeSymlink | fs.ModePerm,
+	}
+	f.notify(nef.WatchCreate, newname)
+
+	return nil
+}
+
 // WriteFile writes data to the named file, creating it if necessary; returns fs.ErrExist if it already exists.
 func (f *MemFS) WriteFile(name string, data []byte, perm os.FileMode) error {
-	if _, err := f.Stat(name); err == nil {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// an item that Stat does not find may still exist, as a dangling
+	// symbolic link, which is replaced
+	_, existed := f.MapFS[name]
+	if _, err := f.MapFS.Stat(name); err == nil {
 		return fs.ErrExist
 	}
 
@@ -190,6 +440,7 @@ func (f *MemFS) WriteFile(name string, data []byte, perm os.FileMode) error {
 		Data: data,
 		Mode: perm,
 	}
+	f.replaced(name, existed, existed || len(data) > 0)
 
 	return nil
 }
@@ -234,9 +485,11 @@ func (fi *FileInfoAdapter) Sys() interface{} {
 
 // FileAdapter is an in-memory fs.File used by MemFS for read/write.
 type FileAdapter struct {
-	name string
-	data []byte
-	pos  int64
+	name    string
+	data    []byte
+	pos     int64
+	mapFile *fstest.MapFile
+	owner   *MemFS
 }
 
 // Read reads up to len(p) bytes from the file into p.
@@ -257,6 +510,19 @@ func (f *FileAdapter) Write(p []byte) (n int, err error) {
 	n = len(p)
 	f.pos += int64(n)
 
+	if f.owner != nil {
+		f.owner.mu.Lock()
+		defer f.owner.mu.Unlock()
+	}
+
+	if f.mapFile != nil {
+		f.mapFile.Data = f.data
+	}
+
+	if f.owner != nil {
+		f.owner.notify(nef.WatchWrite, f.name)
+	}
+
 	return n, nil
 }
 
@@ -0,0 +1,348 @@
+package luna
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	nef "github.com/snivilised/nefilim"
+)
+
+// RecordingFS is a decorator over a UniversalFS, that records every call
+// made to it, with its arguments, results and error, into a Trace. The
+// trace can be saved and served back by a ReplayFS, so that a run against
+// a real file system can be captured once and replayed in unit tests.
+//
+// Open captures the whole content of the item opened, so the file returned
+// is an in memory copy. Calc, IsRelative and Root are not recorded.
+type RecordingFS struct {
+	nef.UniversalFS
+	mutex sync.Mutex
+	trace Trace
+}
+
+var (
+	_ nef.UniversalFS   = (*RecordingFS)(nil)
+	_ nef.ChangeTimesFS = (*RecordingFS)(nil)
+)
+
+// NewRecordingFS creates a RecordingFS over fS
+func NewRecordingFS(fS nef.UniversalFS) *RecordingFS {
+	return &RecordingFS{
+		UniversalFS: fS,
+	}
+}
+
+// Trace returns a copy of the calls recorded so far
+func (f *RecordingFS) Trace() Trace {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return append(Trace{}, f.trace...)
+}
+
+func (f *RecordingFS) record(call *TraceCall, err error) {
+	call.Err = newTraceError(err)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.trace = append(f.trace, *call)
+}
+
+func (f *RecordingFS) unary(op, name string, fn func() error) error {
+	err := fn()
+	f.record(&TraceCall{Op: op, Args: []string{name}}, err)
+
+	return err
+}
+
+func (f *RecordingFS) binary(op, from, to string, fn func(from, to string) error) error {
+	err := fn(from, to)
+	f.record(&TraceCall{Op: op, Args: []string{from, to}}, err)
+
+	return err
+}
+
+// ToAbsolute converts name to an absolute path
+func (f *RecordingFS) ToAbsolute(name string) (string, error) {
+	path, err := f.UniversalFS.ToAbsolute(name)
+	f.record(&TraceCall{Op: "ToAbsolute", Args: []string{name}, Result: path}, err)
+
+	return path, err
+}
+
+// ToRelative converts an absolute path to one relative to the root
+func (f *RecordingFS) ToRelative(path string) (string, error) {
+	rel, err := f.UniversalFS.ToRelative(path)
+	f.record(&TraceCall{Op: "ToRelative", Args: []string{path}, Result: rel}, err)
+
+	return rel, err
+}
+
+// FileExists checks whether the named file exists
+func (f *RecordingFS) FileExists(name string) bool {
+	exists := f.UniversalFS.FileExists(name)
+	f.record(&TraceCall{Op: "FileExists", Args: []string{name},
+		Result: strconv.FormatBool(exists),
+	}, nil)
+
+	return exists
+}
+
+// DirectoryExists checks whether the named directory exists
+func (f *RecordingFS) DirectoryExists(name string) bool {
+	exists := f.UniversalFS.DirectoryExists(name)
+	f.record(&TraceCall{Op: "DirectoryExists", Args: []string{name},
+		Result: strconv.FormatBool(exists),
+	}, nil)
+
+	return exists
+}
+
+// Open opens the named item, capturing its content
+func (f *RecordingFS) Open(name string) (fs.File, error) {
+	call := &TraceCall{Op: "Open", Args: []string{name}}
+	file, err := f.open(name, call)
+	f.record(call, err)
+
+	return file, err
+}
+
+func (f *RecordingFS) open(name string, call *TraceCall) (fs.File, error) {
+	file, err := f.UniversalFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck // ok, content captured
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	call.Info = newTraceInfo(info)
+
+	if !info.IsDir() {
+		content, err := io.ReadAll(file)
+		if err != nil {
+			return nil, err
+		}
+
+		call.Output = content
+
+		return newTraceFile(name, info, content, nil), nil
+	}
+
+	directory, ok := file.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entries, err := directory.ReadDir(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	if call.Entries, err = traceEntries(entries); err != nil {
+		return nil, err
+	}
+
+	return newTraceFile(name, info, nil, entries), nil
+}
+
+func traceEntries(entries []fs.DirEntry) ([]*TraceInfo, error) {
+	infos := make([]*TraceInfo, 0, len(entries))
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, newTraceInfo(info))
+	}
+
+	return infos, nil
+}
+
+// Stat returns the info of the named item
+func (f *RecordingFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := f.UniversalFS.Stat(name)
+	call := &TraceCall{Op: "Stat", Args: []string{name}}
+
+	if err == nil {
+		call.Info = newTraceInfo(info)
+	}
+
+	f.record(call, err)
+
+	return info, err
+}
+
+// ReadDir reads the named directory
+func (f *RecordingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := f.UniversalFS.ReadDir(name)
+	call := &TraceCall{Op: "ReadDir", Args: []string{name}}
+
+	if err == nil {
+		call.Entries, err = traceEntries(entries)
+	}
+
+	f.record(call, err)
+
+	return entries, err
+}
+
+// ReadFile reads the named file
+func (f *RecordingFS) ReadFile(name string) ([]byte, error) {
+	content, err := f.UniversalFS.ReadFile(name)
+	f.record(&TraceCall{Op: "ReadFile", Args: []string{name}, Output: content}, err)
+
+	return content, err
+}
+
+// MakeDir creates the named directory
+func (f *RecordingFS) MakeDir(name string, perm os.FileMode) error {
+	err := f.UniversalFS.MakeDir(name, perm)
+	f.record(&TraceCall{Op: "MakeDir", Args: []string{name, formatPerm(perm)}}, err)
+
+	return err
+}
+
+// MakeDirAll creates the named directory and any missing parents
+func (f *RecordingFS) MakeDirAll(name string, perm os.FileMode) error {
+	err := f.UniversalFS.MakeDirAll(name, perm)
+	f.record(&TraceCall{Op: "MakeDirAll", Args: []string{name, formatPerm(perm)}}, err)
+
+	return err
+}
+
+// Ensure makes sure that a path exists
+func (f *RecordingFS) Ensure(as nef.PathAs) (string, error) {
+	at, err := f.UniversalFS.Ensure(as)
+	f.record(&TraceCall{Op: "Ensure", Args: ensureArgs(as), Result: at}, err)
+
+	return at, err
+}
+
+func ensureArgs(as nef.PathAs) []string {
+	return []string{as.Name, as.Default, formatPerm(as.Perm), strconv.FormatBool(as.AsFile)}
+}
+
+// Move moves an item
+func (f *RecordingFS) Move(from, to string) error {
+	return f.binary("Move", from, to, f.UniversalFS.Move)
+}
+
+// Change renames an item within its directory
+func (f *RecordingFS) Change(from, to string) error {
+	return f.binary("Change", from, to, f.UniversalFS.Change)
+}
+
+// Copy copies an item
+func (f *RecordingFS) Copy(from, to string) error {
+	return f.binary("Copy", from, to, f.UniversalFS.Copy)
+}
+
+// Rename renames an item
+func (f *RecordingFS) Rename(from, to string) error {
+	return f.binary("Rename", from, to, f.UniversalFS.Rename)
+}
+
+// CopyFS copies fsys into dir; the content of fsys is not recorded.
+func (f *RecordingFS) CopyFS(dir string, fsys fs.FS) error {
+	return f.unary("CopyFS", dir, func() error {
+		return f.UniversalFS.CopyFS(dir, fsys)
+	})
+}
+
+// Remove removes the named item
+func (f *RecordingFS) Remove(name string) error {
+	return f.unary("Remove", name, func() error {
+		return f.UniversalFS.Remove(name)
+	})
+}
+
+// RemoveAll removes path and any children
+func (f *RecordingFS) RemoveAll(path string) error {
+	return f.unary("RemoveAll", path, func() error {
+		return f.UniversalFS.RemoveAll(path)
+	})
+}
+
+// Chtimes changes the times of the named item; an error is returned if
+// the decorated file system is not a ChangeTimesFS.
+func (f *RecordingFS) Chtimes(name string, atime, mtime time.Time) error {
+	var err error = &fs.PathError{Op: "Chtimes", Path: name, Err: fs.ErrInvalid}
+
+	if changer, ok := f.UniversalFS.(nef.ChangeTimesFS); ok {
+		err = changer.Chtimes(name, atime, mtime)
+	}
+
+	f.record(&TraceCall{Op: "Chtimes",
+		Args: []string{name, formatTime(atime), formatTime(mtime)},
+	}, err)
+
+	return err
+}
+
+// WriteFile writes the named file
+func (f *RecordingFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	err := f.UniversalFS.WriteFile(name, data, perm)
+	f.record(&TraceCall{Op: "WriteFile",
+		Args:  []string{name, formatPerm(perm)},
+		Input: bytes.Clone(data),
+	}, err)
+
+	return err
+}
+
+// Create creates the named file; writes to and closing the file returned
+// are also recorded.
+func (f *RecordingFS) Create(name string) (fs.File, error) {
+	file, err := f.UniversalFS.Create(name)
+	f.record(&TraceCall{Op: "Create", Args: []string{name}}, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordingFile{
+		File: file,
+		fS:   f,
+		name: name,
+	}, nil
+}
+
+type recordingFile struct {
+	fs.File
+	fS   *RecordingFS
+	name string
+}
+
+func (f *recordingFile) Write(p []byte) (int, error) {
+	writer, ok := f.File.(io.Writer)
+	if !ok {
+		return 0, &fs.PathError{Op: "Write", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	n, err := writer.Write(p)
+	f.fS.record(&TraceCall{Op: "Write", Args: []string{f.name},
+		Input:  bytes.Clone(p),
+		Result: strconv.Itoa(n),
+	}, err)
+
+	return n, err
+}
+
+func (f *recordingFile) Close() error {
+	err := f.File.Close()
+	f.fS.record(&TraceCall{Op: "Close", Args: []string{f.name}}, err)
+
+	return err
+}
@@ -0,0 +1,250 @@
+package luna_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+type observations struct {
+	exists  bool
+	size    int64
+	content []byte
+	names   []string
+	missing error
+	written int
+}
+
+// scenario is the code under test, whose calls are recorded and replayed
+func scenario(fS nef.UniversalFS) *observations {
+	result := &observations{}
+
+	Expect(fS.MakeDirAll("reports", lab.Perms.Dir)).To(Succeed())
+	Expect(fS.WriteFile("reports/q1.csv", data, lab.Perms.File)).To(Succeed())
+	result.exists = fS.FileExists("reports/q1.csv")
+
+	info, err := fS.Stat("reports/q1.csv")
+	Expect(err).To(Succeed())
+	result.size = info.Size()
+
+	result.content, err = fS.ReadFile("reports/q1.csv")
+	Expect(err).To(Succeed())
+
+	file, err := fS.Create("reports/q2.csv")
+	Expect(err).To(Succeed())
+	result.written, err = file.(io.Writer).Write(data)
+	Expect(err).To(Succeed())
+	Expect(file.Close()).To(Succeed())
+
+	entries, err := fS.ReadDir("reports")
+	Expect(err).To(Succeed())
+
+	for _, entry := range entries {
+		result.names = append(result.names, entry.Name())
+	}
+
+	_, result.missing = fS.ReadFile("reports/q3.csv")
+
+	return result
+}
+
+// replay records act against fS, then replays it from the trace written
+// and read back, returning the original and the replayed error
+func replay(fS nef.UniversalFS, act func(fS nef.UniversalFS) error) (original, replayed error) {
+	recorder := luna.NewRecordingFS(fS)
+	original = act(recorder)
+
+	var buffer bytes.Buffer
+	_, err := recorder.Trace().WriteTo(&buffer)
+	Expect(err).To(Succeed())
+
+	trace, err := luna.ReadTrace(&buffer)
+	Expect(err).To(Succeed())
+
+	return original, act(luna.NewReplayFS(trace))
+}
+
+var replayTargets = []error{
+	fs.ErrNotExist, fs.ErrExist, fs.ErrPermission, fs.ErrInvalid,
+	errors.ErrUnsupported, nef.ErrCoreInvalidPath, nef.ErrCoreBinaryFsOp,
+	nef.ErrCoreRejectSameDirMove, nef.ErrCoreRejectDifferentDirChange,
+	nef.ErrCoreQuotaExceeded, nef.ErrCoreCancelled, nef.ErrCoreLocked,
+	nef.ErrCoreDecryption, context.Canceled, context.DeadlineExceeded,
+	syscall.ENOSPC, syscall.EACCES, io.EOF,
+}
+
+var _ = Describe("RecordingFS/ReplayFS", func() {
+	var (
+		trace    luna.Trace
+		recorded *observations
+	)
+
+	BeforeEach(func() {
+		recorder := luna.NewRecordingFS(nef.NewUniversalFS(nef.Rel{
+			Root: GinkgoT().TempDir(),
+		}))
+		recorded = scenario(recorder)
+
+		var buffer bytes.Buffer
+		_, err := recorder.Trace().WriteTo(&buffer)
+		Expect(err).To(Succeed())
+
+		trace, err = luna.ReadTrace(&buffer)
+		Expect(err).To(Succeed())
+	})
+
+	When("given: same sequence of calls", func() {
+		It("🧪 should: replay recorded results", func() {
+			Expect(trace).To(HaveLen(10))
+			Expect(recorded.missing).To(MatchError(fs.ErrNotExist))
+
+			fS := luna.NewReplayFS(trace)
+			replayed := scenario(fS)
+
+			Expect(replayed.exists).To(BeTrue())
+			Expect(replayed.size).To(Equal(int64(len(data))))
+			Expect(replayed.content).To(Equal(data))
+			Expect(replayed.written).To(Equal(len(data)))
+			Expect(replayed.names).To(Equal([]string{"q1.csv", "q2.csv"}))
+			Expect(replayed.missing).To(MatchError(fs.ErrNotExist))
+			Expect(fS.Verify()).To(Succeed())
+		})
+	})
+
+	When("given: different content written", func() {
+		It("🧪 should: report divergence", func() {
+			fS := luna.NewReplayFS(trace)
+			Expect(fS.MakeDirAll("reports", lab.Perms.Dir)).To(Succeed())
+
+			err := fS.WriteFile("reports/q1.csv", []byte("other"), lab.Perms.File)
+			Expect(err).To(MatchError(luna.ErrDivergence))
+
+			var divergence *luna.DivergenceError
+			Expect(errors.As(err, &divergence)).To(BeTrue())
+			Expect(divergence.Index).To(Equal(1))
+			Expect(divergence.Expected.Op).To(Equal("WriteFile"))
+
+			Expect(fS.FileExists("reports/q1.csv")).To(BeFalse(), "divergence is sticky")
+			Expect(fS.Verify()).To(MatchError(luna.ErrDivergence))
+		})
+	})
+
+	When("given: calls not replayed", func() {
+		It("🧪 should: fail verification", func() {
+			fS := luna.NewReplayFS(trace)
+			Expect(fS.MakeDirAll("reports", lab.Perms.Dir)).To(Succeed())
+			Expect(fS.Remaining()).To(Equal(9))
+			Expect(fS.Verify()).To(MatchError(luna.ErrDivergence))
+		})
+	})
+
+	DescribeTable("replayed error",
+		func(_ string, fS func() nef.UniversalFS, act func(fS nef.UniversalFS) error) {
+			original, replayed := replay(fS(), act)
+			Expect(original).NotTo(Succeed())
+			Expect(replayed).To(MatchError(original.Error()))
+
+			for _, target := range replayTargets {
+				Expect(errors.Is(replayed, target)).To(Equal(errors.Is(original, target)),
+					"errors.Is: %v", target,
+				)
+			}
+
+			var originalPath, replayedPath *fs.PathError
+			Expect(errors.As(replayed, &replayedPath)).To(Equal(errors.As(original, &originalPath)))
+
+			if originalPath != nil {
+				Expect(replayedPath.Op).To(Equal(originalPath.Op))
+				Expect(replayedPath.Path).To(Equal(originalPath.Path))
+			}
+
+			var originalLink, replayedLink *os.LinkError
+			Expect(errors.As(replayed, &replayedLink)).To(Equal(errors.As(original, &originalLink)))
+
+			if originalLink != nil {
+				Expect(replayedLink.Old).To(Equal(originalLink.Old))
+				Expect(replayedLink.New).To(Equal(originalLink.New))
+			}
+
+			var originalErrno, replayedErrno syscall.Errno
+			Expect(errors.As(replayed, &replayedErrno)).To(Equal(errors.As(original, &originalErrno)))
+			Expect(replayedErrno).To(Equal(originalErrno))
+		},
+		func(given string, _ func() nef.UniversalFS, _ func(fS nef.UniversalFS) error) string {
+			return fmt.Sprintf("🧪 ===> given: %v, should: satisfy errors.Is and errors.As as original", given)
+		},
+		Entry(nil, "change onto existing file",
+			func() nef.UniversalFS {
+				return nef.NewUniversalFS(nef.Rel{Root: GinkgoT().TempDir()})
+			},
+			func(fS nef.UniversalFS) error {
+				Expect(fS.WriteFile("a.txt", data, lab.Perms.File)).To(Succeed())
+				Expect(fS.WriteFile("b.txt", data, lab.Perms.File)).To(Succeed())
+
+				return fS.Change("a.txt", "b.txt")
+			},
+		),
+		Entry(nil, "missing file",
+			func() nef.UniversalFS {
+				return nef.NewUniversalFS(nef.Rel{Root: GinkgoT().TempDir()})
+			},
+			func(fS nef.UniversalFS) error {
+				_, err := fS.ReadFile("missing.txt")
+
+				return err
+			},
+		),
+		Entry(nil, "injected errno",
+			func() nef.UniversalFS {
+				return luna.NewFaultFS(luna.NewMemFS(), 0, luna.Fault{Op: "Rename", Err: syscall.ENOSPC})
+			},
+			func(fS nef.UniversalFS) error {
+				return fS.Rename("a.txt", "b.txt")
+			},
+		),
+		Entry(nil, "unsupported operation",
+			func() nef.UniversalFS {
+				return luna.NewMemFS()
+			},
+			func(fS nef.UniversalFS) error {
+				return fS.Copy("a.txt", "b.txt")
+			},
+		),
+		Entry(nil, "cancelled operation",
+			func() nef.UniversalFS {
+				return luna.NewFaultFS(luna.NewMemFS(), 0, luna.Fault{
+					Op:  "RemoveAll",
+					Err: fmt.Errorf("%w: %w", nef.ErrCoreCancelled, context.Canceled),
+				})
+			},
+			func(fS nef.UniversalFS) error {
+				return fS.RemoveAll("reports")
+			},
+		),
+		Entry(nil, "locked and decryption failure",
+			func() nef.UniversalFS {
+				return luna.NewFaultFS(luna.NewMemFS(), 0, luna.Fault{
+					Op:  "ReadFile",
+					Err: errors.Join(nef.ErrCoreLocked, nef.ErrCoreDecryption),
+				})
+			},
+			func(fS nef.UniversalFS) error {
+				_, err := fS.ReadFile("secret")
+
+				return err
+			},
+		),
+	)
+})
@@ -0,0 +1,383 @@
+package luna
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	nef "github.com/snivilised/nefilim"
+)
+
+// ErrDivergence indicates that the calls made to a ReplayFS diverged from
+// the trace being replayed
+var ErrDivergence = errors.New("replay diverged from trace")
+
+// DivergenceError is the error returned by a ReplayFS when a call does
+// not match the next call in the trace
+type DivergenceError struct {
+	// Index is the position in the trace at which the divergence occurred
+	Index int
+	// Expected is the call expected, nil if the trace had been exhausted
+	Expected *TraceCall
+	// Actual is the call made, nil if the trace was not exhausted
+	Actual *TraceCall
+}
+
+// Error returns the error message
+func (e *DivergenceError) Error() string {
+	describe := func(call *TraceCall) string {
+		if call == nil {
+			return "nothing"
+		}
+
+		return fmt.Sprintf("%v(%v)", call.Op, strings.Join(call.Args, ", "))
+	}
+
+	return fmt.Sprintf("%v at call %v: expected %v, actual %v",
+		ErrDivergence, e.Index, describe(e.Expected), describe(e.Actual),
+	)
+}
+
+// Is determines if the target error is a divergence error
+func (e *DivergenceError) Is(target error) bool {
+	return target == ErrDivergence
+}
+
+// ReplayFS is a UniversalFS that serves back a Trace captured by a
+// RecordingFS. Every call must match the next call in the trace, by
+// operation, arguments and content written, otherwise a DivergenceError
+// is returned for it and every call thereafter. A ReplayFS behaves as a
+// relative file system with a RelativeCalc.
+type ReplayFS struct {
+	mutex      sync.Mutex
+	trace      Trace
+	position   int
+	divergence error
+	calc       nef.PathCalc
+}
+
+var (
+	_ nef.UniversalFS   = (*ReplayFS)(nil)
+	_ nef.ChangeTimesFS = (*ReplayFS)(nil)
+)
+
+// NewReplayFS creates a ReplayFS that serves back trace
+func NewReplayFS(trace Trace) *ReplayFS {
+	return &ReplayFS{
+		trace: trace,
+		calc:  &nef.RelativeCalc{},
+	}
+}
+
+// Err returns the first divergence, if any
+func (f *ReplayFS) Err() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.divergence
+}
+
+// Remaining returns the number of calls in the trace that have not been
+// replayed
+func (f *ReplayFS) Remaining() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return len(f.trace) - f.position
+}
+
+// Verify returns the first divergence, or a DivergenceError if calls in
+// the trace have not been replayed; it is intended to be invoked at the
+// end of a test.
+func (f *ReplayFS) Verify() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.divergence != nil {
+		return f.divergence
+	}
+
+	if f.position < len(f.trace) {
+		return &DivergenceError{
+			Index:    f.position,
+			Expected: &f.trace[f.position],
+		}
+	}
+
+	return nil
+}
+
+// next returns the next call in the trace, if it matches actual
+func (f *ReplayFS) next(actual *TraceCall) (*TraceCall, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.divergence != nil {
+		return nil, f.divergence
+	}
+
+	if f.position >= len(f.trace) {
+		f.divergence = &DivergenceError{
+			Index:  f.position,
+			Actual: actual,
+		}
+
+		return nil, f.divergence
+	}
+
+	expected := &f.trace[f.position]
+
+	if expected.Op != actual.Op || !slices.Equal(expected.Args, actual.Args) ||
+		!bytes.Equal(expected.Input, actual.Input) {
+		f.divergence = &DivergenceError{
+			Index:    f.position,
+			Expected: expected,
+			Actual:   actual,
+		}
+
+		return nil, f.divergence
+	}
+
+	f.position++
+
+	return expected, nil
+}
+
+func (f *ReplayFS) replay(op string, args ...string) error {
+	call, err := f.next(&TraceCall{Op: op, Args: args})
+	if err != nil {
+		return err
+	}
+
+	return call.Err.error()
+}
+
+func (f *ReplayFS) predicate(op, name string) bool {
+	call, err := f.next(&TraceCall{Op: op, Args: []string{name}})
+
+	return err == nil && call.Result == strconv.FormatBool(true)
+}
+
+func (f *ReplayFS) result(op string, args ...string) (string, error) {
+	call, err := f.next(&TraceCall{Op: op, Args: args})
+	if err != nil {
+		return "", err
+	}
+
+	return call.Result, call.Err.error()
+}
+
+// Calc returns a RelativeCalc
+func (f *ReplayFS) Calc() nef.PathCalc {
+	return f.calc
+}
+
+// IsRelative returns true
+func (f *ReplayFS) IsRelative() bool {
+	return true
+}
+
+// Root returns the empty string
+func (f *ReplayFS) Root() string {
+	return ""
+}
+
+// ToAbsolute replays the conversion of name to an absolute path
+func (f *ReplayFS) ToAbsolute(name string) (string, error) {
+	return f.result("ToAbsolute", name)
+}
+
+// ToRelative replays the conversion of path to a relative path
+func (f *ReplayFS) ToRelative(path string) (string, error) {
+	return f.result("ToRelative", path)
+}
+
+// FileExists replays the check for the existence of a file
+func (f *ReplayFS) FileExists(name string) bool {
+	return f.predicate("FileExists", name)
+}
+
+// DirectoryExists replays the check for the existence of a directory
+func (f *ReplayFS) DirectoryExists(name string) bool {
+	return f.predicate("DirectoryExists", name)
+}
+
+// Open replays the opening of the named item; the file returned serves
+// the content captured.
+func (f *ReplayFS) Open(name string) (fs.File, error) {
+	call, err := f.next(&TraceCall{Op: "Open", Args: []string{name}})
+	if err != nil {
+		return nil, err
+	}
+
+	if call.Err != nil || call.Info == nil {
+		return nil, call.Err.error()
+	}
+
+	return newTraceFile(name, call.Info.info(), call.Output, replayEntries(call.Entries)), nil
+}
+
+func replayEntries(infos []*TraceInfo) []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(infos))
+
+	for _, info := range infos {
+		entries = append(entries, fs.FileInfoToDirEntry(info.info()))
+	}
+
+	return entries
+}
+
+// Stat replays the stat of the named item
+func (f *ReplayFS) Stat(name string) (fs.FileInfo, error) {
+	call, err := f.next(&TraceCall{Op: "Stat", Args: []string{name}})
+	if err != nil {
+		return nil, err
+	}
+
+	if call.Err != nil || call.Info == nil {
+		return nil, call.Err.error()
+	}
+
+	return call.Info.info(), nil
+}
+
+// ReadDir replays the reading of the named directory
+func (f *ReplayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	call, err := f.next(&TraceCall{Op: "ReadDir", Args: []string{name}})
+	if err != nil {
+		return nil, err
+	}
+
+	if call.Err != nil {
+		return nil, call.Err.error()
+	}
+
+	return replayEntries(call.Entries), nil
+}
+
+// ReadFile replays the reading of the named file
+func (f *ReplayFS) ReadFile(name string) ([]byte, error) {
+	call, err := f.next(&TraceCall{Op: "ReadFile", Args: []string{name}})
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(call.Output), call.Err.error()
+}
+
+// MakeDir replays the creation of the named directory
+func (f *ReplayFS) MakeDir(name string, perm os.FileMode) error {
+	return f.replay("MakeDir", name, formatPerm(perm))
+}
+
+// MakeDirAll replays the creation of the named directory and its parents
+func (f *ReplayFS) MakeDirAll(name string, perm os.FileMode) error {
+	return f.replay("MakeDirAll", name, formatPerm(perm))
+}
+
+// Ensure replays making sure that a path exists
+func (f *ReplayFS) Ensure(as nef.PathAs) (string, error) {
+	return f.result("Ensure", ensureArgs(as)...)
+}
+
+// Move replays the move of an item
+func (f *ReplayFS) Move(from, to string) error {
+	return f.replay("Move", from, to)
+}
+
+// Change replays the renaming of an item within its directory
+func (f *ReplayFS) Change(from, to string) error {
+	return f.replay("Change", from, to)
+}
+
+// Copy replays the copying of an item
+func (f *ReplayFS) Copy(from, to string) error {
+	return f.replay("Copy", from, to)
+}
+
+// CopyFS replays the copying of a file system into dir
+func (f *ReplayFS) CopyFS(dir string, _ fs.FS) error {
+	return f.replay("CopyFS", dir)
+}
+
+// Remove replays the removal of the named item
+func (f *ReplayFS) Remove(name string) error {
+	return f.replay("Remove", name)
+}
+
+// RemoveAll replays the removal of path and its children
+func (f *ReplayFS) RemoveAll(path string) error {
+	return f.replay("RemoveAll", path)
+}
+
+// Rename replays the renaming of an item
+func (f *ReplayFS) Rename(from, to string) error {
+	return f.replay("Rename", from, to)
+}
+
+// Chtimes replays the change of the times of the named item
+func (f *ReplayFS) Chtimes(name string, atime, mtime time.Time) error {
+	return f.replay("Chtimes", name, formatTime(atime), formatTime(mtime))
+}
+
+// WriteFile replays the writing of the named file; the content must
+// match the content recorded.
+func (f *ReplayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	call, err := f.next(&TraceCall{Op: "WriteFile",
+		Args:  []string{name, formatPerm(perm)},
+		Input: data,
+	})
+	if err != nil {
+		return err
+	}
+
+	return call.Err.error()
+}
+
+// Create replays the creation of the named file; writes to and closing
+// the file returned are also replayed.
+func (f *ReplayFS) Create(name string) (fs.File, error) {
+	if err := f.replay("Create", name); err != nil {
+		return nil, err
+	}
+
+	return &replayFile{
+		fS:   f,
+		name: name,
+	}, nil
+}
+
+type replayFile struct {
+	fS   *ReplayFS
+	name string
+}
+
+func (f *replayFile) Stat() (fs.FileInfo, error) {
+	return nil, &fs.PathError{Op: "stat", Path: f.name, Err: fs.ErrInvalid}
+}
+
+func (f *replayFile) Read(_ []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrInvalid}
+}
+
+func (f *replayFile) Write(p []byte) (int, error) {
+	call, err := f.fS.next(&TraceCall{Op: "Write", Args: []string{f.name}, Input: p})
+	if err != nil {
+		return 0, err
+	}
+
+	n, _ := strconv.Atoi(call.Result)
+
+	return n, call.Err.error()
+}
+
+func (f *replayFile) Close() error {
+	return f.fS.replay("Close", f.name)
+}
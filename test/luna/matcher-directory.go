@@ -0,0 +1,105 @@
+package luna
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/onsi/gomega/types"
+	nef "github.com/snivilised/nefilim"
+)
+
+// DirectoryEntriesMatcher is a Gomega matcher that checks the entries of
+// a directory in an nef.ReaderFS.
+type DirectoryEntriesMatcher struct {
+	fS       nef.ReaderFS
+	expected []string
+	empty    bool
+	actual   []string
+	missing  []string
+}
+
+// BeEmptyDirectory returns a Gomega matcher that asserts the directory
+// denoted by actual (AsDirectory or string) in fS has no entries.
+func BeEmptyDirectory(fS nef.ReaderFS) types.GomegaMatcher {
+	return &DirectoryEntriesMatcher{
+		fS:    fS,
+		empty: true,
+	}
+}
+
+// ContainEntries returns a Gomega matcher that asserts the directory
+// denoted by actual (AsDirectory or string) in fS contains entries with
+// all of the names specified; other entries are permitted.
+func ContainEntries(fS nef.ReaderFS, names ...string) types.GomegaMatcher {
+	return &DirectoryEntriesMatcher{
+		fS:       fS,
+		expected: names,
+	}
+}
+
+// Match runs the matcher
+func (m *DirectoryEntriesMatcher) Match(actual interface{}) (bool, error) {
+	path, err := pathOf(actual)
+	if err != nil {
+		return false, err
+	}
+
+	entries, err := m.fS.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+
+	m.actual = make([]string, 0, len(entries))
+	for _, entry := range entries {
+		m.actual = append(m.actual, entry.Name())
+	}
+
+	if m.empty {
+		return len(m.actual) == 0, nil
+	}
+
+	m.missing = []string{}
+	for _, name := range m.expected {
+		if !slices.Contains(m.actual, name) {
+			m.missing = append(m.missing, name)
+		}
+	}
+
+	return len(m.missing) == 0, nil
+}
+
+// FailureMessage returns the message shown when the matcher fails
+func (m *DirectoryEntriesMatcher) FailureMessage(actual interface{}) string {
+	if m.empty {
+		return fmt.Sprintf("🔥 Expected\n\t%v\nto be an empty directory, but contains:\n%v",
+			actual, listing(m.actual, "  "),
+		)
+	}
+
+	return fmt.Sprintf("🔥 Expected\n\t%v\nto contain entries, but missing:\n%v\nactual entries:\n%v",
+		actual, listing(m.missing, "- "), listing(m.actual, "  "),
+	)
+}
+
+// NegatedFailureMessage returns the message shown when the negated
+// matcher fails
+func (m *DirectoryEntriesMatcher) NegatedFailureMessage(actual interface{}) string {
+	if m.empty {
+		return fmt.Sprintf("🔥 Expected\n\t%v\nNOT to be an empty directory\n", actual)
+	}
+
+	return fmt.Sprintf("🔥 Expected\n\t%v\nNOT to contain entries\n%v",
+		actual, listing(m.expected, "  "),
+	)
+}
+
+func listing(names []string, prefix string) string {
+	var builder strings.Builder
+
+	for _, name := range names {
+		builder.WriteString("\t" + prefix + name + "\n")
+	}
+
+	return builder.String()
+}
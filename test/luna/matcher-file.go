@@ -0,0 +1,156 @@
+package luna
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/onsi/gomega/types"
+	nef "github.com/snivilised/nefilim"
+)
+
+// pathOf returns the path denoted by the actual value of a matcher,
+// which may be an AsFile, AsDirectory or string.
+func pathOf(actual interface{}) (string, error) {
+	switch path := actual.(type) {
+	case AsFile:
+		return string(path), nil
+	case AsDirectory:
+		return string(path), nil
+	case string:
+		return path, nil
+	}
+
+	return "", fmt.Errorf("❌ matcher expected an AsFile, AsDirectory or string instance (%T)", actual)
+}
+
+// FileContentMatcher is a Gomega matcher that checks the content of a
+// file in an nef.ReaderFS.
+type FileContentMatcher struct {
+	fS       nef.ReaderFS
+	expected string
+	actual   string
+}
+
+// HaveFileContent returns a Gomega matcher that asserts the file denoted by
+// actual (AsFile or string) in fS has the expected content, which may be
+// a string or []byte. The failure message contains a diff of the content.
+func HaveFileContent(fS nef.ReaderFS, expected interface{}) types.GomegaMatcher {
+	content := ""
+
+	switch e := expected.(type) {
+	case string:
+		content = e
+	case []byte:
+		content = string(e)
+	default:
+		content = fmt.Sprint(e)
+	}
+
+	return &FileContentMatcher{
+		fS:       fS,
+		expected: content,
+	}
+}
+
+// Match runs the matcher
+func (m *FileContentMatcher) Match(actual interface{}) (bool, error) {
+	path, err := pathOf(actual)
+	if err != nil {
+		return false, err
+	}
+
+	content, err := m.fS.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	m.actual = string(content)
+
+	return m.actual == m.expected, nil
+}
+
+// FailureMessage returns the message shown when the content differs
+func (m *FileContentMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("🔥 Expected\n\t%v\nto have content (-expected +actual):\n%v",
+		actual, cmp.Diff(m.expected, m.actual),
+	)
+}
+
+// NegatedFailureMessage returns the message shown when the content matches
+func (m *FileContentMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("🔥 Expected\n\t%v\nNOT to have content\n\t%q\n", actual, m.expected)
+}
+
+// FileInfoMatcher is a Gomega matcher that checks an attribute of the info
+// of an item in an nef.ReaderFS.
+type FileInfoMatcher struct {
+	fS          nef.ReaderFS
+	attribute   string
+	expected    interface{}
+	actual      interface{}
+	requirement func(info fs.FileInfo) (actual interface{}, matched bool)
+}
+
+// HaveFileMode returns a Gomega matcher that asserts the item denoted by
+// actual in fS has the expected mode. If expected does not contain any
+// type bits, only the permission bits are compared.
+func HaveFileMode(fS nef.ReaderFS, expected fs.FileMode) types.GomegaMatcher {
+	return &FileInfoMatcher{
+		fS:        fS,
+		attribute: "mode",
+		expected:  expected,
+		requirement: func(info fs.FileInfo) (interface{}, bool) {
+			if expected&fs.ModeType == 0 {
+				return info.Mode().Perm(), info.Mode().Perm() == expected.Perm()
+			}
+
+			return info.Mode(), info.Mode() == expected
+		},
+	}
+}
+
+// HaveFileSize returns a Gomega matcher that asserts the item denoted by
+// actual in fS has the expected size.
+func HaveFileSize(fS nef.ReaderFS, expected int64) types.GomegaMatcher {
+	return &FileInfoMatcher{
+		fS:        fS,
+		attribute: "size",
+		expected:  expected,
+		requirement: func(info fs.FileInfo) (interface{}, bool) {
+			return info.Size(), info.Size() == expected
+		},
+	}
+}
+
+// Match runs the matcher
+func (m *FileInfoMatcher) Match(actual interface{}) (bool, error) {
+	path, err := pathOf(actual)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := m.fS.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	var matched bool
+	m.actual, matched = m.requirement(info)
+
+	return matched, nil
+}
+
+// FailureMessage returns the message shown when the attribute differs
+func (m *FileInfoMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("🔥 Expected\n\t%v\nto have %v\n\t%v\nbut was\n\t%v",
+		actual, m.attribute, m.expected, m.actual,
+	)
+}
+
+// NegatedFailureMessage returns the message shown when the attribute matches
+func (m *FileInfoMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("🔥 Expected\n\t%v\nNOT to have %v\n\t%v\n",
+		actual, m.attribute, m.expected,
+	)
+}
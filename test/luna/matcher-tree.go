@@ -0,0 +1,127 @@
+package luna
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/onsi/gomega/types"
+	nef "github.com/snivilised/nefilim"
+)
+
+// Tree is a specification of the content of a directory tree. The keys
+// are slash separated paths relative to the root of the tree; a directory
+// is denoted by a trailing '/' (and an empty value), any other key denotes
+// a file with the value as its content.
+type Tree map[string]string
+
+// ReadTree reads the tree under root in fS into a Tree, that contains an
+// item for every file and directory.
+func ReadTree(fS nef.ReaderFS, root string) (Tree, error) {
+	tree := Tree{}
+
+	return tree, readTree(fS, root, "", tree)
+}
+
+func readTree(fS nef.ReaderFS, path, rel string, tree Tree) error {
+	entries, err := fS.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		childPath := childOf(fS, path, entry.Name())
+		childRel := rel + entry.Name()
+
+		if entry.IsDir() {
+			tree[childRel+"/"] = ""
+
+			if err := readTree(fS, childPath, childRel+"/", tree); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		content, err := fS.ReadFile(childPath)
+		if err != nil {
+			return err
+		}
+
+		tree[childRel] = string(content)
+	}
+
+	return nil
+}
+
+// childOf returns the path of the child named name in the directory parent
+func childOf(fS nef.FSUtility, parent, name string) string {
+	if fS.IsRelative() && (parent == "." || parent == "") {
+		return name
+	}
+
+	return fS.Calc().Join(parent, name)
+}
+
+// TreeMatcher is a Gomega matcher that checks the content of a directory
+// tree in an nef.ReaderFS.
+type TreeMatcher struct {
+	fS       nef.ReaderFS
+	expected Tree
+	actual   Tree
+}
+
+// MatchTree returns a Gomega matcher that asserts the tree under the
+// directory denoted by actual (AsDirectory or string) in fS consists of
+// exactly the items in expected, with the same content. Parent directories
+// of the files in expected need not be specified. The failure message
+// contains a diff of the trees.
+func MatchTree(fS nef.ReaderFS, expected Tree) types.GomegaMatcher {
+	return &TreeMatcher{
+		fS:       fS,
+		expected: expected.complete(),
+	}
+}
+
+// complete returns a copy of the tree, that includes the parent
+// directories of every item.
+func (t Tree) complete() Tree {
+	complete := Tree{}
+
+	for key, value := range t {
+		complete[key] = value
+
+		segments := strings.Split(strings.TrimSuffix(key, "/"), "/")
+		for i := 1; i < len(segments); i++ {
+			complete[strings.Join(segments[:i], "/")+"/"] = ""
+		}
+	}
+
+	return complete
+}
+
+// Match runs the matcher
+func (m *TreeMatcher) Match(actual interface{}) (bool, error) {
+	path, err := pathOf(actual)
+	if err != nil {
+		return false, err
+	}
+
+	if m.actual, err = ReadTree(m.fS, path); err != nil {
+		return false, err
+	}
+
+	return cmp.Equal(m.expected, m.actual), nil
+}
+
+// FailureMessage returns the message shown when the trees differ
+func (m *TreeMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("🔥 Expected\n\t%v\nto match tree (-expected +actual):\n%v",
+		actual, cmp.Diff(m.expected, m.actual),
+	)
+}
+
+// NegatedFailureMessage returns the message shown when the trees match
+func (m *TreeMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("🔥 Expected\n\t%v\nNOT to match tree\n", actual)
+}
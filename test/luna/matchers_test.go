@@ -0,0 +1,121 @@
+package luna_test
+
+import (
+	"io/fs"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+var _ = Describe("Matchers", func() {
+	var memFS *luna.MemFS
+
+	BeforeEach(func() {
+		memFS = luna.NewMemFS()
+		memFS.MapFS["site/index.html"] = &fstest.MapFile{
+			Data: []byte("<html>\n<body/>\n</html>\n"),
+			Mode: lab.Perms.File,
+		}
+		memFS.MapFS["site/css/site.css"] = &fstest.MapFile{
+			Data: []byte("body {}"),
+			Mode: 0o600,
+		}
+		memFS.MapFS["site/empty"] = &fstest.MapFile{
+			Mode: fs.ModeDir | lab.Perms.Dir,
+		}
+	})
+
+	Context("HaveFileContent", func() {
+		It("🧪 should: match content", func() {
+			Expect(luna.AsFile("site/css/site.css")).To(luna.HaveFileContent(memFS, "body {}"))
+			Expect("site/css/site.css").To(luna.HaveFileContent(memFS, []byte("body {}")))
+			Expect(luna.AsFile("site/css/site.css")).NotTo(luna.HaveFileContent(memFS, "p {}"))
+		})
+
+		It("🧪 should: report diff", func() {
+			matcher := luna.HaveFileContent(memFS, "<html>\n<head/>\n</html>\n")
+			Expect(matcher.Match(luna.AsFile("site/index.html"))).To(BeFalse())
+			message := matcher.FailureMessage(luna.AsFile("site/index.html"))
+			Expect(message).To(ContainSubstring("-"))
+			Expect(message).To(ContainSubstring("<head/>"))
+			Expect(message).To(ContainSubstring("<body/>"))
+		})
+
+		When("given: missing file", func() {
+			It("🧪 should: return error", func() {
+				_, err := luna.HaveFileContent(memFS, "").Match(luna.AsFile("site/missing"))
+				Expect(err).To(MatchError(fs.ErrNotExist))
+			})
+		})
+	})
+
+	Context("HaveFileMode/HaveFileSize", func() {
+		It("🧪 should: match info", func() {
+			Expect(luna.AsFile("site/css/site.css")).To(luna.HaveFileMode(memFS, 0o600))
+			Expect(luna.AsDirectory("site/empty")).To(luna.HaveFileMode(memFS, fs.ModeDir|lab.Perms.Dir))
+			Expect(luna.AsFile("site/css/site.css")).To(luna.HaveFileSize(memFS, 7))
+
+			matcher := luna.HaveFileSize(memFS, 8)
+			Expect(matcher.Match(luna.AsFile("site/css/site.css"))).To(BeFalse())
+			Expect(matcher.FailureMessage(luna.AsFile("site/css/site.css"))).To(
+				ContainSubstring("to have size\n\t8\nbut was\n\t7"),
+			)
+		})
+	})
+
+	Context("BeEmptyDirectory/ContainEntries", func() {
+		It("🧪 should: match entries", func() {
+			Expect(luna.AsDirectory("site/empty")).To(luna.BeEmptyDirectory(memFS))
+			Expect(luna.AsDirectory("site")).NotTo(luna.BeEmptyDirectory(memFS))
+			Expect(luna.AsDirectory("site")).To(luna.ContainEntries(memFS, "css", "index.html"))
+
+			matcher := luna.ContainEntries(memFS, "index.html", "about.html")
+			Expect(matcher.Match(luna.AsDirectory("site"))).To(BeFalse())
+			Expect(matcher.FailureMessage(luna.AsDirectory("site"))).To(ContainSubstring("- about.html"))
+		})
+	})
+
+	Context("MatchTree", func() {
+		It("🧪 should: match tree", func() {
+			Expect(luna.AsDirectory("site")).To(luna.MatchTree(memFS, luna.Tree{
+				"index.html":   "<html>\n<body/>\n</html>\n",
+				"css/site.css": "body {}",
+				"empty/":       "",
+			}))
+		})
+
+		It("🧪 should: report diff", func() {
+			matcher := luna.MatchTree(memFS, luna.Tree{
+				"index.html":   "<html>\n<body/>\n</html>\n",
+				"css/site.css": "p {}",
+				"js/app.js":    "",
+			})
+			Expect(matcher.Match(luna.AsDirectory("site"))).To(BeFalse())
+
+			message := matcher.FailureMessage(luna.AsDirectory("site"))
+			Expect(message).To(ContainSubstring("js/app.js"))
+			Expect(message).To(ContainSubstring("empty/"))
+			Expect(message).To(ContainSubstring("p {}"))
+		})
+
+		When("given: relative file system", func() {
+			It("🧪 should: match tree", func() {
+				root := GinkgoT().TempDir()
+				fS := nef.NewUniversalFS(nef.Rel{
+					Root: root,
+				})
+				Expect(fS.MakeDirAll("a/b", lab.Perms.Dir)).To(Succeed())
+				Expect(fS.WriteFile("a/b/c.txt", []byte("c"), lab.Perms.File)).To(Succeed())
+
+				Expect(".").To(luna.MatchTree(fS, luna.Tree{
+					"a/b/c.txt": "c",
+				}))
+			})
+		})
+	})
+})
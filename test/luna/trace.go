@@ -0,0 +1,296 @@
+package luna
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	nef "github.com/snivilised/nefilim"
+)
+
+// TraceInfo is the serialisable form of an fs.FileInfo
+type TraceInfo struct {
+	Name    string      `json:"name"`
+	Size    int64       `json:"size"`
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"modTime"`
+}
+
+func newTraceInfo(info fs.FileInfo) *TraceInfo {
+	return &TraceInfo{
+		Name:    info.Name(),
+		Size:    info.Size(),
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+	}
+}
+
+func (i *TraceInfo) info() fs.FileInfo {
+	return &traceFileInfo{i}
+}
+
+type traceFileInfo struct {
+	*TraceInfo
+}
+
+func (i *traceFileInfo) Name() string       { return i.TraceInfo.Name }
+func (i *traceFileInfo) Size() int64        { return i.TraceInfo.Size }
+func (i *traceFileInfo) Mode() fs.FileMode  { return i.TraceInfo.Mode }
+func (i *traceFileInfo) ModTime() time.Time { return i.TraceInfo.ModTime }
+func (i *traceFileInfo) IsDir() bool        { return i.TraceInfo.Mode.IsDir() }
+func (i *traceFileInfo) Sys() any           { return nil }
+
+// TraceError is the serialisable form of an error. Kinds identify the
+// well known errors (eg fs.ErrNotExist) that the error wraps, so that a
+// replayed error satisfies errors.Is as the original did. The errno of a
+// wrapped syscall.Errno, and an fs.PathError or os.LinkError at the top
+// of the error, are also preserved, so errors.As can retrieve them; other
+// error types are not.
+type TraceError struct {
+	Message string          `json:"message"`
+	Kinds   []string        `json:"kinds,omitempty"`
+	Errno   int             `json:"errno,omitempty"`
+	Path    *TracePathError `json:"path,omitempty"`
+}
+
+// TracePathError is the serialisable form of an fs.PathError, or an
+// os.LinkError when Link is set, in which case Path is the old path.
+type TracePathError struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	New   string `json:"new,omitempty"`
+	Link  bool   `json:"link,omitempty"`
+	Cause string `json:"cause"`
+}
+
+var traceErrorKinds = []struct {
+	kind string
+	err  error
+}{
+	{"not-exist", fs.ErrNotExist},
+	{"exist", fs.ErrExist},
+	{"permission", fs.ErrPermission},
+	{"invalid", fs.ErrInvalid},
+	{"unsupported", errors.ErrUnsupported},
+	{"invalid-path", nef.ErrCoreInvalidPath},
+	{"binary-fs-op", nef.ErrCoreBinaryFsOp},
+	{"same-dir-move", nef.ErrCoreRejectSameDirMove},
+	{"different-dir-change", nef.ErrCoreRejectDifferentDirChange},
+	{"quota-exceeded", nef.ErrCoreQuotaExceeded},
+	{"cancelled", nef.ErrCoreCancelled},
+	{"locked", nef.ErrCoreLocked},
+	{"decryption", nef.ErrCoreDecryption},
+	{"context-cancelled", context.Canceled},
+	{"deadline-exceeded", context.DeadlineExceeded},
+	{"eof", io.EOF},
+}
+
+func newTraceError(err error) *TraceError {
+	if err == nil {
+		return nil
+	}
+
+	traceErr := &TraceError{
+		Message: err.Error(),
+	}
+
+	for _, known := range traceErrorKinds {
+		if errors.Is(err, known.err) {
+			traceErr.Kinds = append(traceErr.Kinds, known.kind)
+		}
+	}
+
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		traceErr.Errno = int(errno)
+	}
+
+	switch wrapper := err.(type) {
+	case *fs.PathError:
+		traceErr.Path = &TracePathError{
+			Op:    wrapper.Op,
+			Path:  wrapper.Path,
+			Cause: wrapper.Err.Error(),
+		}
+
+	case *os.LinkError:
+		traceErr.Path = &TracePathError{
+			Op:    wrapper.Op,
+			Path:  wrapper.Old,
+			New:   wrapper.New,
+			Link:  true,
+			Cause: wrapper.Err.Error(),
+		}
+	}
+
+	return traceErr
+}
+
+func (e *TraceError) error() error {
+	if e == nil {
+		return nil
+	}
+
+	replayed := &replayedError{message: e.Message}
+
+	for _, known := range traceErrorKinds {
+		if slices.Contains(e.Kinds, known.kind) {
+			replayed.kinds = append(replayed.kinds, known.err)
+		}
+	}
+
+	if e.Errno != 0 {
+		replayed.kinds = append(replayed.kinds, syscall.Errno(e.Errno))
+	}
+
+	if e.Path == nil {
+		return replayed
+	}
+
+	replayed.message = e.Path.Cause
+
+	if e.Path.Link {
+		return &os.LinkError{Op: e.Path.Op, Old: e.Path.Path, New: e.Path.New, Err: replayed}
+	}
+
+	return &fs.PathError{Op: e.Path.Op, Path: e.Path.Path, Err: replayed}
+}
+
+type replayedError struct {
+	message string
+	kinds   []error
+}
+
+func (e *replayedError) Error() string {
+	return e.message
+}
+
+func (e *replayedError) Unwrap() []error {
+	return e.kinds
+}
+
+// TraceCall is a single call made to a file system, with its arguments
+// and results
+type TraceCall struct {
+	// Op is the name of the method invoked, or "Write" and "Close" for a
+	// file returned by Create
+	Op string `json:"op"`
+	// Args are the arguments of the call, formatted as strings
+	Args []string `json:"args,omitempty"`
+	// Input is the content written
+	Input []byte `json:"input,omitempty"`
+	// Output is the content read
+	Output []byte `json:"output,omitempty"`
+	// Result is the result of a call that returns a bool or a path
+	Result string `json:"result,omitempty"`
+	// Info is the info of the item returned by Stat or Open
+	Info *TraceInfo `json:"info,omitempty"`
+	// Entries are the directory entries returned by ReadDir or Open
+	Entries []*TraceInfo `json:"entries,omitempty"`
+	// Err is the error returned
+	Err *TraceError `json:"err,omitempty"`
+}
+
+// Trace is a sequence of calls made to a file system
+type Trace []TraceCall
+
+// WriteTo writes the trace to w as JSON lines, ie one call per line
+func (t Trace) WriteTo(w io.Writer) (int64, error) {
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+
+	for i := range t {
+		if err := encoder.Encode(&t[i]); err != nil {
+			return 0, err
+		}
+	}
+
+	return buffer.WriteTo(w)
+}
+
+// ReadTrace reads a trace written by Trace.WriteTo
+func ReadTrace(r io.Reader) (Trace, error) {
+	trace := Trace{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var call TraceCall
+		if err := json.Unmarshal(scanner.Bytes(), &call); err != nil {
+			return nil, err
+		}
+
+		trace = append(trace, call)
+	}
+
+	return trace, scanner.Err()
+}
+
+func formatPerm(perm os.FileMode) string {
+	return strconv.FormatUint(uint64(perm), 8)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// traceFile is an open file or directory, whose content has been captured
+type traceFile struct {
+	*bytes.Reader
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func newTraceFile(name string, info fs.FileInfo, content []byte, entries []fs.DirEntry) *traceFile {
+	return &traceFile{
+		Reader:  bytes.NewReader(content),
+		name:    name,
+		info:    info,
+		entries: entries,
+	}
+}
+
+func (f *traceFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *traceFile) Close() error {
+	return nil
+}
+
+func (f *traceFile) ReadDir(count int) ([]fs.DirEntry, error) {
+	if !f.info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	remaining := len(f.entries) - f.offset
+
+	if count > 0 && remaining == 0 {
+		return nil, io.EOF
+	}
+
+	if count <= 0 || count > remaining {
+		count = remaining
+	}
+
+	entries := f.entries[f.offset : f.offset+count]
+	f.offset += count
+
+	return entries, nil
+}
@@ -0,0 +1,116 @@
+package luna
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"testing/fstest"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"golang.org/x/tools/txtar"
+)
+
+// The names of the files in a txtar archive are slash separated paths,
+// relative to the root of the tree. Since txtar has no notion of a
+// directory, a name with a trailing '/' (and no content) denotes an
+// (empty) directory, as it does in a Tree.
+
+// FromTxtar creates a MemFS containing the files of the archive, along
+// with their parent directories. If the archive contains the same name
+// more than once, the last one wins.
+func FromTxtar(archive *txtar.Archive) *MemFS {
+	memFS := NewMemFS()
+
+	for _, file := range archive.Files {
+		name, dir := path.Clean(file.Name), strings.HasSuffix(file.Name, "/")
+
+		if parent := path.Dir(name); parent != "." {
+			_ = memFS.MakeDirAll(parent, lab.Perms.Dir)
+		}
+
+		if dir {
+			_ = memFS.MakeDirAll(name, lab.Perms.Dir)
+
+			continue
+		}
+
+		memFS.MapFS[name] = &fstest.MapFile{
+			Data: slices.Clone(file.Data),
+			Mode: lab.Perms.File,
+		}
+	}
+
+	return memFS
+}
+
+// LoadTxtar writes the files of the archive into dst, relative to its
+// root, creating parent directories as required.
+func LoadTxtar(dst nef.WriterFS, archive *txtar.Archive) error {
+	for _, file := range archive.Files {
+		if name, dir := strings.CutSuffix(file.Name, "/"); dir {
+			if err := dst.MakeDirAll(name, lab.Perms.Dir); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if parent := path.Dir(file.Name); parent != "." {
+			if err := dst.MakeDirAll(parent, lab.Perms.Dir); err != nil {
+				return err
+			}
+		}
+
+		if err := dst.WriteFile(file.Name, file.Data, lab.Perms.File); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ToTxtar creates an archive of the files under root in src, in lexical
+// order of their names, which are relative to root. Empty directories
+// are included so that the tree is preserved.
+func ToTxtar(src nef.ReaderFS, root string) (*txtar.Archive, error) {
+	tree, err := ReadTree(src, root)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(tree))
+
+	for name := range tree {
+		if !strings.HasSuffix(name, "/") || !tree.hasChildren(name) {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	archive := &txtar.Archive{
+		Files: make([]txtar.File, 0, len(names)),
+	}
+
+	for _, name := range names {
+		archive.Files = append(archive.Files, txtar.File{
+			Name: name,
+			Data: []byte(tree[name]),
+		})
+	}
+
+	return archive, nil
+}
+
+// hasChildren reports whether the directory denoted by dir (with a
+// trailing '/') contains any items.
+func (t Tree) hasChildren(dir string) bool {
+	for name := range t {
+		if name != dir && strings.HasPrefix(name, dir) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,79 @@
+package luna_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/txtar"
+
+	nef "github.com/snivilised/nefilim"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+var _ = Describe("Txtar", func() {
+	var archive *txtar.Archive
+
+	BeforeEach(func() {
+		archive = txtar.Parse([]byte(`golden test
+-- site/index.html --
+<html/>
+-- site/css/site.css --
+body {}
+-- site/empty/ --
+-- README --
+read me
+`))
+	})
+
+	Context("FromTxtar", func() {
+		It("🧪 should: create MemFS", func() {
+			memFS := luna.FromTxtar(archive)
+
+			Expect(".").To(luna.MatchTree(memFS, luna.Tree{
+				"site/index.html":   "<html/>\n",
+				"site/css/site.css": "body {}\n",
+				"site/empty/":       "",
+				"README":            "read me\n",
+			}))
+		})
+	})
+
+	Context("LoadTxtar", func() {
+		It("🧪 should: write files into file system", func() {
+			fS := nef.NewUniversalFS(nef.Rel{
+				Root: GinkgoT().TempDir(),
+			})
+			Expect(luna.LoadTxtar(fS, archive)).To(Succeed())
+
+			Expect(luna.AsDirectory("site")).To(luna.MatchTree(fS, luna.Tree{
+				"index.html":   "<html/>\n",
+				"css/site.css": "body {}\n",
+				"empty/":       "",
+			}))
+			Expect(luna.AsFile("README")).To(luna.HaveFileContent(fS, "read me\n"))
+		})
+	})
+
+	Context("ToTxtar", func() {
+		It("🧪 should: create archive in lexical order", func() {
+			exported, err := luna.ToTxtar(luna.FromTxtar(archive), "site")
+			Expect(err).To(Succeed())
+			Expect(string(txtar.Format(exported))).To(Equal(`-- css/site.css --
+body {}
+-- empty/ --
+-- index.html --
+<html/>
+`))
+		})
+
+		It("🧪 should: round trip", func() {
+			fS := nef.NewUniversalFS(nef.Rel{
+				Root: GinkgoT().TempDir(),
+			})
+			Expect(luna.LoadTxtar(fS, archive)).To(Succeed())
+
+			exported, err := luna.ToTxtar(fS, ".")
+			Expect(err).To(Succeed())
+			Expect(luna.FromTxtar(exported).MapFS).To(Equal(luna.FromTxtar(archive).MapFS))
+		})
+	})
+})
@@ -0,0 +1,273 @@
+package nef
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 🔥 Typed paths: all the file system interfaces take raw strings, which
+// means that it is easy to pass an absolute path to a relative file system
+// (where it is rejected at runtime by fs.ValidPath) or to join paths
+// computed by the wrong path calculator. RelPath and AbsPath are validated
+// once at construction, so that the operations performed on them (Join,
+// Parent, Base) always result in valid paths of the same kind. Together
+// with TypedFS, these allow path mistakes to be caught at compile time.
+
+type (
+	// RelPath is a path that is relative to the root of a relative file
+	// system. It is always valid according to fs.ValidPath, so it is
+	// unrooted, '/' separated and does not contain '.' or '..' elements,
+	// except for the special case '.', which denotes the root.
+	RelPath struct {
+		path string
+	}
+
+	// AbsPath is an absolute path on the local file system, in the form
+	// produced by filepath.Clean.
+	AbsPath struct {
+		path string
+	}
+
+	// TypedPath is the constraint satisfied by the typed paths
+	TypedPath interface {
+		RelPath | AbsPath
+		String() string
+	}
+)
+
+// NewRelPath creates a RelPath, returning an invalid path error if p is
+// not valid according to fs.ValidPath.
+func NewRelPath(p string) (RelPath, error) {
+	if !fs.ValidPath(p) {
+		return RelPath{}, NewInvalidPathError("RelPath", p)
+	}
+
+	return RelPath{path: p}, nil
+}
+
+// RootRelPath returns the RelPath that denotes the root of a relative
+// file system.
+func RootRelPath() RelPath {
+	return RelPath{path: "."}
+}
+
+// String returns the path as a string
+func (p RelPath) String() string {
+	if p.path == "" {
+		return "."
+	}
+
+	return p.path
+}
+
+// IsRoot reports whether the path denotes the root
+func (p RelPath) IsRoot() bool {
+	return p.String() == "."
+}
+
+// Join joins the elements onto this path. Since all the elements are
+// valid relative paths, the result is also a valid relative path.
+func (p RelPath) Join(elements ...RelPath) RelPath {
+	segments := make([]string, 0, len(elements)+1)
+	segments = append(segments, p.String())
+
+	for _, e := range elements {
+		segments = append(segments, e.String())
+	}
+
+	return RelPath{path: path.Join(segments...)}
+}
+
+// Parent returns the parent of this path; the parent of the root is
+// the root.
+func (p RelPath) Parent() RelPath {
+	return RelPath{path: path.Dir(p.String())}
+}
+
+// Base returns the last element of this path
+func (p RelPath) Base() string {
+	return path.Base(p.String())
+}
+
+// ToAbs converts this path to an absolute path, relative to root, which
+// would typically be the root of the file system this path belongs to.
+func (p RelPath) ToAbs(root AbsPath) AbsPath {
+	return root.Join(p)
+}
+
+// NewAbsPath creates an AbsPath, returning an invalid path error if p is
+// not absolute. The path is cleaned.
+func NewAbsPath(p string) (AbsPath, error) {
+	if !filepath.IsAbs(p) {
+		return AbsPath{}, NewInvalidPathError("AbsPath", p)
+	}
+
+	return AbsPath{path: filepath.Clean(p)}, nil
+}
+
+// String returns the path as a string
+func (p AbsPath) String() string {
+	return p.path
+}
+
+// Join joins the relative elements onto this path.
+func (p AbsPath) Join(elements ...RelPath) AbsPath {
+	segments := make([]string, 0, len(elements)+1)
+	segments = append(segments, p.path)
+
+	for _, e := range elements {
+		segments = append(segments, filepath.FromSlash(e.String()))
+	}
+
+	return AbsPath{path: filepath.Join(segments...)}
+}
+
+// Parent returns the parent of this path; the parent of the root is
+// the root.
+func (p AbsPath) Parent() AbsPath {
+	return AbsPath{path: filepath.Dir(p.path)}
+}
+
+// Base returns the last element of this path
+func (p AbsPath) Base() string {
+	return filepath.Base(p.path)
+}
+
+// RelTo converts this path to a path relative to root. An invalid path
+// error is returned if this path does not reside within root.
+func (p AbsPath) RelTo(root AbsPath) (RelPath, error) {
+	rel, err := filepath.Rel(root.path, p.path)
+	if err != nil {
+		return RelPath{}, newInvalidPathError("RelTo", p.path, ReasonOutsideRoot, err)
+	}
+
+	rel = filepath.ToSlash(rel)
+
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return RelPath{}, newInvalidPathError("RelTo", p.path, ReasonOutsideRoot, nil)
+	}
+
+	return NewRelPath(rel)
+}
+
+// TypedFS is an adapter over UniversalFS, whose methods take typed paths
+// instead of strings. TypedFS[RelPath] can only be created over a relative
+// file system and TypedFS[AbsPath] over an absolute one, so passing the
+// wrong kind of path to a file system results in a compile time error.
+type TypedFS[P TypedPath] struct {
+	fS UniversalFS
+}
+
+// NewRelativeTypedFS creates a TypedFS that takes RelPath arguments. An
+// error is returned if fS is not a relative file system.
+func NewRelativeTypedFS(fS UniversalFS) (*TypedFS[RelPath], error) {
+	if !fS.IsRelative() {
+		return nil, newInvalidPathError("NewRelativeTypedFS", "", ReasonFileSystemMismatch,
+			errors.New("file system is not relative"),
+		)
+	}
+
+	return &TypedFS[RelPath]{fS: fS}, nil
+}
+
+// NewAbsoluteTypedFS creates a TypedFS that takes AbsPath arguments. An
+// error is returned if fS is a relative file system.
+func NewAbsoluteTypedFS(fS UniversalFS) (*TypedFS[AbsPath], error) {
+	if fS.IsRelative() {
+		return nil, newInvalidPathError("NewAbsoluteTypedFS", "", ReasonFileSystemMismatch,
+			errors.New("file system is relative"),
+		)
+	}
+
+	return &TypedFS[AbsPath]{fS: fS}, nil
+}
+
+// FS returns the underlying file system
+func (f *TypedFS[P]) FS() UniversalFS {
+	return f.fS
+}
+
+// Open opens the named file
+func (f *TypedFS[P]) Open(name P) (fs.File, error) {
+	return f.fS.Open(name.String())
+}
+
+// Stat returns a FileInfo describing the named file
+func (f *TypedFS[P]) Stat(name P) (fs.FileInfo, error) {
+	return f.fS.Stat(name.String())
+}
+
+// ReadDir reads the named directory
+func (f *TypedFS[P]) ReadDir(name P) ([]fs.DirEntry, error) {
+	return f.fS.ReadDir(name.String())
+}
+
+// ReadFile reads the named file
+func (f *TypedFS[P]) ReadFile(name P) ([]byte, error) {
+	return f.fS.ReadFile(name.String())
+}
+
+// FileExists does file exist at the path specified
+func (f *TypedFS[P]) FileExists(name P) bool {
+	return f.fS.FileExists(name.String())
+}
+
+// DirectoryExists does directory exist at the path specified
+func (f *TypedFS[P]) DirectoryExists(name P) bool {
+	return f.fS.DirectoryExists(name.String())
+}
+
+// MakeDir creates a new directory
+func (f *TypedFS[P]) MakeDir(name P, perm os.FileMode) error {
+	return f.fS.MakeDir(name.String(), perm)
+}
+
+// MakeDirAll creates a directory along with any necessary parents
+func (f *TypedFS[P]) MakeDirAll(name P, perm os.FileMode) error {
+	return f.fS.MakeDirAll(name.String(), perm)
+}
+
+// Create creates or truncates the named file
+func (f *TypedFS[P]) Create(name P) (fs.File, error) {
+	return f.fS.Create(name.String())
+}
+
+// WriteFile writes data to the named file
+func (f *TypedFS[P]) WriteFile(name P, data []byte, perm os.FileMode) error {
+	return f.fS.WriteFile(name.String(), data, perm)
+}
+
+// Remove removes the named file or (empty) directory
+func (f *TypedFS[P]) Remove(name P) error {
+	return f.fS.Remove(name.String())
+}
+
+// RemoveAll removes path and any children it contains
+func (f *TypedFS[P]) RemoveAll(name P) error {
+	return f.fS.RemoveAll(name.String())
+}
+
+// Rename renames (moves) from to to
+func (f *TypedFS[P]) Rename(from, to P) error {
+	return f.fS.Rename(from.String(), to.String())
+}
+
+// Move moves an item from one path to another
+func (f *TypedFS[P]) Move(from, to P) error {
+	return f.fS.Move(from.String(), to.String())
+}
+
+// Change changes the name of an item, within the same directory; to is
+// a name not a path.
+func (f *TypedFS[P]) Change(from P, to string) error {
+	return f.fS.Change(from.String(), to)
+}
+
+// Copy copies an item from one path to another
+func (f *TypedFS[P]) Copy(from, to P) error {
+	return f.fS.Copy(from.String(), to.String())
+}
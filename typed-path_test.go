@@ -0,0 +1,118 @@
+package nef_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nef "github.com/snivilised/nefilim"
+	lab "github.com/snivilised/nefilim/internal/laboratory"
+	"github.com/snivilised/nefilim/test/luna"
+)
+
+var _ = Describe("typed paths", func() {
+	Context("RelPath", func() {
+		When("given: invalid path", func() {
+			It("🧪 should: reject path at construction", func() {
+				for _, p := range []string{"/foo", "foo/", "foo/../bar", ""} {
+					_, err := nef.NewRelPath(p)
+					Expect(nef.IsInvalidPathError(err)).To(BeTrue(), p)
+				}
+			})
+		})
+
+		When("given: valid path", func() {
+			It("🧪 should: join, find parent and base", func() {
+				foo, err := nef.NewRelPath("foo")
+				Expect(err).To(Succeed())
+				barBaz, err := nef.NewRelPath("bar/baz.txt")
+				Expect(err).To(Succeed())
+
+				joined := foo.Join(barBaz)
+				Expect(joined.String()).To(Equal("foo/bar/baz.txt"))
+				Expect(joined.Parent().String()).To(Equal("foo/bar"))
+				Expect(joined.Base()).To(Equal("baz.txt"))
+				Expect(foo.Parent().IsRoot()).To(BeTrue())
+				Expect(nef.RootRelPath().Join(foo).String()).To(Equal("foo"))
+			})
+		})
+	})
+
+	Context("AbsPath", func() {
+		When("given: relative path", func() {
+			It("🧪 should: reject path at construction", func() {
+				_, err := nef.NewAbsPath("foo/bar")
+				Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+			})
+		})
+
+		When("given: path within root", func() {
+			It("🧪 should: convert to and from relative path", func() {
+				root, err := nef.NewAbsPath(filepath.Join(string(filepath.Separator), "home", "root"))
+				Expect(err).To(Succeed())
+				rel, err := nef.NewRelPath("foo/bar.txt")
+				Expect(err).To(Succeed())
+
+				abs := rel.ToAbs(root)
+				Expect(abs.String()).To(Equal(filepath.Join(root.String(), "foo", "bar.txt")))
+				Expect(abs.Base()).To(Equal("bar.txt"))
+				Expect(abs.Parent().Parent()).To(Equal(root))
+
+				back, err := abs.RelTo(root)
+				Expect(err).To(Succeed())
+				Expect(back).To(Equal(rel))
+			})
+		})
+
+		When("given: path outside root", func() {
+			It("🧪 should: return invalid path error", func() {
+				root, _ := nef.NewAbsPath(filepath.Join(string(filepath.Separator), "home", "root"))
+				other, _ := nef.NewAbsPath(filepath.Join(string(filepath.Separator), "home", "other"))
+
+				_, err := other.RelTo(root)
+				Expect(nef.IsInvalidPathError(err)).To(BeTrue())
+
+				var pathErr *nef.InvalidPathError
+				Expect(errors.As(err, &pathErr)).To(BeTrue())
+				Expect(pathErr.Reason).To(Equal(nef.ReasonOutsideRoot))
+			})
+		})
+	})
+
+	Context("TypedFS", func() {
+		When("given: mismatched file system", func() {
+			It("🧪 should: reject file system", func() {
+				_, err := nef.NewRelativeTypedFS(nef.NewUniversalABS())
+				Expect(err).NotTo(Succeed())
+
+				_, err = nef.NewAbsoluteTypedFS(luna.NewMemFS())
+				Expect(err).NotTo(Succeed())
+			})
+		})
+
+		When("given: relative file system", func() {
+			It("🧪 should: invoke operations with typed paths", func() {
+				typed, err := nef.NewRelativeTypedFS(luna.NewMemFS())
+				Expect(err).To(Succeed())
+
+				dir, _ := nef.NewRelPath("scratch")
+				file, _ := nef.NewRelPath("foo.txt")
+				path := dir.Join(file)
+
+				Expect(typed.MakeDirAll(dir, lab.Perms.Dir)).To(Succeed())
+				Expect(typed.WriteFile(path, []byte("foo"), lab.Perms.File)).To(Succeed())
+				Expect(typed.FileExists(path)).To(BeTrue())
+				Expect(typed.DirectoryExists(dir)).To(BeTrue())
+
+				content, err := typed.ReadFile(path)
+				Expect(err).To(Succeed())
+				Expect(content).To(Equal([]byte("foo")))
+
+				Expect(typed.Remove(path)).To(Succeed())
+				Expect(typed.FileExists(path)).To(BeFalse())
+			})
+		})
+	})
+})